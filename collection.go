@@ -0,0 +1,42 @@
+package dom
+
+import "github.com/dennwc/dom/js"
+
+// https://developer.mozilla.org/en-US/docs/Web/API/HTMLCollection
+
+func AsHTMLCollection(v js.Value) *HTMLCollection {
+	if !v.Valid() {
+		return nil
+	}
+	return &HTMLCollection{v: v}
+}
+
+// HTMLCollection is a live list of elements. It's updated automatically when the document changes.
+type HTMLCollection struct {
+	v js.Value
+}
+
+// JSValue implements js.Wrapper.
+func (c *HTMLCollection) JSValue() js.Ref {
+	return c.v.JSValue()
+}
+
+// Length returns the number of elements in the collection.
+func (c *HTMLCollection) Length() int {
+	return c.v.Get("length").Int()
+}
+
+// Item returns an element at a given index, or nil if the index is out of range.
+func (c *HTMLCollection) Item(i int) *Element {
+	return AsElement(c.v.Call("item", i))
+}
+
+// NamedItem returns the first element with a given id or name, or nil if there is no such element.
+func (c *HTMLCollection) NamedItem(name string) *Element {
+	return AsElement(c.v.Call("namedItem", name))
+}
+
+// Elements returns a snapshot of elements that are currently in the collection.
+func (c *HTMLCollection) Elements() NodeList {
+	return AsNodeList(c.v)
+}
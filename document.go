@@ -7,7 +7,7 @@ func GetDocument() *Document {
 	if !doc.Valid() {
 		return nil
 	}
-	return &Document{NodeBase{v: doc}}
+	return &Document{NodeBase{newEventTarget(doc)}}
 }
 
 func NewElement(tag string) *Element {
@@ -34,6 +34,43 @@ func (d *Document) CreateElementNS(ns string, tag string) *Element {
 	v := d.v.Call("createElementNS", ns, tag)
 	return AsElement(v)
 }
+
+// CreateTextNode creates a new text node.
+func (d *Document) CreateTextNode(s string) *Text {
+	if d == nil {
+		return nil
+	}
+	v := d.v.Call("createTextNode", s)
+	return AsText(v)
+}
+
+// CreateComment creates a new comment node.
+func (d *Document) CreateComment(s string) *Comment {
+	if d == nil {
+		return nil
+	}
+	v := d.v.Call("createComment", s)
+	return AsComment(v)
+}
+
+// CreateDocumentFragment creates a new empty document fragment.
+func (d *Document) CreateDocumentFragment() *DocumentFragment {
+	if d == nil {
+		return nil
+	}
+	v := d.v.Call("createDocumentFragment")
+	return AsDocumentFragment(v)
+}
+
+// Doctype returns the document type declaration, or nil if the document has none.
+func (d *Document) Doctype() *DocumentType {
+	if d == nil {
+		return nil
+	}
+	v := d.v.Get("doctype")
+	return AsDocumentType(v)
+}
+
 func (d *Document) GetElementById(id string) *Element {
 	if d == nil {
 		return nil
@@ -48,6 +85,26 @@ func (d *Document) GetElementsByTagName(tag string) NodeList {
 	v := d.v.Call("getElementsByTagName", tag)
 	return AsNodeList(v)
 }
+
+// GetElementsByTagNameLive is the same as GetElementsByTagName, but returns a live collection instead of a snapshot.
+func (d *Document) GetElementsByTagNameLive(tag string) *HTMLCollection {
+	if d == nil {
+		return nil
+	}
+	v := d.v.Call("getElementsByTagName", tag)
+	return AsHTMLCollection(v)
+}
+
+// GetElementsByClassName returns a live collection of elements that have all given classes.
+// Classes are separated by whitespace.
+func (d *Document) GetElementsByClassName(class string) *HTMLCollection {
+	if d == nil {
+		return nil
+	}
+	v := d.v.Call("getElementsByClassName", class)
+	return AsHTMLCollection(v)
+}
+
 func (d *Document) QuerySelector(qu string) *Element {
 	if d == nil {
 		return nil
@@ -0,0 +1,76 @@
+package dom
+
+import "github.com/dennwc/dom/js"
+
+// https://developer.mozilla.org/en-US/docs/Web/API/DocumentFragment
+
+func AsDocumentFragment(v js.Value) *DocumentFragment {
+	if !v.Valid() {
+		return nil
+	}
+	return &DocumentFragment{NodeBase{newEventTarget(v)}}
+}
+
+var _ Node = (*DocumentFragment)(nil)
+
+// DocumentFragment is a lightweight container of nodes. When it's inserted into the document,
+// its children are moved to the insertion point, and the fragment becomes empty.
+type DocumentFragment struct {
+	NodeBase
+}
+
+// GetElementById returns a descendant element with a given id.
+func (f *DocumentFragment) GetElementById(id string) *Element {
+	return AsElement(f.v.Call("getElementById", id))
+}
+
+// ChildElementCount returns the number of child elements.
+func (f *DocumentFragment) ChildElementCount() int {
+	return f.v.Get("childElementCount").Int()
+}
+
+// Children returns a live collection of child elements.
+func (f *DocumentFragment) Children() *HTMLCollection {
+	return AsHTMLCollection(f.v.Get("children"))
+}
+
+// QuerySelector returns the first descendant element that matches a given CSS selector, or nil if there is none.
+func (f *DocumentFragment) QuerySelector(qu string) *Element {
+	return AsElement(f.v.Call("querySelector", qu))
+}
+
+// QuerySelectorAll returns all descendant elements that match a given CSS selector.
+func (f *DocumentFragment) QuerySelectorAll(qu string) NodeList {
+	return AsNodeList(f.v.Call("querySelectorAll", qu))
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/API/DocumentType
+
+func AsDocumentType(v js.Value) *DocumentType {
+	if !v.Valid() {
+		return nil
+	}
+	return &DocumentType{NodeBase{newEventTarget(v)}}
+}
+
+var _ Node = (*DocumentType)(nil)
+
+// DocumentType is a doctype declaration of the document.
+type DocumentType struct {
+	NodeBase
+}
+
+// Name returns the name of the document type, for example "html".
+func (d *DocumentType) Name() string {
+	return d.v.Get("name").String()
+}
+
+// PublicID returns the public identifier of the document type.
+func (d *DocumentType) PublicID() string {
+	return d.v.Get("publicId").String()
+}
+
+// SystemID returns the system identifier of the document type.
+func (d *DocumentType) SystemID() string {
+	return d.v.Get("systemId").String()
+}
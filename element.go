@@ -12,9 +12,13 @@ func AsElement(v js.Value) *Element {
 	if !v.Valid() {
 		return nil
 	}
-	return &Element{NodeBase{v: v}}
+	e := &Element{NodeBase: NodeBase{newEventTarget(v)}}
+	e.eventHandlers.t = &e.eventTarget
+	return e
 }
 
+// AsNodeList converts a JS list of elements to NodeList. All items are converted to *Element,
+// thus it should not be used for lists that may contain other node types, see AsNodes.
 func AsNodeList(v js.Value) NodeList {
 	if !v.Valid() {
 		return nil
@@ -30,6 +34,7 @@ var _ Node = (*Element)(nil)
 
 type Element struct {
 	NodeBase
+	eventHandlers
 }
 
 type Position string
@@ -223,6 +228,42 @@ func (e *Element) SetUndoScope(v bool) {
 	e.v.Set("undoScope", v)
 }
 
+// FirstElementChild returns the first child element, or nil if the element has no child elements.
+func (e *Element) FirstElementChild() *Element {
+	return AsElement(e.v.Get("firstElementChild"))
+}
+
+// LastElementChild returns the last child element, or nil if the element has no child elements.
+func (e *Element) LastElementChild() *Element {
+	return AsElement(e.v.Get("lastElementChild"))
+}
+
+// NextElementSibling returns the next element with the same parent, or nil if the element is the last one.
+func (e *Element) NextElementSibling() *Element {
+	return AsElement(e.v.Get("nextElementSibling"))
+}
+
+// PreviousElementSibling returns the previous element with the same parent, or nil if the element is the first one.
+func (e *Element) PreviousElementSibling() *Element {
+	return AsElement(e.v.Get("previousElementSibling"))
+}
+
+// ChildElementCount returns the number of child elements.
+func (e *Element) ChildElementCount() int {
+	return e.v.Get("childElementCount").Int()
+}
+
+// Children returns a live collection of child elements.
+func (e *Element) Children() *HTMLCollection {
+	return AsHTMLCollection(e.v.Get("children"))
+}
+
+// CloneElement returns a copy of the element. If deep is set, all descendants are copied as well.
+// Event listeners are not copied.
+func (e *Element) CloneElement(deep bool) *Element {
+	return AsElement(e.v.Call("cloneNode", deep))
+}
+
 // Methods
 
 func (e *Element) SetAttribute(k string, v interface{}) {
@@ -237,33 +278,54 @@ func (e *Element) RemoveAttribute(k string) {
 	e.v.Call("removeAttribute", k)
 }
 
-func (e *Element) GetBoundingClientRect() Rect {
-	rv := e.v.Call("getBoundingClientRect")
-	x, y := rv.Get("x").Int(), rv.Get("y").Int()
-	w, h := rv.Get("width").Int(), rv.Get("height").Int()
-	return Rect{Min: Point{x, y}, Max: Point{x + w, y + h}}
+// QuerySelector returns the first descendant element that matches a given CSS selector, or nil if there is none.
+func (e *Element) QuerySelector(qu string) *Element {
+	return AsElement(e.v.Call("querySelector", qu))
+}
+
+// QuerySelectorAll returns all descendant elements that match a given CSS selector.
+func (e *Element) QuerySelectorAll(qu string) NodeList {
+	return AsNodeList(e.v.Call("querySelectorAll", qu))
 }
 
-func (e *Element) onMouseEvent(typ string, h MouseEventHandler) {
-	e.AddEventListener(typ, func(e Event) {
-		h(e.(*MouseEvent))
-	})
+// Closest returns the closest ancestor of the element (or the element itself) that matches a given CSS selector,
+// or nil if there is none.
+func (e *Element) Closest(qu string) *Element {
+	return AsElement(e.v.Call("closest", qu))
 }
 
-func (e *Element) OnClick(h MouseEventHandler) {
-	e.onMouseEvent("click", h)
+// Matches checks if the element matches a given CSS selector.
+func (e *Element) Matches(qu string) bool {
+	return e.v.Call("matches", qu).Bool()
 }
 
-func (e *Element) OnMouseDown(h MouseEventHandler) {
-	e.onMouseEvent("mousedown", h)
+// GetElementsByClassName returns a live collection of descendant elements that have all given classes.
+// Classes are separated by whitespace.
+func (e *Element) GetElementsByClassName(class string) *HTMLCollection {
+	return AsHTMLCollection(e.v.Call("getElementsByClassName", class))
 }
 
-func (e *Element) OnMouseMove(h MouseEventHandler) {
-	e.onMouseEvent("mousemove", h)
+// GetElementsByTagName returns a live collection of descendant elements with a given tag name.
+func (e *Element) GetElementsByTagName(tag string) *HTMLCollection {
+	return AsHTMLCollection(e.v.Call("getElementsByTagName", tag))
 }
 
-func (e *Element) OnMouseUp(h MouseEventHandler) {
-	e.onMouseEvent("mouseup", h)
+var rectProps = js.NewProps("x", "y", "width", "height")
+
+// GetBoundingClientRectF returns the size of an element and its position relative to the viewport.
+func (e *Element) GetBoundingClientRectF() DOMRect {
+	var buf [4]float64
+	r := rectProps.Floats(e.v.Call("getBoundingClientRect"), buf[:0])
+	return DOMRect{X: r[0], Y: r[1], Width: r[2], Height: r[3]}
+}
+
+// GetBoundingClientRect is the same as GetBoundingClientRectF, but truncates the position and size to integers.
+// Use GetBoundingClientRectF().Rect() to get the smallest integer rectangle that contains the element.
+func (e *Element) GetBoundingClientRect() Rect {
+	r := e.GetBoundingClientRectF()
+	x, y := toInt(r.X), toInt(r.Y)
+	w, h := toInt(r.Width), toInt(r.Height)
+	return Rect{Min: Point{X: x, Y: y}, Max: Point{X: x + w, Y: y + h}}
 }
 
 type AttachShadowOpts struct {
@@ -0,0 +1,70 @@
+//+build !wasm
+
+package dom
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestElementQuery(t *testing.T) {
+	d := setHTML(t, `<div id="a" class="box"><section><p class="x">1</p><p>2</p></section></div><p class="x">3</p>`)
+	a := d.GetElementById("a")
+
+	p := a.QuerySelector("p.x")
+	require.NotNil(t, p)
+	require.Equal(t, "1", p.TextContent())
+	require.Nil(t, a.QuerySelector("span"))
+	require.Len(t, a.QuerySelectorAll("p"), 2)
+	require.Len(t, d.QuerySelectorAll("p.x"), 2)
+
+	require.True(t, p.Matches("section > .x"))
+	require.False(t, p.Matches("div > .x"))
+	require.Equal(t, "a", p.Closest(".box").Id())
+	require.True(t, p.IsSameNode(p.Closest("p")))
+	require.Nil(t, p.Closest("ul"))
+}
+
+func TestHTMLCollection(t *testing.T) {
+	d := setHTML(t, `<ul id="list"><li class="x">1</li><li>2</li></ul>`)
+	list := d.GetElementById("list")
+
+	items := list.GetElementsByClassName("x")
+	children := list.Children()
+	tags := d.GetElementsByTagNameLive("li")
+	require.Equal(t, 1, items.Length())
+	require.Equal(t, 2, children.Length())
+	require.Equal(t, 2, tags.Length())
+	snapshot := children.Elements()
+
+	li := d.CreateElement("li")
+	li.SetClassName("x")
+	li.SetAttribute("name", "third")
+	list.Append(li)
+	require.Equal(t, 2, items.Length())
+	require.Equal(t, 3, children.Length())
+	require.Equal(t, 3, tags.Length())
+	require.Len(t, snapshot, 2)
+	require.True(t, li.IsSameNode(items.Item(1)))
+	require.True(t, li.IsSameNode(children.NamedItem("third")))
+	require.Nil(t, children.Item(3))
+
+	list.FirstElementChild().Remove()
+	require.Equal(t, 1, items.Length())
+	require.Equal(t, "2", children.Item(0).TextContent())
+}
+
+func TestDOMRect(t *testing.T) {
+	r := DOMRect{X: 1.5, Y: -0.5, Width: 2, Height: 1}
+	require.Equal(t, image.Rect(1, -1, 4, 1), r.Rect())
+
+	nan := math.NaN()
+	r = DOMRect{X: nan, Y: 1, Width: nan, Height: math.Inf(1)}
+	require.Equal(t, image.Rect(0, 1, 0, 0), r.Rect())
+
+	d := setHTML(t, `<div id="a"></div>`)
+	require.Equal(t, Rect{}, d.GetElementById("a").GetBoundingClientRect())
+}
@@ -1,14 +1,35 @@
 package dom
 
 import (
+	"math"
+	"sync"
+
 	"github.com/dennwc/dom/js"
 )
 
 type EventTarget interface {
 	js.Wrapper
-	AddEventListener(typ string, h EventHandler)
-	// TODO: removeEventListener
-	// TODO: dispatchEvent
+	// AddEventListener adds an event handler for a given event type.
+	// It returns a function that removes the listener and releases its callback.
+	AddEventListener(typ string, h EventHandler) func()
+	// AddEventListenerOpts is the same as AddEventListener, but accepts additional options.
+	AddEventListenerOpts(typ string, h EventHandler, opts *ListenerOptions) func()
+	// DispatchEvent dispatches an event to the target. It returns false if the event is cancelable
+	// and one of the handlers called PreventDefault.
+	DispatchEvent(e Event) bool
+}
+
+// ListenerOptions is a set of options for AddEventListenerOpts.
+type ListenerOptions struct {
+	// Capture invokes the handler in the capturing phase, before handlers of descendants of the target.
+	Capture bool
+	// Once removes the listener after it's invoked for the first time.
+	Once bool
+	// Passive indicates that the handler never calls PreventDefault. It allows the browser to scroll
+	// without waiting for the handler.
+	Passive bool
+	// Signal is an AbortSignal that removes the listener when aborted. Optional.
+	Signal js.Value
 }
 
 type Event interface {
@@ -32,6 +53,8 @@ type EventHandler func(Event)
 
 type EventConstructor func(e BaseEvent) Event
 
+// RegisterEventType registers a Go type for a given JS event class. Types registered later take priority,
+// thus subclasses must be registered after their parent classes.
 func RegisterEventType(typ string, fnc EventConstructor) {
 	cl := js.Get(typ)
 	if !cl.Valid() {
@@ -46,6 +69,166 @@ func init() {
 	RegisterEventType("MouseEvent", func(e BaseEvent) Event {
 		return &MouseEvent{e}
 	})
+	RegisterEventType("CustomEvent", func(e BaseEvent) Event {
+		return &CustomEvent{e}
+	})
+	RegisterEventType("KeyboardEvent", func(e BaseEvent) Event {
+		return &KeyboardEvent{e}
+	})
+	RegisterEventType("InputEvent", func(e BaseEvent) Event {
+		return &InputEvent{e}
+	})
+	RegisterEventType("FocusEvent", func(e BaseEvent) Event {
+		return &FocusEvent{e}
+	})
+	RegisterEventType("TouchEvent", func(e BaseEvent) Event {
+		return &TouchEvent{e}
+	})
+	RegisterEventType("WheelEvent", func(e BaseEvent) Event {
+		return &WheelEvent{MouseEvent{e}}
+	})
+	RegisterEventType("PointerEvent", func(e BaseEvent) Event {
+		return &PointerEvent{MouseEvent{e}}
+	})
+	RegisterEventType("DragEvent", func(e BaseEvent) Event {
+		return &DragEvent{MouseEvent{e}}
+	})
+}
+
+// eventListener is an event listener added to a JS object.
+type eventListener struct {
+	list    *eventListeners
+	target  js.Value
+	typ     string
+	capture bool
+	cb      js.Func
+	abort   js.Func // abort signal callback, if any
+	signal  js.Value
+	once    sync.Once
+}
+
+// remove removes the listener from the target and releases its callbacks. It's safe to call it multiple times.
+func (l *eventListener) remove() {
+	l.once.Do(func() {
+		l.target.Call("removeEventListener", l.typ, l.cb, l.capture)
+		l.cb.Release()
+		if l.signal.Valid() {
+			l.signal.Call("removeEventListener", "abort", l.abort)
+			l.abort.Release()
+		}
+		l.list.mu.Lock()
+		delete(l.list.m, l)
+		l.list.mu.Unlock()
+	})
+}
+
+// eventListeners is a set of event listeners added to a JS object via AddEventListener.
+type eventListeners struct {
+	mu sync.Mutex
+	m  map[*eventListener]struct{}
+}
+
+// add adds an event listener to the target and returns a function that removes it.
+func (s *eventListeners) add(target js.Value, typ string, h EventHandler, opts *ListenerOptions) func() {
+	if opts == nil {
+		opts = &ListenerOptions{}
+	}
+	l := &eventListener{
+		list: s, target: target, typ: typ,
+		capture: opts.Capture,
+	}
+	if opts.Signal.Valid() {
+		if opts.Signal.Get("aborted").Bool() {
+			return func() {}
+		}
+		l.signal = opts.Signal
+		l.abort = js.NewEventCallback(func(js.Value) {
+			l.remove()
+		})
+	}
+	once := opts.Once
+	l.cb = js.NewEventCallback(func(v js.Value) {
+		if once {
+			l.remove()
+		}
+		h(convertEvent(v))
+	})
+	s.mu.Lock()
+	if s.m == nil {
+		s.m = make(map[*eventListener]struct{})
+	}
+	s.m[l] = struct{}{}
+	s.mu.Unlock()
+	target.Call("addEventListener", typ, l.cb, js.Obj{
+		"capture": opts.Capture,
+		"once":    opts.Once,
+		"passive": opts.Passive,
+	})
+	if l.signal.Valid() {
+		l.signal.Call("addEventListener", "abort", l.abort)
+	}
+	return l.remove
+}
+
+// release removes all event listeners and releases their callbacks.
+func (s *eventListeners) release() {
+	s.mu.Lock()
+	list := make([]*eventListener, 0, len(s.m))
+	for l := range s.m {
+		list = append(list, l)
+	}
+	s.mu.Unlock()
+	for _, l := range list {
+		l.remove()
+	}
+}
+
+// eventTarget implements EventTarget for nodes and windows. It keeps track of listeners added with
+// AddEventListener, so they can be removed and released together.
+type eventTarget struct {
+	v         js.Value
+	listeners *eventListeners
+}
+
+// listenersMu protects listeners of event targets that were not created with newEventTarget.
+var listenersMu sync.Mutex
+
+// newEventTarget creates an event target for a JS object. The set of listeners is allocated up front,
+// thus copies of the target share it, and listeners can be added from multiple goroutines.
+func newEventTarget(v js.Value) eventTarget {
+	return eventTarget{v: v, listeners: &eventListeners{}}
+}
+
+// getListeners returns the set of listeners of the target, allocating it if necessary.
+func (t *eventTarget) getListeners() *eventListeners {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	if t.listeners == nil {
+		t.listeners = &eventListeners{}
+	}
+	return t.listeners
+}
+
+// AddEventListener adds an event handler for a given event type.
+// It returns a function that removes the listener and releases its callback.
+func (t *eventTarget) AddEventListener(typ string, h EventHandler) func() {
+	return t.AddEventListenerOpts(typ, h, nil)
+}
+
+// AddEventListenerOpts is the same as AddEventListener, but accepts additional options.
+func (t *eventTarget) AddEventListenerOpts(typ string, h EventHandler, opts *ListenerOptions) func() {
+	return t.getListeners().add(t.v, typ, h, opts)
+}
+
+// DispatchEvent dispatches an event to the target. It returns false if the event is cancelable
+// and one of the handlers called PreventDefault.
+func (t *eventTarget) DispatchEvent(ev Event) bool {
+	return t.v.Call("dispatchEvent", ev).Bool()
+}
+
+// ReleaseListeners removes all event listeners added with AddEventListener and releases their callbacks.
+func (t *eventTarget) ReleaseListeners() {
+	t.getListeners().release()
 }
 
 type eventClass struct {
@@ -60,7 +243,9 @@ var (
 func convertEvent(v js.Value) Event {
 	e := BaseEvent{v: v}
 	// TODO: get class name directly
-	for _, cl := range eventClasses {
+	// check types registered later first, since subclasses like PointerEvent are registered after MouseEvent
+	for i := len(eventClasses) - 1; i >= 0; i-- {
+		cl := eventClasses[i]
 		if v.InstanceOf(cl.Class) {
 			return cl.New(e)
 		}
@@ -68,13 +253,43 @@ func convertEvent(v js.Value) Event {
 	return &e
 }
 
+// baseEventOf returns a BaseEvent for a given event. It allows to access an event as a specific type,
+// regardless of the type returned by convertEvent.
+func baseEventOf(e Event) BaseEvent {
+	if b, ok := e.(*BaseEvent); ok {
+		return *b
+	}
+	return BaseEvent{v: js.Value{Ref: e.JSValue()}}
+}
+
+// boolProp returns a boolean property of a JS object. It returns false if the property is missing,
+// for example when a plain Event is passed to a typed event handler.
+func boolProp(v js.Value, name string) bool {
+	p := v.Get(name)
+	return p.Type() == js.TypeBoolean && p.Bool()
+}
+
+// numberProp returns a numeric property of a JS object. It returns zero if the property is missing or NaN.
+func numberProp(v js.Value, name string) float64 {
+	p := v.Get(name)
+	if p.Type() != js.TypeNumber {
+		return 0
+	}
+	f := p.Float()
+	if math.IsNaN(f) {
+		return 0
+	}
+	return f
+}
+
 type BaseEvent struct {
 	v js.Value
 }
 
 func (e *BaseEvent) getBool(name string) bool {
-	return e.v.Get(name).Bool()
+	return boolProp(e.v, name)
 }
+
 func (e *BaseEvent) Bubbles() bool {
 	return e.getBool("bubbles")
 }
@@ -131,14 +346,21 @@ type MouseEvent struct {
 	BaseEvent
 }
 
-func (e *MouseEvent) getPos(nameX, nameY string) Point {
-	x := e.v.Get(nameX).Int()
-	y := e.v.Get(nameY).Int()
-	return Point{X: x, Y: y}
+var (
+	clientPos = js.NewProps("clientX", "clientY")
+	offsetPos = js.NewProps("offsetX", "offsetY")
+	pagePos   = js.NewProps("pageX", "pageY")
+	screenPos = js.NewProps("screenX", "screenY")
+)
+
+func getPos(v js.Value, p *js.Props) Point {
+	var buf [2]int
+	xy := p.Ints(v, buf[:0])
+	return Point{X: xy[0], Y: xy[1]}
 }
 
-func (e *MouseEvent) getPosPref(pref string) Point {
-	return e.getPos(pref+"X", pref+"Y")
+func (e *MouseEvent) getPos(p *js.Props) Point {
+	return getPos(e.v, p)
 }
 
 const (
@@ -148,37 +370,96 @@ const (
 type MouseButton int
 
 func (e *MouseEvent) Button() MouseButton {
-	return MouseButton(e.v.Get("button").Int())
+	return MouseButton(int(numberProp(e.v, "button")))
 }
 
 func (e *MouseEvent) ClientPos() Point {
-	return e.getPosPref("client")
+	return e.getPos(clientPos)
 }
 
 func (e *MouseEvent) OffsetPos() Point {
-	return e.getPosPref("offset")
+	return e.getPos(offsetPos)
 }
 
 func (e *MouseEvent) PagePos() Point {
-	return e.getPosPref("page")
+	return e.getPos(pagePos)
 }
 
 func (e *MouseEvent) ScreenPos() Point {
-	return e.getPosPref("screen")
+	return e.getPos(screenPos)
 }
 
 func (e *MouseEvent) AltKey() bool {
-	return e.v.Get("altKey").Bool()
+	return boolProp(e.v, "altKey")
 }
 
 func (e *MouseEvent) CtrlKey() bool {
-	return e.v.Get("ctrlKey").Bool()
+	return boolProp(e.v, "ctrlKey")
 }
 
 func (e *MouseEvent) ShiftKey() bool {
-	return e.v.Get("shiftKey").Bool()
+	return boolProp(e.v, "shiftKey")
 }
 
 func (e *MouseEvent) MetaKey() bool {
-	return e.v.Get("metaKey").Bool()
+	return boolProp(e.v, "metaKey")
+}
+
+// RelatedTarget returns the secondary target of the event. For example, the element that the pointer
+// left for "mouseover" events. It returns nil if there is no such element.
+func (e *MouseEvent) RelatedTarget() *Element {
+	return AsElement(e.v.Get("relatedTarget"))
+}
+
+// EventInit is a set of options for new events.
+type EventInit struct {
+	// Bubbles indicates that the event propagates to ancestors of the target.
+	Bubbles bool
+	// Cancelable indicates that the event can be canceled with PreventDefault.
+	Cancelable bool
+	// Composed indicates that the event propagates outside of the shadow root.
+	Composed bool
+}
+
+func (o *EventInit) toJS() js.Obj {
+	if o == nil {
+		o = &EventInit{}
+	}
+	return js.Obj{
+		"bubbles":    o.Bubbles,
+		"cancelable": o.Cancelable,
+		"composed":   o.Composed,
+	}
+}
+
+// NewEvent creates a new event of a given type that can be sent with DispatchEvent. Options are optional.
+func NewEvent(typ string, opts *EventInit) Event {
+	return convertEvent(js.New("Event", typ, opts.toJS()))
+}
+
+// NewCustomEvent creates a new CustomEvent of a given type that can be sent with DispatchEvent.
+// The detail value is converted with js.Marshal. Options are optional.
+func NewCustomEvent(typ string, detail interface{}, opts *EventInit) (*CustomEvent, error) {
+	d, err := js.Marshal(detail)
+	if err != nil {
+		return nil, err
+	}
+	init := opts.toJS()
+	init["detail"] = d
+	return &CustomEvent{BaseEvent{v: js.New("CustomEvent", typ, init)}}, nil
+}
+
+// CustomEvent is an event with an application-defined payload.
+type CustomEvent struct {
+	BaseEvent
+}
+
+// Detail returns the payload of the event.
+func (e *CustomEvent) Detail() js.Value {
+	return e.v.Get("detail")
+}
+
+// DecodeDetail decodes the payload of the event into a Go value, as js.Unmarshal does.
+func (e *CustomEvent) DecodeDetail(dst interface{}) error {
+	return js.Unmarshal(e.Detail(), dst)
 }
@@ -0,0 +1,614 @@
+package dom
+
+import "github.com/dennwc/dom/js"
+
+// https://developer.mozilla.org/en-US/docs/Web/API/KeyboardEvent
+
+type KeyboardEventHandler func(*KeyboardEvent)
+
+// KeyboardEvent describes a user interaction with the keyboard.
+type KeyboardEvent struct {
+	BaseEvent
+}
+
+// KeyLocation is a location of the key on the keyboard.
+type KeyLocation int
+
+const (
+	KeyLocationStandard = KeyLocation(0)
+	KeyLocationLeft     = KeyLocation(1)
+	KeyLocationRight    = KeyLocation(2)
+	KeyLocationNumpad   = KeyLocation(3)
+)
+
+// Key returns the value of the key, taking the keyboard layout and modifiers into account.
+// For example, "a", "A", "Enter" or "ArrowLeft".
+func (e *KeyboardEvent) Key() string {
+	return e.v.Get("key").String()
+}
+
+// Code returns the physical key code, regardless of the keyboard layout. For example, "KeyA" or "Enter".
+func (e *KeyboardEvent) Code() string {
+	return e.v.Get("code").String()
+}
+
+// Location returns the location of the key on the keyboard.
+func (e *KeyboardEvent) Location() KeyLocation {
+	return KeyLocation(int(numberProp(e.v, "location")))
+}
+
+// Repeat checks if the key is being held down, and the event is repeated automatically.
+func (e *KeyboardEvent) Repeat() bool {
+	return boolProp(e.v, "repeat")
+}
+
+// IsComposing checks if the event is fired during the composition session of an input method.
+func (e *KeyboardEvent) IsComposing() bool {
+	return boolProp(e.v, "isComposing")
+}
+
+func (e *KeyboardEvent) AltKey() bool {
+	return boolProp(e.v, "altKey")
+}
+
+func (e *KeyboardEvent) CtrlKey() bool {
+	return boolProp(e.v, "ctrlKey")
+}
+
+func (e *KeyboardEvent) ShiftKey() bool {
+	return boolProp(e.v, "shiftKey")
+}
+
+func (e *KeyboardEvent) MetaKey() bool {
+	return boolProp(e.v, "metaKey")
+}
+
+// GetModifierState checks if a given modifier key, like "CapsLock" or "Shift", is active.
+func (e *KeyboardEvent) GetModifierState(key string) bool {
+	if e.v.Get("getModifierState").Type() != js.TypeFunction {
+		return false
+	}
+	return e.v.Call("getModifierState", key).Bool()
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/API/InputEvent
+
+type InputEventHandler func(*InputEvent)
+
+// InputEvent describes a modification of an editable content.
+type InputEvent struct {
+	BaseEvent
+}
+
+// Data returns the inserted text. It returns an empty string if the content was deleted.
+func (e *InputEvent) Data() string {
+	v := e.v.Get("data")
+	if !v.Valid() {
+		return ""
+	}
+	return v.String()
+}
+
+// InputType returns the type of the modification. For example, "insertText" or "deleteContentBackward".
+func (e *InputEvent) InputType() string {
+	return e.v.Get("inputType").String()
+}
+
+// IsComposing checks if the event is fired during the composition session of an input method.
+func (e *InputEvent) IsComposing() bool {
+	return boolProp(e.v, "isComposing")
+}
+
+// DataTransfer returns the data that is added to or removed from the content, if it's a rich text.
+// It returns nil otherwise.
+func (e *InputEvent) DataTransfer() *DataTransfer {
+	return AsDataTransfer(e.v.Get("dataTransfer"))
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/API/FocusEvent
+
+type FocusEventHandler func(*FocusEvent)
+
+// FocusEvent describes a change of the focus.
+type FocusEvent struct {
+	BaseEvent
+}
+
+// RelatedTarget returns the secondary target of the event. For example, the element that lost the focus
+// for "focus" events. It returns nil if there is no such element.
+func (e *FocusEvent) RelatedTarget() *Element {
+	return AsElement(e.v.Get("relatedTarget"))
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/API/WheelEvent
+
+type WheelEventHandler func(*WheelEvent)
+
+// WheelEvent describes a rotation of the mouse wheel or a similar device.
+type WheelEvent struct {
+	MouseEvent
+}
+
+// DeltaMode is a unit of the delta values of a WheelEvent.
+type DeltaMode int
+
+const (
+	DeltaPixel = DeltaMode(0)
+	DeltaLine  = DeltaMode(1)
+	DeltaPage  = DeltaMode(2)
+)
+
+// DeltaX returns the horizontal scroll amount, in DeltaMode units.
+func (e *WheelEvent) DeltaX() float64 {
+	return numberProp(e.v, "deltaX")
+}
+
+// DeltaY returns the vertical scroll amount, in DeltaMode units.
+func (e *WheelEvent) DeltaY() float64 {
+	return numberProp(e.v, "deltaY")
+}
+
+// DeltaZ returns the scroll amount for the z-axis, in DeltaMode units.
+func (e *WheelEvent) DeltaZ() float64 {
+	return numberProp(e.v, "deltaZ")
+}
+
+// DeltaMode returns the unit of delta values.
+func (e *WheelEvent) DeltaMode() DeltaMode {
+	return DeltaMode(int(numberProp(e.v, "deltaMode")))
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/API/PointerEvent
+
+type PointerEventHandler func(*PointerEvent)
+
+// PointerEvent describes an interaction with a pointing device: mouse, pen or touch.
+type PointerEvent struct {
+	MouseEvent
+}
+
+// PointerType is a type of the pointing device.
+type PointerType string
+
+const (
+	PointerMouse = PointerType("mouse")
+	PointerPen   = PointerType("pen")
+	PointerTouch = PointerType("touch")
+)
+
+// PointerId returns a unique identifier of the pointer that caused the event.
+func (e *PointerEvent) PointerId() int {
+	return int(numberProp(e.v, "pointerId"))
+}
+
+// PointerType returns the type of the device that caused the event.
+func (e *PointerEvent) PointerType() PointerType {
+	return PointerType(e.v.Get("pointerType").String())
+}
+
+// IsPrimary checks if the pointer is the primary pointer of its type.
+func (e *PointerEvent) IsPrimary() bool {
+	return boolProp(e.v, "isPrimary")
+}
+
+// Width returns the width of the contact geometry of the pointer, in CSS pixels.
+func (e *PointerEvent) Width() float64 {
+	return numberProp(e.v, "width")
+}
+
+// Height returns the height of the contact geometry of the pointer, in CSS pixels.
+func (e *PointerEvent) Height() float64 {
+	return numberProp(e.v, "height")
+}
+
+// Pressure returns the normalized pressure of the pointer, in range [0, 1].
+func (e *PointerEvent) Pressure() float64 {
+	return numberProp(e.v, "pressure")
+}
+
+// TangentialPressure returns the normalized tangential pressure of the pointer, in range [-1, 1].
+func (e *PointerEvent) TangentialPressure() float64 {
+	return numberProp(e.v, "tangentialPressure")
+}
+
+// TiltX returns the angle between the Y-Z plane and the pen, in degrees.
+func (e *PointerEvent) TiltX() int {
+	return int(numberProp(e.v, "tiltX"))
+}
+
+// TiltY returns the angle between the X-Z plane and the pen, in degrees.
+func (e *PointerEvent) TiltY() int {
+	return int(numberProp(e.v, "tiltY"))
+}
+
+// Twist returns the clockwise rotation of the pen around its own axis, in degrees.
+func (e *PointerEvent) Twist() int {
+	return int(numberProp(e.v, "twist"))
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/API/DragEvent
+
+type DragEventHandler func(*DragEvent)
+
+// DragEvent describes a drag and drop interaction.
+type DragEvent struct {
+	MouseEvent
+}
+
+// DataTransfer returns the data that is being dragged.
+func (e *DragEvent) DataTransfer() *DataTransfer {
+	return AsDataTransfer(e.v.Get("dataTransfer"))
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/API/DataTransfer
+
+func AsDataTransfer(v js.Value) *DataTransfer {
+	if !v.Valid() {
+		return nil
+	}
+	return &DataTransfer{v: v}
+}
+
+// DataTransfer holds the data that is being dragged or pasted.
+type DataTransfer struct {
+	v js.Value
+}
+
+// JSValue implements js.Wrapper.
+func (d *DataTransfer) JSValue() js.Ref {
+	return d.v.JSValue()
+}
+
+// DropEffect returns the type of the drag and drop operation: "none", "copy", "link" or "move".
+func (d *DataTransfer) DropEffect() string {
+	return d.v.Get("dropEffect").String()
+}
+
+// SetDropEffect sets the type of the drag and drop operation: "none", "copy", "link" or "move".
+func (d *DataTransfer) SetDropEffect(v string) {
+	d.v.Set("dropEffect", v)
+}
+
+// EffectAllowed returns the types of operations that are allowed. For example, "copyMove" or "all".
+func (d *DataTransfer) EffectAllowed() string {
+	return d.v.Get("effectAllowed").String()
+}
+
+// SetEffectAllowed sets the types of operations that are allowed. For example, "copyMove" or "all".
+func (d *DataTransfer) SetEffectAllowed(v string) {
+	d.v.Set("effectAllowed", v)
+}
+
+// Types returns the formats of the data, for example "text/plain". It returns "Files" format if files are dragged.
+func (d *DataTransfer) Types() []string {
+	v := d.v.Get("types")
+	arr := make([]string, v.Length())
+	for i := range arr {
+		arr[i] = v.Index(i).String()
+	}
+	return arr
+}
+
+// GetData returns the data for a given format, or an empty string if there is no such data.
+func (d *DataTransfer) GetData(format string) string {
+	return d.v.Call("getData", format).String()
+}
+
+// SetData sets the data for a given format.
+func (d *DataTransfer) SetData(format, data string) {
+	d.v.Call("setData", format, data)
+}
+
+// ClearData removes the data for given formats. If no formats are given, all data is removed.
+func (d *DataTransfer) ClearData(formats ...string) {
+	if len(formats) == 0 {
+		d.v.Call("clearData")
+		return
+	}
+	for _, f := range formats {
+		d.v.Call("clearData", f)
+	}
+}
+
+// Files returns a FileList of dragged files.
+func (d *DataTransfer) Files() js.Value {
+	return d.v.Get("files")
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/API/TouchEvent
+
+type TouchEventHandler func(*TouchEvent)
+
+// TouchEvent describes a change of touch points on a touch screen.
+type TouchEvent struct {
+	BaseEvent
+}
+
+func asTouchList(v js.Value) []*Touch {
+	if !v.Valid() {
+		return nil
+	}
+	arr := make([]*Touch, v.Length())
+	for i := range arr {
+		arr[i] = &Touch{v: v.Index(i)}
+	}
+	return arr
+}
+
+// Touches returns all touch points that are currently on the screen.
+func (e *TouchEvent) Touches() []*Touch {
+	return asTouchList(e.v.Get("touches"))
+}
+
+// TargetTouches returns touch points that are currently on the screen and started on the target element.
+func (e *TouchEvent) TargetTouches() []*Touch {
+	return asTouchList(e.v.Get("targetTouches"))
+}
+
+// ChangedTouches returns touch points that changed in this event. For example, touch points that were removed
+// for "touchend" events.
+func (e *TouchEvent) ChangedTouches() []*Touch {
+	return asTouchList(e.v.Get("changedTouches"))
+}
+
+func (e *TouchEvent) AltKey() bool {
+	return boolProp(e.v, "altKey")
+}
+
+func (e *TouchEvent) CtrlKey() bool {
+	return boolProp(e.v, "ctrlKey")
+}
+
+func (e *TouchEvent) ShiftKey() bool {
+	return boolProp(e.v, "shiftKey")
+}
+
+func (e *TouchEvent) MetaKey() bool {
+	return boolProp(e.v, "metaKey")
+}
+
+// Touch is a single touch point on a touch screen.
+type Touch struct {
+	v js.Value
+}
+
+// JSValue implements js.Wrapper.
+func (t *Touch) JSValue() js.Ref {
+	return t.v.JSValue()
+}
+
+// Identifier returns a unique identifier of the touch point. It stays the same while the finger is on the screen.
+func (t *Touch) Identifier() int {
+	return int(numberProp(t.v, "identifier"))
+}
+
+// Target returns the element on which the touch point started.
+func (t *Touch) Target() *Element {
+	return AsElement(t.v.Get("target"))
+}
+
+func (t *Touch) ClientPos() Point {
+	return getPos(t.v, clientPos)
+}
+
+func (t *Touch) PagePos() Point {
+	return getPos(t.v, pagePos)
+}
+
+func (t *Touch) ScreenPos() Point {
+	return getPos(t.v, screenPos)
+}
+
+// RadiusX returns the X radius of the ellipse that covers the contact area, in CSS pixels.
+func (t *Touch) RadiusX() float64 {
+	return numberProp(t.v, "radiusX")
+}
+
+// RadiusY returns the Y radius of the ellipse that covers the contact area, in CSS pixels.
+func (t *Touch) RadiusY() float64 {
+	return numberProp(t.v, "radiusY")
+}
+
+// RotationAngle returns the rotation of the contact area ellipse, in degrees.
+func (t *Touch) RotationAngle() float64 {
+	return numberProp(t.v, "rotationAngle")
+}
+
+// Force returns the normalized pressure of the touch point, in range [0, 1].
+func (t *Touch) Force() float64 {
+	return numberProp(t.v, "force")
+}
+
+// eventHandlers provides typed event helpers for elements and windows. Each On* method adds a handler
+// with AddEventListener and returns a function that removes it.
+//
+// The event is converted to a specific type directly, since the type returned by convertEvent may differ:
+// for example, browsers may send a PointerEvent for "click". Accessors return zero values for properties
+// that are missing, in case an event of a different class is dispatched with the same type.
+type eventHandlers struct {
+	t *eventTarget
+}
+
+func (h eventHandlers) onMouseEvent(typ string, fnc MouseEventHandler) func() {
+	return h.t.AddEventListener(typ, func(e Event) {
+		fnc(&MouseEvent{baseEventOf(e)})
+	})
+}
+
+func (h eventHandlers) onKeyboardEvent(typ string, fnc KeyboardEventHandler) func() {
+	return h.t.AddEventListener(typ, func(e Event) {
+		fnc(&KeyboardEvent{baseEventOf(e)})
+	})
+}
+
+func (h eventHandlers) onInputEvent(typ string, fnc InputEventHandler) func() {
+	return h.t.AddEventListener(typ, func(e Event) {
+		fnc(&InputEvent{baseEventOf(e)})
+	})
+}
+
+func (h eventHandlers) onFocusEvent(typ string, fnc FocusEventHandler) func() {
+	return h.t.AddEventListener(typ, func(e Event) {
+		fnc(&FocusEvent{baseEventOf(e)})
+	})
+}
+
+func (h eventHandlers) onWheelEvent(typ string, fnc WheelEventHandler) func() {
+	return h.t.AddEventListener(typ, func(e Event) {
+		fnc(&WheelEvent{MouseEvent{baseEventOf(e)}})
+	})
+}
+
+func (h eventHandlers) onPointerEvent(typ string, fnc PointerEventHandler) func() {
+	return h.t.AddEventListener(typ, func(e Event) {
+		fnc(&PointerEvent{MouseEvent{baseEventOf(e)}})
+	})
+}
+
+func (h eventHandlers) onTouchEvent(typ string, fnc TouchEventHandler) func() {
+	return h.t.AddEventListener(typ, func(e Event) {
+		fnc(&TouchEvent{baseEventOf(e)})
+	})
+}
+
+func (h eventHandlers) onDragEvent(typ string, fnc DragEventHandler) func() {
+	return h.t.AddEventListener(typ, func(e Event) {
+		fnc(&DragEvent{MouseEvent{baseEventOf(e)}})
+	})
+}
+
+// OnClick adds a handler that is called when the target is clicked.
+func (h eventHandlers) OnClick(fnc MouseEventHandler) func() {
+	return h.onMouseEvent("click", fnc)
+}
+
+// OnMouseDown adds a handler that is called when a mouse button is pressed over the target.
+func (h eventHandlers) OnMouseDown(fnc MouseEventHandler) func() {
+	return h.onMouseEvent("mousedown", fnc)
+}
+
+// OnMouseMove adds a handler that is called when the mouse moves over the target.
+func (h eventHandlers) OnMouseMove(fnc MouseEventHandler) func() {
+	return h.onMouseEvent("mousemove", fnc)
+}
+
+// OnMouseUp adds a handler that is called when a mouse button is released over the target.
+func (h eventHandlers) OnMouseUp(fnc MouseEventHandler) func() {
+	return h.onMouseEvent("mouseup", fnc)
+}
+
+// OnKeyDown adds a handler that is called when a key is pressed.
+func (h eventHandlers) OnKeyDown(fnc KeyboardEventHandler) func() {
+	return h.onKeyboardEvent("keydown", fnc)
+}
+
+// OnKeyUp adds a handler that is called when a key is released.
+func (h eventHandlers) OnKeyUp(fnc KeyboardEventHandler) func() {
+	return h.onKeyboardEvent("keyup", fnc)
+}
+
+// OnInput adds a handler that is called when an editable content is modified.
+func (h eventHandlers) OnInput(fnc InputEventHandler) func() {
+	return h.onInputEvent("input", fnc)
+}
+
+// OnWheel adds a handler that is called when the mouse wheel is rotated.
+func (h eventHandlers) OnWheel(fnc WheelEventHandler) func() {
+	return h.onWheelEvent("wheel", fnc)
+}
+
+// OnPointerDown adds a handler that is called when a pointer becomes active.
+func (h eventHandlers) OnPointerDown(fnc PointerEventHandler) func() {
+	return h.onPointerEvent("pointerdown", fnc)
+}
+
+// OnPointerMove adds a handler that is called when a pointer changes its coordinates.
+func (h eventHandlers) OnPointerMove(fnc PointerEventHandler) func() {
+	return h.onPointerEvent("pointermove", fnc)
+}
+
+// OnPointerUp adds a handler that is called when a pointer is no longer active.
+func (h eventHandlers) OnPointerUp(fnc PointerEventHandler) func() {
+	return h.onPointerEvent("pointerup", fnc)
+}
+
+// OnPointerEnter adds a handler that is called when a pointer enters the bounds of the target.
+func (h eventHandlers) OnPointerEnter(fnc PointerEventHandler) func() {
+	return h.onPointerEvent("pointerenter", fnc)
+}
+
+// OnPointerLeave adds a handler that is called when a pointer leaves the bounds of the target.
+func (h eventHandlers) OnPointerLeave(fnc PointerEventHandler) func() {
+	return h.onPointerEvent("pointerleave", fnc)
+}
+
+// OnPointerCancel adds a handler that is called when the browser cancels a pointer interaction.
+func (h eventHandlers) OnPointerCancel(fnc PointerEventHandler) func() {
+	return h.onPointerEvent("pointercancel", fnc)
+}
+
+// OnTouchStart adds a handler that is called when a touch point is placed on the screen.
+func (h eventHandlers) OnTouchStart(fnc TouchEventHandler) func() {
+	return h.onTouchEvent("touchstart", fnc)
+}
+
+// OnTouchMove adds a handler that is called when a touch point moves.
+func (h eventHandlers) OnTouchMove(fnc TouchEventHandler) func() {
+	return h.onTouchEvent("touchmove", fnc)
+}
+
+// OnTouchEnd adds a handler that is called when a touch point is removed from the screen.
+func (h eventHandlers) OnTouchEnd(fnc TouchEventHandler) func() {
+	return h.onTouchEvent("touchend", fnc)
+}
+
+// OnTouchCancel adds a handler that is called when a touch point is disrupted.
+func (h eventHandlers) OnTouchCancel(fnc TouchEventHandler) func() {
+	return h.onTouchEvent("touchcancel", fnc)
+}
+
+// OnDragStart adds a handler that is called when the user starts dragging the target.
+func (h eventHandlers) OnDragStart(fnc DragEventHandler) func() {
+	return h.onDragEvent("dragstart", fnc)
+}
+
+// OnDrag adds a handler that is called periodically while the target is dragged.
+func (h eventHandlers) OnDrag(fnc DragEventHandler) func() {
+	return h.onDragEvent("drag", fnc)
+}
+
+// OnDragEnd adds a handler that is called when a drag operation of the target ends.
+func (h eventHandlers) OnDragEnd(fnc DragEventHandler) func() {
+	return h.onDragEvent("dragend", fnc)
+}
+
+// OnDragEnter adds a handler that is called when a dragged item enters the target.
+func (h eventHandlers) OnDragEnter(fnc DragEventHandler) func() {
+	return h.onDragEvent("dragenter", fnc)
+}
+
+// OnDragOver adds a handler that is called periodically while a dragged item is over the target.
+// The handler must call PreventDefault to allow a drop.
+func (h eventHandlers) OnDragOver(fnc DragEventHandler) func() {
+	return h.onDragEvent("dragover", fnc)
+}
+
+// OnDragLeave adds a handler that is called when a dragged item leaves the target.
+func (h eventHandlers) OnDragLeave(fnc DragEventHandler) func() {
+	return h.onDragEvent("dragleave", fnc)
+}
+
+// OnDrop adds a handler that is called when an item is dropped on the target.
+func (h eventHandlers) OnDrop(fnc DragEventHandler) func() {
+	return h.onDragEvent("drop", fnc)
+}
+
+// OnFocus adds a handler that is called when the focus is received.
+func (h eventHandlers) OnFocus(fnc FocusEventHandler) func() {
+	return h.onFocusEvent("focus", fnc)
+}
+
+// OnBlur adds a handler that is called when the focus is lost.
+func (h eventHandlers) OnBlur(fnc FocusEventHandler) func() {
+	return h.onFocusEvent("blur", fnc)
+}
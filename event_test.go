@@ -0,0 +1,194 @@
+//+build !wasm
+
+package dom
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/dennwc/dom/js"
+	"github.com/stretchr/testify/require"
+)
+
+// dispatch sends an event created in JS to the target.
+func dispatch(t EventTarget, class, typ string, init js.Obj) bool {
+	return t.DispatchEvent(convertEvent(js.New(class, typ, init)))
+}
+
+func TestEventListener(t *testing.T) {
+	d := setHTML(t, `<div id="a"></div>`)
+	a := d.GetElementById("a")
+
+	var calls, once, signal int
+	remove := a.AddEventListener("ping", func(e Event) {
+		calls++
+	})
+	a.AddEventListenerOpts("ping", func(e Event) {
+		once++
+	}, &ListenerOptions{Once: true})
+	ctrl := js.New("AbortController")
+	a.AddEventListenerOpts("ping", func(e Event) {
+		signal++
+	}, &ListenerOptions{Signal: ctrl.Get("signal")})
+
+	a.DispatchEvent(NewEvent("ping", nil))
+	a.DispatchEvent(NewEvent("ping", nil))
+	require.Equal(t, 2, calls)
+	require.Equal(t, 1, once)
+	require.Equal(t, 2, signal)
+
+	ctrl.Call("abort")
+	remove()
+	remove()
+	a.DispatchEvent(NewEvent("ping", nil))
+	require.Equal(t, 2, calls)
+	require.Equal(t, 2, signal)
+
+	// listeners added after the abort are ignored
+	a.AddEventListenerOpts("ping", func(e Event) {
+		signal++
+	}, &ListenerOptions{Signal: ctrl.Get("signal")})
+	a.DispatchEvent(NewEvent("ping", nil))
+	require.Equal(t, 2, signal)
+
+	// Remove releases all listeners of the node, but only if it was attached
+	a.AddEventListener("ping", func(e Event) {
+		calls++
+	})
+	a.Remove()
+	a.DispatchEvent(NewEvent("ping", nil))
+	require.Equal(t, 2, calls)
+
+	a.AddEventListener("ping", func(e Event) {
+		calls++
+	})
+	a.Remove()
+	a.DispatchEvent(NewEvent("ping", nil))
+	require.Equal(t, 3, calls)
+}
+
+func TestEventListenerConcurrent(t *testing.T) {
+	w := &Window{eventTarget: eventTarget{v: GetWindow().v}}
+	w.eventHandlers.t = &w.eventTarget
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.AddEventListener("ping", func(e Event) {})
+		}()
+	}
+	wg.Wait()
+	w.ReleaseListeners()
+}
+
+func TestDispatchEvent(t *testing.T) {
+	d := setHTML(t, `<div id="a"><p id="b"></p></div>`)
+	a, b := d.GetElementById("a"), d.GetElementById("b")
+
+	type payload struct {
+		Name  string `js:"name"`
+		Count int    `js:"count"`
+	}
+	var (
+		got     []payload
+		targets []string
+	)
+	a.AddEventListener("update", func(e Event) {
+		ce, ok := e.(*CustomEvent)
+		require.True(t, ok, "%T", e)
+		targets = append(targets, ce.Target().Id())
+		var p payload
+		require.NoError(t, ce.DecodeDetail(&p))
+		got = append(got, p)
+		ce.PreventDefault()
+	})
+
+	ev, err := NewCustomEvent("update", payload{Name: "a", Count: 1}, &EventInit{Bubbles: true, Cancelable: true})
+	require.NoError(t, err)
+	require.Equal(t, "a", ev.Detail().Get("name").String())
+	require.False(t, b.DispatchEvent(ev))
+
+	// not bubbling, thus the handler of the parent is not called
+	ev, err = NewCustomEvent("update", payload{Name: "b"}, nil)
+	require.NoError(t, err)
+	require.True(t, b.DispatchEvent(ev))
+
+	// not cancelable, thus PreventDefault has no effect
+	ev, err = NewCustomEvent("update", payload{Name: "c", Count: 3}, nil)
+	require.NoError(t, err)
+	require.True(t, a.DispatchEvent(ev))
+	require.Equal(t, []payload{{Name: "a", Count: 1}, {Name: "c", Count: 3}}, got)
+	require.Equal(t, []string{"b", "a"}, targets)
+}
+
+func TestTypedEvents(t *testing.T) {
+	d := setHTML(t, `<div id="a"></div>`)
+	a := d.GetElementById("a")
+
+	var events []Event
+	a.AddEventListener("test", func(e Event) {
+		events = append(events, e)
+	})
+	for _, class := range []string{"Event", "MouseEvent", "KeyboardEvent", "PointerEvent", "WheelEvent", "DragEvent", "FocusEvent"} {
+		dispatch(a, class, "test", nil)
+	}
+	require.Len(t, events, 7)
+	require.IsType(t, &BaseEvent{}, events[0])
+	require.IsType(t, &MouseEvent{}, events[1])
+	require.IsType(t, &KeyboardEvent{}, events[2])
+	require.IsType(t, &PointerEvent{}, events[3])
+	require.IsType(t, &WheelEvent{}, events[4])
+	require.IsType(t, &DragEvent{}, events[5])
+	require.IsType(t, &FocusEvent{}, events[6])
+
+	var key *KeyboardEvent
+	a.OnKeyDown(func(e *KeyboardEvent) {
+		key = e
+	})
+	dispatch(a, "KeyboardEvent", "keydown", js.Obj{"key": "A", "code": "KeyA", "shiftKey": true})
+	require.NotNil(t, key)
+	require.Equal(t, "A", key.Key())
+	require.Equal(t, "KeyA", key.Code())
+	require.True(t, key.ShiftKey())
+
+	var ptr *PointerEvent
+	a.OnPointerDown(func(e *PointerEvent) {
+		ptr = e
+	})
+	dispatch(a, "PointerEvent", "pointerdown", js.Obj{"pointerType": "pen", "clientX": 3, "clientY": 4})
+	require.NotNil(t, ptr)
+	require.Equal(t, PointerPen, ptr.PointerType())
+	require.Equal(t, Point{X: 3, Y: 4}, ptr.ClientPos())
+
+	// typed handlers accept events of other classes, and missing coordinates are zero
+	var click *MouseEvent
+	remove := a.OnClick(func(e *MouseEvent) {
+		click = e
+	})
+	dispatch(a, "Event", "click", nil)
+	require.NotNil(t, click)
+	require.Equal(t, Point{}, click.OffsetPos())
+	require.Equal(t, MouseLeft, click.Button())
+	require.False(t, click.AltKey())
+
+	key = nil
+	dispatch(a, "Event", "keydown", nil)
+	require.NotNil(t, key)
+	require.False(t, key.Repeat())
+	require.Equal(t, KeyLocationStandard, key.Location())
+	require.False(t, key.GetModifierState("Shift"))
+
+	click = nil
+	remove()
+	dispatch(a, "MouseEvent", "click", nil)
+	require.Nil(t, click)
+
+	var focus int
+	w := GetWindow()
+	defer w.OnFocus(func(e *FocusEvent) {
+		focus++
+	})()
+	dispatch(w, "FocusEvent", "focus", nil)
+	require.Equal(t, 1, focus)
+}
@@ -2,6 +2,7 @@ package dom
 
 import (
 	"image"
+	"math"
 
 	"github.com/dennwc/dom/js"
 )
@@ -35,3 +36,28 @@ func Loop() {
 
 type Point = image.Point
 type Rect = image.Rectangle
+
+// DOMRect describes the size and position of a rectangle in CSS pixels.
+// Unlike Rect, it preserves fractional coordinates.
+type DOMRect struct {
+	X, Y          float64
+	Width, Height float64
+}
+
+// Rect returns the smallest integer rectangle that contains r.
+// NaN and infinite coordinates are treated as zero.
+func (r DOMRect) Rect() Rect {
+	return image.Rect(
+		toInt(math.Floor(r.X)), toInt(math.Floor(r.Y)),
+		toInt(math.Ceil(r.X+r.Width)), toInt(math.Ceil(r.Y+r.Height)),
+	)
+}
+
+// toInt truncates f to an integer. It returns 0 for NaN and infinite values,
+// since converting them to int is implementation-specific in Go.
+func toInt(f float64) int {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return int(f)
+}
@@ -0,0 +1,269 @@
+//+build !wasm
+
+package headless
+
+import (
+	"strings"
+
+	"github.com/dennwc/dom/js"
+)
+
+// normName normalizes an attribute name for lookup. Names are case-insensitive for HTML elements.
+func (n *node) normName(name string) string {
+	if n.ns == nsHTML {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// attrIndex returns an index of the attribute with a given qualified name, or -1.
+func (n *node) attrIndex(name string) int {
+	name = n.normName(name)
+	for i, a := range n.attrs {
+		if a.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// attrIndexNS returns an index of the attribute with a given namespace and local name, or -1.
+func (n *node) attrIndexNS(ns, local string) int {
+	for i, a := range n.attrs {
+		if a.ns == ns && localName(a.name) == local {
+			return i
+		}
+	}
+	return -1
+}
+
+// localName returns a local part of the qualified name.
+func localName(name string) string {
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// syncedAttrs returns a list of attributes after syncing them with style and dataset objects.
+func (n *node) syncedAttrs() []attr {
+	n.syncAttrs()
+	return n.attrs
+}
+
+// getAttr returns a value of the attribute.
+func (n *node) getAttr(name string) (string, bool) {
+	if name == "style" || strings.HasPrefix(name, "data-") {
+		n.syncAttrs()
+	}
+	if i := n.attrIndex(name); i >= 0 {
+		return n.attrs[i].value, true
+	}
+	return "", false
+}
+
+// hasAttr checks if the element has a given attribute.
+func (n *node) hasAttr(name string) bool {
+	_, ok := n.getAttr(name)
+	return ok
+}
+
+// setAttrRaw sets the attribute value without updating style and dataset objects.
+func (n *node) setAttrRaw(ns, name, val string) {
+	if i := n.attrIndex(name); i >= 0 {
+		n.attrs[i].value = val
+	} else {
+		n.attrs = append(n.attrs, attr{ns: ns, name: n.normName(name), value: val})
+	}
+	changed()
+}
+
+// removeAttrRaw removes the attribute without updating style and dataset objects.
+func (n *node) removeAttrRaw(name string) bool {
+	i := n.attrIndex(name)
+	if i < 0 {
+		return false
+	}
+	n.attrs = append(n.attrs[:i:i], n.attrs[i+1:]...)
+	changed()
+	return true
+}
+
+// setAttr implements Element.setAttribute.
+func (n *node) setAttr(name, val string) {
+	if !validName(name) {
+		throwDOMException("InvalidCharacterError", "'"+name+"' is not a valid attribute name.")
+	}
+	n.syncAttrs()
+	n.setAttrRaw("", name, val)
+	n.attrChanged(n.normName(name))
+}
+
+// removeAttr implements Element.removeAttribute.
+func (n *node) removeAttr(name string) {
+	n.syncAttrs()
+	if n.removeAttrRaw(name) {
+		n.attrChanged(n.normName(name))
+	}
+}
+
+// attrChanged updates objects that reflect attributes of the element.
+func (n *node) attrChanged(name string) {
+	i := n.attrIndex(name)
+	switch {
+	case name == "style":
+		if n.style.Valid() {
+			v := ""
+			if i >= 0 {
+				v = n.attrs[i].value
+			}
+			setCSSText(n.style, v)
+		}
+	case strings.HasPrefix(name, "data-"):
+		if n.dataset.Valid() {
+			key := dataKey(name)
+			if i >= 0 {
+				n.dataset.Set(key, n.attrs[i].value)
+			} else {
+				deleteProp(n.dataset, key)
+			}
+		}
+	}
+}
+
+// syncAttrs updates attributes that might be changed via style and dataset objects.
+func (n *node) syncAttrs() {
+	if n.style.Valid() {
+		css := cssText(n.style)
+		if i := n.attrIndex("style"); i >= 0 {
+			n.attrs[i].value = css
+		} else if css != "" {
+			n.attrs = append(n.attrs, attr{name: "style", value: css})
+		}
+	}
+	if n.dataset.Valid() {
+		keys := keysOf(n.dataset)
+		set := make(map[string]bool, len(keys))
+		for _, k := range keys {
+			name := "data-" + dataAttr(k)
+			set[name] = true
+			val := toString(n.dataset.Get(k))
+			if i := n.attrIndex(name); i >= 0 {
+				n.attrs[i].value = val
+			} else {
+				n.attrs = append(n.attrs, attr{name: name, value: val})
+			}
+		}
+		for i := 0; i < len(n.attrs); i++ {
+			if name := n.attrs[i].name; strings.HasPrefix(name, "data-") && !set[name] {
+				n.attrs = append(n.attrs[:i:i], n.attrs[i+1:]...)
+				i--
+			}
+		}
+	}
+}
+
+// validName checks if the string is a valid name for an element or an attribute.
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	return !strings.ContainsAny(name, " \t\n\f\r\"'<>/=")
+}
+
+// dataKey converts a name of a data attribute to a key of the dataset.
+func dataKey(name string) string {
+	name = strings.TrimPrefix(name, "data-")
+	var buf strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '-' && i+1 < len(name) && name[i+1] >= 'a' && name[i+1] <= 'z' {
+			buf.WriteByte(name[i+1] - 'a' + 'A')
+			i++
+			continue
+		}
+		buf.WriteByte(name[i])
+	}
+	return buf.String()
+}
+
+// dataAttr converts a key of the dataset to a name of a data attribute without the prefix.
+func dataAttr(key string) string {
+	var buf strings.Builder
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; c >= 'A' && c <= 'Z' {
+			buf.WriteByte('-')
+			buf.WriteByte(c - 'A' + 'a')
+			continue
+		}
+		buf.WriteByte(key[i])
+	}
+	return buf.String()
+}
+
+// classes returns a list of classes of the element.
+func (n *node) classes() []string {
+	v, _ := n.getAttr("class")
+	return strings.Fields(v)
+}
+
+// hasClass checks if the element has a given class.
+func (n *node) hasClass(name string) bool {
+	for _, c := range n.classes() {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	attrClass      *class
+	namedNodeClass *class
+)
+
+// attributesOf returns a snapshot of the element attributes as a NamedNodeMap.
+func attributesOf(n *node) js.Value {
+	m := namedNodeClass.create()
+	attrs := n.syncedAttrs()
+	for i, a := range attrs {
+		v := attrClass.create()
+		local, prefix := localName(a.name), interface{}(nil)
+		if local != a.name {
+			prefix = a.name[:len(a.name)-len(local)-1]
+		}
+		ns := interface{}(nil)
+		if a.ns != "" {
+			ns = a.ns
+		}
+		defineValue(v, "name", a.name)
+		defineValue(v, "localName", local)
+		defineValue(v, "prefix", prefix)
+		defineValue(v, "namespaceURI", ns)
+		defineValue(v, "value", a.value)
+		defineValue(v, "ownerElement", n.jsValue())
+		m.SetIndex(i, v)
+	}
+	defineValue(m, "length", len(attrs))
+	return m
+}
+
+func setupAttributes() {
+	attrClass = newClass("Attr", nil, nil)
+	namedNodeClass = newClass("NamedNodeMap", nil, nil)
+	namedNodeClass.method("item", func(this js.Value, args []js.Value) interface{} {
+		i := arg(args, 0).Int()
+		if i < 0 || i >= this.Get("length").Int() {
+			return nil
+		}
+		return this.Index(i)
+	})
+	namedNodeClass.method("getNamedItem", func(this js.Value, args []js.Value) interface{} {
+		name := toString(arg(args, 0))
+		for i, n := 0, this.Get("length").Int(); i < n; i++ {
+			if a := this.Index(i); a.Get("name").String() == name {
+				return a
+			}
+		}
+		return nil
+	})
+}
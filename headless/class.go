@@ -0,0 +1,146 @@
+//+build !wasm
+
+package headless
+
+import (
+	"github.com/dennwc/dom/js"
+)
+
+var (
+	objectClass = js.Get("Object")
+	objectProto = js.Get("Object", "prototype")
+
+	null = js.ValueOf(nil)
+)
+
+// class is a JS class implemented in Go.
+type class struct {
+	name  string
+	ctor  js.Value
+	proto js.Value
+}
+
+// newClass creates a new JS class and registers it as a global. If ctor is nil, the class cannot be
+// constructed from JS. Otherwise, ctor is called with a new object that should be initialized.
+func newClass(name string, parent *class, ctor func(this js.Value, args []js.Value)) *class {
+	pproto := objectProto
+	if parent != nil {
+		pproto = parent.proto
+	}
+	c := &class{name: name, proto: objectClass.Call("create", pproto)}
+	f := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if ctor == nil || !this.InstanceOf(c.ctor) {
+			throwTypeError("Illegal constructor")
+		}
+		ctor(this, args)
+		return nil
+	})
+	c.ctor = js.Value{Ref: f.Value}
+	c.ctor.Set("name", name)
+	c.ctor.Set("prototype", c.proto)
+	if parent != nil {
+		objectClass.Call("setPrototypeOf", c.ctor, parent.ctor)
+	}
+	defineValue(c.proto, "constructor", c.ctor)
+	js.Set(name, c.ctor)
+	return c
+}
+
+// create creates a new instance of the class without calling the constructor.
+func (c *class) create() js.Value {
+	return objectClass.Call("create", c.proto)
+}
+
+// method defines a method on the class prototype.
+func (c *class) method(name string, fnc func(this js.Value, args []js.Value) interface{}) {
+	defineValue(c.proto, name, js.FuncOf(fnc))
+}
+
+// getter defines an accessor property on the class prototype. The setter is optional.
+func (c *class) getter(name string, get func(this js.Value) interface{}, set func(this js.Value, v js.Value)) {
+	defineAccessor(c.proto, name, get, set)
+}
+
+// constant defines a constant on the class and its prototype.
+func (c *class) constant(name string, v interface{}) {
+	defineValue(c.ctor, name, v)
+	defineValue(c.proto, name, v)
+}
+
+// defineValue defines a non-enumerable data property on an object.
+func defineValue(o js.Value, name string, v interface{}) {
+	objectClass.Call("defineProperty", o, name, js.Obj{
+		"value": v, "writable": true, "configurable": true,
+	})
+}
+
+// defineAccessor defines a non-enumerable accessor property on an object.
+func defineAccessor(o js.Value, name string, get func(this js.Value) interface{}, set func(this js.Value, v js.Value)) {
+	desc := js.Obj{
+		"configurable": true,
+		"get": js.FuncOf(func(this js.Value, _ []js.Value) interface{} {
+			return get(this)
+		}),
+	}
+	if set != nil {
+		desc["set"] = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			set(this, arg(args, 0))
+			return nil
+		})
+	}
+	objectClass.Call("defineProperty", o, name, desc)
+}
+
+// arg returns i-th argument or undefined.
+func arg(args []js.Value, i int) js.Value {
+	if i < len(args) {
+		return args[i]
+	}
+	return js.Value{}
+}
+
+// optString converts an optional argument to a string, using a default value for undefined.
+func optString(v js.Value, def string) string {
+	if v.IsUndefined() {
+		return def
+	}
+	return v.String()
+}
+
+// toString converts a value to string the same way as JS String() does.
+func toString(v js.Value) string {
+	return js.Get("String").Invoke(v).String()
+}
+
+// keysOf returns own enumerable property names of the object.
+func keysOf(o js.Value) []string {
+	arr := objectClass.Call("keys", o).Slice()
+	keys := make([]string, 0, len(arr))
+	for _, k := range arr {
+		keys = append(keys, k.String())
+	}
+	return keys
+}
+
+// deleteProp removes a property from the object.
+func deleteProp(o js.Value, name string) {
+	js.Get("Reflect").Call("deleteProperty", o, name)
+}
+
+// newArray creates a JS array with given elements.
+func newArray(vals []interface{}) js.Value {
+	if vals == nil {
+		vals = []interface{}{}
+	}
+	return js.ValueOf(vals)
+}
+
+// throwTypeError raises a JS TypeError.
+func throwTypeError(msg string) {
+	panic(js.Error{Value: js.New("TypeError", msg).Ref})
+}
+
+// throwDOMException raises a JS DOMException with a given name.
+func throwDOMException(name, msg string) {
+	panic(js.Error{Value: js.New("DOMException", msg, name).Ref})
+}
@@ -0,0 +1,99 @@
+//+build !wasm
+
+package headless
+
+import (
+	"strconv"
+
+	"github.com/dennwc/dom/js"
+)
+
+// collection is an internal state of NodeList and HTMLCollection objects.
+//
+// Collections are live: index properties are refreshed when the tree changes and the collection is accessed
+// via one of its methods or the length property.
+type collection struct {
+	list func() []*node
+	last []*node
+	ver  int
+}
+
+var collections = make(map[js.Ref]*collection)
+
+// newCollection creates a new collection object of a given class.
+func newCollection(c *class, list func() []*node) js.Value {
+	v := c.create()
+	col := &collection{list: list, ver: -1}
+	collections[v.Ref] = col
+	col.refresh(v)
+	return v
+}
+
+// collectionOf returns a collection for a method receiver and refreshes it.
+func collectionOf(this js.Value) []*node {
+	col := collections[this.Ref]
+	if col == nil {
+		throwTypeError("Illegal invocation")
+	}
+	return col.refresh(this)
+}
+
+// refresh updates index properties of the collection if the tree has changed.
+func (col *collection) refresh(v js.Value) []*node {
+	if col.ver == version {
+		return col.last
+	}
+	list := col.list()
+	for i, n := range list {
+		v.SetIndex(i, n.jsValue())
+	}
+	for i := len(list); i < len(col.last); i++ {
+		deleteProp(v, strconv.Itoa(i))
+	}
+	col.last = append(col.last[:0], list...)
+	col.ver = version
+	return col.last
+}
+
+func setupCollections() {
+	item := func(this js.Value, args []js.Value) interface{} {
+		list := collectionOf(this)
+		if i := arg(args, 0).Int(); i >= 0 && i < len(list) {
+			return list[i].jsValue()
+		}
+		return nil
+	}
+	length := func(this js.Value) interface{} {
+		return len(collectionOf(this))
+	}
+
+	nodeListClass = newClass("NodeList", nil, nil)
+	nodeListClass.getter("length", length, nil)
+	nodeListClass.method("item", item)
+	nodeListClass.method("forEach", func(this js.Value, args []js.Value) interface{} {
+		fnc := arg(args, 0)
+		for i, n := range collectionOf(this) {
+			fnc.Call("call", arg(args, 1), n.jsValue(), i, this)
+		}
+		return nil
+	})
+
+	collectionClass = newClass("HTMLCollection", nil, nil)
+	collectionClass.getter("length", length, nil)
+	collectionClass.method("item", item)
+	collectionClass.method("namedItem", func(this js.Value, args []js.Value) interface{} {
+		name := toString(arg(args, 0))
+		if name == "" {
+			return nil
+		}
+		for _, n := range collectionOf(this) {
+			if id, _ := n.getAttr("id"); id == name {
+				return n.jsValue()
+			}
+			if v, _ := n.getAttr("name"); v == name && n.ns == nsHTML {
+				return n.jsValue()
+			}
+		}
+		return nil
+	})
+}
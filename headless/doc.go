@@ -0,0 +1,12 @@
+// Package headless provides a pure-Go implementation of a subset of DOM APIs for non-wasm builds.
+//
+// When imported on the host, it registers "window" and "document" globals in the JS emulation provided by
+// the js package, so code that uses the dom package can be tested with a regular "go test".
+// Package dom imports it automatically on non-wasm builds.
+//
+// The implementation has no layout engine: all sizes and positions are reported as zero.
+// Like in the browser, the DOM is not safe for concurrent use: all access must happen from a single goroutine.
+// Use Reset or SetHTML to start each test from a known state.
+//
+// On wasm builds the package is empty.
+package headless
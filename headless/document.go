@@ -0,0 +1,262 @@
+//+build !wasm
+
+package headless
+
+import (
+	"strings"
+
+	"github.com/dennwc/dom/js"
+)
+
+// cookies is a value of the document.cookie.
+var cookies []string
+
+// head returns the head element of the document.
+func (n *node) head() *node {
+	root := n.documentElement()
+	if !root.is("html") {
+		return nil
+	}
+	for _, c := range root.children {
+		if c.is("head") {
+			return c
+		}
+	}
+	return nil
+}
+
+// body returns the body element of the document.
+func (n *node) body() *node {
+	root := n.documentElement()
+	if !root.is("html") {
+		return nil
+	}
+	for _, c := range root.children {
+		if c.is("body") || c.is("frameset") {
+			return c
+		}
+	}
+	return nil
+}
+
+// createElement implements Document.createElement.
+func createElement(name string) *node {
+	if !validName(name) {
+		throwDOMException("InvalidCharacterError", "Failed to execute 'createElement' on 'Document': The tag name provided ('"+name+"') is not a valid name.")
+	}
+	return newElement(strings.ToLower(name), nsHTML, "")
+}
+
+// createElementNS implements Document.createElementNS.
+func createElementNS(ns, name string) *node {
+	if !validName(name) {
+		throwDOMException("InvalidCharacterError", "Failed to execute 'createElementNS' on 'Document': The qualified name provided ('"+name+"') contains the invalid name-start character.")
+	}
+	prefix, local := "", name
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		prefix, local = name[:i], name[i+1:]
+		if ns == "" {
+			throwDOMException("NamespaceError", "Failed to execute 'createElementNS' on 'Document': The namespace URI provided ('') is not valid.")
+		}
+	}
+	return newElement(local, ns, prefix)
+}
+
+func setupDocument() {
+	documentClass = newClass("Document", nodeClass, nil)
+	c := documentClass
+	c.getter("documentElement", func(this js.Value) interface{} {
+		return jsOf(thisNode(this).documentElement())
+	}, nil)
+	c.getter("head", func(this js.Value) interface{} {
+		return jsOf(thisNode(this).head())
+	}, nil)
+	c.getter("body", func(this js.Value) interface{} {
+		return jsOf(thisNode(this).body())
+	}, func(this js.Value, v js.Value) {
+		n := thisNode(this)
+		b := nodeOf(v)
+		if !b.is("body") && !b.is("frameset") {
+			throwDOMException("HierarchyRequestError", "The new body element is of type '"+toString(v)+"'. It must be either a 'BODY' or 'FRAMESET' element.")
+		}
+		if old := n.body(); old != nil {
+			old.parent.replaceChild(b, old)
+		} else if root := n.documentElement(); root != nil {
+			root.insertBefore(b, nil)
+		}
+	})
+	c.getter("doctype", func(this js.Value) interface{} {
+		for _, ch := range thisNode(this).children {
+			if ch.typ == doctypeNode {
+				return ch.jsValue()
+			}
+		}
+		return nil
+	}, nil)
+	c.getter("title", func(this js.Value) interface{} {
+		t := thisNode(this).find(func(c *node) bool { return c.is("title") })
+		if t == nil {
+			return ""
+		}
+		return strings.Join(strings.Fields(t.childText()), " ")
+	}, func(this js.Value, v js.Value) {
+		n := thisNode(this)
+		t := n.find(func(c *node) bool { return c.is("title") })
+		if t == nil {
+			head := n.head()
+			if head == nil {
+				return
+			}
+			t = newElement("title", nsHTML, "")
+			head.appendChild(t)
+		}
+		t.setTextContent(toString(v))
+	})
+	for _, name := range []string{"URL", "documentURI"} {
+		c.getter(name, func(this js.Value) interface{} {
+			return location.String()
+		}, nil)
+	}
+	c.getter("location", func(this js.Value) interface{} {
+		return locationObj
+	}, func(this js.Value, v js.Value) {
+		setLocation(toString(v))
+	})
+	c.getter("cookie", func(this js.Value) interface{} {
+		return strings.Join(cookies, "; ")
+	}, func(this js.Value, v js.Value) {
+		s := toString(v)
+		if i := strings.IndexByte(s, ';'); i >= 0 {
+			s = s[:i]
+		}
+		name := s
+		if i := strings.IndexByte(s, '='); i >= 0 {
+			name = s[:i]
+		}
+		for i, c := range cookies {
+			if strings.HasPrefix(c, name+"=") || c == name {
+				cookies[i] = s
+				return
+			}
+		}
+		cookies = append(cookies, s)
+	})
+	for name, v := range map[string]string{
+		"readyState": "complete", "characterSet": "UTF-8", "charset": "UTF-8", "inputEncoding": "UTF-8",
+		"contentType": "text/html", "compatMode": "CSS1Compat", "visibilityState": "visible",
+	} {
+		v := v
+		c.getter(name, func(this js.Value) interface{} {
+			return v
+		}, nil)
+	}
+	c.getter("hidden", func(this js.Value) interface{} {
+		return false
+	}, nil)
+	c.getter("defaultView", func(this js.Value) interface{} {
+		return window
+	}, nil)
+	c.getter("activeElement", func(this js.Value) interface{} {
+		f := focused
+		for f != nil && f.root().host != nil {
+			f = f.root().host
+		}
+		if f != nil && f.connected() {
+			return f.jsValue()
+		}
+		return jsOf(thisNode(this).body())
+	}, nil)
+	c.getter("forms", func(this js.Value) interface{} {
+		n := thisNode(this)
+		return newCollection(collectionClass, func() []*node {
+			return n.descendants(func(c *node) bool { return c.is("form") })
+		})
+	}, nil)
+	c.getter("images", func(this js.Value) interface{} {
+		n := thisNode(this)
+		return newCollection(collectionClass, func() []*node {
+			return n.descendants(func(c *node) bool { return c.is("img") })
+		})
+	}, nil)
+	c.getter("links", func(this js.Value) interface{} {
+		n := thisNode(this)
+		return newCollection(collectionClass, func() []*node {
+			return n.descendants(func(c *node) bool {
+				return (c.is("a") || c.is("area")) && c.hasAttr("href")
+			})
+		})
+	}, nil)
+	c.getter("scripts", func(this js.Value) interface{} {
+		n := thisNode(this)
+		return newCollection(collectionClass, func() []*node {
+			return n.descendants(func(c *node) bool { return c.is("script") })
+		})
+	}, nil)
+	c.method("createElement", func(this js.Value, args []js.Value) interface{} {
+		return createElement(toString(arg(args, 0))).jsValue()
+	})
+	c.method("createElementNS", func(this js.Value, args []js.Value) interface{} {
+		return createElementNS(nullableString(arg(args, 0)), toString(arg(args, 1))).jsValue()
+	})
+	c.method("createTextNode", func(this js.Value, args []js.Value) interface{} {
+		return newText(toString(arg(args, 0))).jsValue()
+	})
+	c.method("createComment", func(this js.Value, args []js.Value) interface{} {
+		return newComment(toString(arg(args, 0))).jsValue()
+	})
+	c.method("createDocumentFragment", func(this js.Value, args []js.Value) interface{} {
+		return newFragment().jsValue()
+	})
+	c.method("createEvent", func(this js.Value, args []js.Value) interface{} {
+		name := toString(arg(args, 0))
+		switch strings.ToLower(name) {
+		case "event", "events", "htmlevents":
+			name = "Event"
+		case "mouseevent", "mouseevents":
+			name = "MouseEvent"
+		case "customevent":
+			name = "CustomEvent"
+		case "uievent", "uievents":
+			name = "UIEvent"
+		case "keyboardevent":
+			name = "KeyboardEvent"
+		case "focusevent":
+			name = "FocusEvent"
+		default:
+			throwDOMException("NotSupportedError", "The provided event type ('"+name+"') is invalid.")
+		}
+		return js.New(name, "")
+	})
+	c.method("importNode", func(this js.Value, args []js.Value) interface{} {
+		n := argNode(args, 0, "importNode", "Document")
+		if n.typ == documentNode || n.host != nil {
+			throwDOMException("NotSupportedError", "The node provided is a document or a shadow root, which may not be imported.")
+		}
+		return n.clone(arg(args, 1).Truthy()).jsValue()
+	})
+	c.method("adoptNode", func(this js.Value, args []js.Value) interface{} {
+		n := argNode(args, 0, "adoptNode", "Document")
+		if n.typ == documentNode || n.host != nil {
+			throwDOMException("NotSupportedError", "The node provided is a document or a shadow root, which may not be adopted.")
+		}
+		n.detach()
+		return n.jsValue()
+	})
+	c.method("getElementById", func(this js.Value, args []js.Value) interface{} {
+		return jsOf(thisNode(this).elementByID(toString(arg(args, 0))))
+	})
+	c.method("getElementsByName", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		name := toString(arg(args, 0))
+		return newCollection(nodeListClass, func() []*node {
+			return n.descendants(func(c *node) bool {
+				v, ok := c.getAttr("name")
+				return ok && v == name && c.ns == nsHTML
+			})
+		})
+	})
+	c.method("hasFocus", func(this js.Value, args []js.Value) interface{} {
+		return true
+	})
+	parentNodeMixin(c)
+}
@@ -0,0 +1,1434 @@
+//+build !wasm
+
+package headless
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dennwc/dom/js"
+)
+
+var rectClass *class
+
+// newRect creates a new DOMRect.
+func newRect(x, y, w, h float64) js.Value {
+	return rectClass.ctor.New(x, y, w, h)
+}
+
+// reflectString defines a string property that reflects an attribute.
+func reflectString(c *class, prop, name string) {
+	c.getter(prop, func(this js.Value) interface{} {
+		v, _ := thisNode(this).getAttr(name)
+		return v
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setAttr(name, toString(v))
+	})
+}
+
+// reflectURL defines a string property that reflects an attribute with a URL. The URL is resolved
+// relative to the document location.
+func reflectURL(c *class, prop, name string) {
+	c.getter(prop, func(this js.Value) interface{} {
+		v, ok := thisNode(this).getAttr(name)
+		if !ok {
+			return ""
+		}
+		return resolveURL(v)
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setAttr(name, toString(v))
+	})
+}
+
+// resolveURL resolves the URL relative to the document location.
+func resolveURL(s string) string {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return s
+	}
+	return location.ResolveReference(u).String()
+}
+
+// reflectBool defines a boolean property that reflects a presence of an attribute.
+func reflectBool(c *class, prop, name string) {
+	c.getter(prop, func(this js.Value) interface{} {
+		return thisNode(this).hasAttr(name)
+	}, func(this js.Value, v js.Value) {
+		if n := thisNode(this); v.Truthy() {
+			n.setAttr(name, "")
+		} else {
+			n.removeAttr(name)
+		}
+	})
+}
+
+// reflectInt defines an integer property that reflects an attribute.
+func reflectInt(c *class, prop, name string, def int) {
+	c.getter(prop, func(this js.Value) interface{} {
+		return attrInt(thisNode(this), name, def)
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setAttr(name, strconv.Itoa(v.Int()))
+	})
+}
+
+// reflectEnum defines a string property that reflects an attribute limited to a set of known values.
+// The first value is used as a default.
+func reflectEnum(c *class, prop, name string, vals ...string) {
+	c.getter(prop, func(this js.Value) interface{} {
+		return attrEnum(thisNode(this), name, vals...)
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setAttr(name, toString(v))
+	})
+}
+
+// attrInt returns an integer value of the attribute.
+func attrInt(n *node, name string, def int) int {
+	v, ok := n.getAttr(name)
+	if !ok {
+		return def
+	}
+	// parse a leading integer the same way as parseInt does
+	v = strings.TrimSpace(v)
+	end := 0
+	for end < len(v) && (v[end] >= '0' && v[end] <= '9' || (end == 0 && (v[0] == '-' || v[0] == '+'))) {
+		end++
+	}
+	i, err := strconv.Atoi(v[:end])
+	if err != nil {
+		return def
+	}
+	return i
+}
+
+// attrEnum returns a value of an enumerated attribute. The first value is used as a default.
+func attrEnum(n *node, name string, vals ...string) string {
+	v, _ := n.getAttr(name)
+	v = strings.ToLower(v)
+	for _, s := range vals {
+		if s == v {
+			return s
+		}
+	}
+	return vals[0]
+}
+
+// disabled checks if a form control is disabled.
+func (n *node) disabled() bool {
+	if !n.isHTML() {
+		return false
+	}
+	switch n.name {
+	case "button", "input", "select", "textarea", "fieldset", "option", "optgroup":
+	default:
+		return false
+	}
+	if n.hasAttr("disabled") {
+		return true
+	}
+	for p := n.parent; p != nil; p = p.parent {
+		if p.is("fieldset") && p.hasAttr("disabled") {
+			return true
+		}
+		if n.name == "option" && p.is("optgroup") && p.hasAttr("disabled") {
+			return true
+		}
+	}
+	return false
+}
+
+// focusable checks if the element can be focused.
+func (n *node) focusable() bool {
+	if !n.isElement() || !n.connected() || n.disabled() {
+		return false
+	}
+	if n.hasAttr("tabindex") {
+		return true
+	}
+	if !n.isHTML() {
+		return false
+	}
+	switch n.name {
+	case "button", "select", "textarea", "iframe", "summary":
+		return true
+	case "input":
+		v, _ := n.getAttr("type")
+		return strings.ToLower(v) != "hidden"
+	case "a", "area":
+		return n.hasAttr("href")
+	}
+	return n.contentEditable()
+}
+
+// contentEditable checks if the element is editable.
+func (n *node) contentEditable() bool {
+	for p := n; p.isHTML(); p = p.parent {
+		if v, ok := p.getAttr("contenteditable"); ok {
+			switch strings.ToLower(v) {
+			case "", "true", "plaintext-only":
+				return true
+			case "false":
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// dispatchTrusted dispatches an event generated by the browser itself.
+func dispatchTrusted(target js.Value, ev js.Value) bool {
+	eventOf(ev).trusted = true
+	return dispatchEvent(target, ev)
+}
+
+// fireFocus dispatches focus events for a given element.
+func fireFocus(n *node, typ, typ2 string, related *node) {
+	init := js.Obj{"view": window, "composed": true, "relatedTarget": jsOf(related)}
+	dispatchTrusted(n.jsValue(), newEvent(focusEventClass, typ, init))
+	init["bubbles"] = true
+	dispatchTrusted(n.jsValue(), newEvent(focusEventClass, typ2, init))
+}
+
+// focus moves focus to the element.
+func (n *node) focus() {
+	if focused == n || !n.focusable() {
+		return
+	}
+	old := focused
+	focused = n
+	if old != nil {
+		fireFocus(old, "blur", "focusout", n)
+	}
+	fireFocus(n, "focus", "focusin", old)
+}
+
+// blur removes focus from the element.
+func (n *node) blur() {
+	if focused != n {
+		return
+	}
+	focused = nil
+	fireFocus(n, "blur", "focusout", nil)
+}
+
+// click simulates a mouse click on the element.
+func (n *node) click() {
+	if n.disabled() {
+		return
+	}
+	// legacy pre-activation behavior of checkboxes and radio buttons
+	typ := n.inputType()
+	var (
+		undo       func()
+		checkedWas bool
+	)
+	switch {
+	case n.is("input") && typ == "checkbox":
+		checkedWas = n.isChecked()
+		n.setChecked(!checkedWas)
+		undo = func() { n.setChecked(checkedWas) }
+	case n.is("input") && typ == "radio":
+		var prev *node
+		for _, r := range n.radioGroup() {
+			if r.isChecked() {
+				prev = r
+			}
+		}
+		n.setChecked(true)
+		undo = func() {
+			if prev != nil {
+				prev.setChecked(true)
+			} else {
+				n.setChecked(false)
+			}
+		}
+		checkedWas = prev == n
+	}
+	ev := newEvent(mouseEventClass, "click", js.Obj{
+		"bubbles": true, "cancelable": true, "composed": true, "view": window, "detail": 1,
+	})
+	if !dispatchEvent(n.jsValue(), ev) {
+		if undo != nil {
+			undo()
+		}
+		return
+	}
+	if undo != nil {
+		if !checkedWas || typ == "checkbox" {
+			dispatchTrusted(n.jsValue(), newEvent(eventClass, "input", js.Obj{"bubbles": true, "composed": true}))
+			dispatchTrusted(n.jsValue(), newEvent(eventClass, "change", js.Obj{"bubbles": true}))
+		}
+		return
+	}
+	n.activate()
+}
+
+// activate runs activation behavior of the element after a click.
+func (n *node) activate() {
+	switch {
+	case n.is("button") || (n.is("input") && (n.inputType() == "submit" || n.inputType() == "image")):
+		form := n.form()
+		if form == nil {
+			return
+		}
+		switch {
+		case n.is("button") && attrEnum(n, "type", "submit", "reset", "button") == "reset":
+			form.resetForm()
+		case n.is("button") && attrEnum(n, "type", "submit", "reset", "button") == "button":
+		default:
+			form.submitForm()
+		}
+	case n.is("input") && n.inputType() == "reset":
+		if form := n.form(); form != nil {
+			form.resetForm()
+		}
+	default:
+		// activate a control of a label
+		for p := n; p != nil; p = p.parent {
+			if p.is("label") {
+				if c := p.labelControl(); c != nil && !c.contains(n) {
+					c.click()
+				}
+				return
+			}
+		}
+	}
+}
+
+// form returns a form owner of the control.
+func (n *node) form() *node {
+	if id, ok := n.getAttr("form"); ok {
+		if f := n.hostRoot().elementByID(id); f.is("form") {
+			return f
+		}
+		return nil
+	}
+	for p := n.parent; p != nil; p = p.parent {
+		if p.is("form") {
+			return p
+		}
+	}
+	return nil
+}
+
+// submitForm fires a submit event on the form. The form is never actually submitted.
+func (n *node) submitForm() {
+	dispatchTrusted(n.jsValue(), newEvent(eventClass, "submit", js.Obj{"bubbles": true, "cancelable": true}))
+}
+
+// resetForm resets all controls of the form.
+func (n *node) resetForm() {
+	ev := newEvent(eventClass, "reset", js.Obj{"bubbles": true, "cancelable": true})
+	if !dispatchTrusted(n.jsValue(), ev) {
+		return
+	}
+	for _, c := range n.descendants(func(c *node) bool { return c.form() == n }) {
+		c.value, c.checked = nil, nil
+		for _, o := range c.descendants(func(c *node) bool { return c.is("option") }) {
+			o.selected = nil
+		}
+	}
+	changed()
+}
+
+// labelControl returns a control associated with the label.
+func (n *node) labelControl() *node {
+	if id, ok := n.getAttr("for"); ok {
+		c := n.root().elementByID(id)
+		if c != nil && c.labelable() {
+			return c
+		}
+		return nil
+	}
+	return n.find(func(c *node) bool { return c.labelable() })
+}
+
+// labelable checks if the element can be associated with a label.
+func (n *node) labelable() bool {
+	if !n.isHTML() {
+		return false
+	}
+	switch n.name {
+	case "button", "meter", "output", "progress", "select", "textarea":
+		return true
+	case "input":
+		return n.inputType() != "hidden"
+	}
+	return false
+}
+
+var htmlTags = map[string]string{
+	"a":        "HTMLAnchorElement",
+	"body":     "HTMLBodyElement",
+	"br":       "HTMLBRElement",
+	"button":   "HTMLButtonElement",
+	"canvas":   "HTMLCanvasElement",
+	"div":      "HTMLDivElement",
+	"form":     "HTMLFormElement",
+	"h1":       "HTMLHeadingElement",
+	"h2":       "HTMLHeadingElement",
+	"h3":       "HTMLHeadingElement",
+	"h4":       "HTMLHeadingElement",
+	"h5":       "HTMLHeadingElement",
+	"h6":       "HTMLHeadingElement",
+	"head":     "HTMLHeadElement",
+	"hr":       "HTMLHRElement",
+	"html":     "HTMLHtmlElement",
+	"img":      "HTMLImageElement",
+	"input":    "HTMLInputElement",
+	"label":    "HTMLLabelElement",
+	"li":       "HTMLLIElement",
+	"link":     "HTMLLinkElement",
+	"meta":     "HTMLMetaElement",
+	"ol":       "HTMLOListElement",
+	"option":   "HTMLOptionElement",
+	"p":        "HTMLParagraphElement",
+	"pre":      "HTMLPreElement",
+	"script":   "HTMLScriptElement",
+	"select":   "HTMLSelectElement",
+	"span":     "HTMLSpanElement",
+	"style":    "HTMLStyleElement",
+	"table":    "HTMLTableElement",
+	"template": "HTMLTemplateElement",
+	"textarea": "HTMLTextAreaElement",
+	"title":    "HTMLTitleElement",
+	"ul":       "HTMLUListElement",
+}
+
+// htmlClass returns a class for an HTML element with a given tag.
+func htmlClass(tag string) *class {
+	return htmlClasses[tag]
+}
+
+func setupElements() {
+	rectClass = newClass("DOMRect", nil, func(this js.Value, args []js.Value) {
+		num := func(i int) float64 {
+			if v := arg(args, i); v.Valid() {
+				return v.Float()
+			}
+			return 0
+		}
+		x, y, w, h := num(0), num(1), num(2), num(3)
+		for _, f := range []struct {
+			name string
+			v    float64
+		}{
+			{"x", x}, {"y", y}, {"width", w}, {"height", h},
+			{"top", math.Min(y, y+h)}, {"right", math.Max(x, x+w)},
+			{"bottom", math.Max(y, y+h)}, {"left", math.Min(x, x+w)},
+		} {
+			this.Set(f.name, f.v)
+		}
+	})
+
+	elementClass = newClass("Element", nodeClass, nil)
+	c := elementClass
+	c.getter("tagName", func(this js.Value) interface{} {
+		return thisNode(this).tagName()
+	}, nil)
+	c.getter("localName", func(this js.Value) interface{} {
+		return thisNode(this).name
+	}, nil)
+	c.getter("namespaceURI", func(this js.Value) interface{} {
+		if n := thisNode(this); n.ns != "" {
+			return n.ns
+		}
+		return nil
+	}, nil)
+	c.getter("prefix", func(this js.Value) interface{} {
+		if n := thisNode(this); n.prefix != "" {
+			return n.prefix
+		}
+		return nil
+	}, nil)
+	reflectString(c, "id", "id")
+	reflectString(c, "className", "class")
+	reflectString(c, "slot", "slot")
+	c.getter("classList", func(this js.Value) interface{} {
+		return classListOf(thisNode(this))
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setAttr("class", toString(v))
+	})
+	c.getter("attributes", func(this js.Value) interface{} {
+		return attributesOf(thisNode(this))
+	}, nil)
+	c.getter("shadowRoot", func(this js.Value) interface{} {
+		if s := thisNode(this).shadow; s != nil && s.mode == "open" {
+			return s.jsValue()
+		}
+		return nil
+	}, nil)
+	c.getter("innerHTML", func(this js.Value) interface{} {
+		return innerHTML(thisNode(this))
+	}, func(this js.Value, v js.Value) {
+		n := thisNode(this)
+		list := parseFragment(nullableString(v), n)
+		if n.content != nil {
+			n = n.content
+		}
+		n.replaceAll(list)
+	})
+	c.getter("outerHTML", func(this js.Value) interface{} {
+		return outerHTML(thisNode(this))
+	}, func(this js.Value, v js.Value) {
+		n := thisNode(this)
+		p := n.parent
+		if p == nil {
+			return
+		}
+		if p.typ == documentNode {
+			throwDOMException("NoModificationAllowedError", "Failed to set the 'outerHTML' property on 'Element': This element's parent is of type '#document'.")
+		}
+		ctx := p
+		if p.typ == fragmentNode {
+			ctx = newElement("body", nsHTML, "")
+		}
+		f := newFragment()
+		for _, c := range parseFragment(nullableString(v), ctx) {
+			f.appendChild(c)
+		}
+		p.replaceChild(f, n)
+	})
+	for _, name := range []string{"clientTop", "clientLeft", "clientWidth", "clientHeight", "scrollWidth", "scrollHeight", "scrollLeftMax", "scrollTopMax"} {
+		c.getter(name, func(this js.Value) interface{} {
+			thisNode(this)
+			return 0
+		}, nil)
+	}
+	c.getter("scrollTop", func(this js.Value) interface{} {
+		return thisNode(this).scrollTop
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).scrollTop = v.Float()
+	})
+	c.getter("scrollLeft", func(this js.Value) interface{} {
+		return thisNode(this).scrollLeft
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).scrollLeft = v.Float()
+	})
+	c.getter("previousElementSibling", func(this js.Value) interface{} {
+		return jsOf(thisNode(this).prevElement())
+	}, nil)
+	c.getter("nextElementSibling", func(this js.Value) interface{} {
+		return jsOf(thisNode(this).nextElement())
+	}, nil)
+	c.method("getAttribute", func(this js.Value, args []js.Value) interface{} {
+		if v, ok := thisNode(this).getAttr(toString(arg(args, 0))); ok {
+			return v
+		}
+		return nil
+	})
+	c.method("getAttributeNS", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		n.syncAttrs()
+		if i := n.attrIndexNS(nullableString(arg(args, 0)), toString(arg(args, 1))); i >= 0 {
+			return n.attrs[i].value
+		}
+		return nil
+	})
+	c.method("setAttribute", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this).setAttr(toString(arg(args, 0)), toString(arg(args, 1)))
+		return nil
+	})
+	c.method("setAttributeNS", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		ns, name := nullableString(arg(args, 0)), toString(arg(args, 1))
+		n.syncAttrs()
+		if i := n.attrIndexNS(ns, localName(name)); i >= 0 {
+			n.attrs[i].value = toString(arg(args, 2))
+			changed()
+		} else {
+			n.attrs = append(n.attrs, attr{ns: ns, name: name, value: toString(arg(args, 2))})
+			changed()
+		}
+		n.attrChanged(name)
+		return nil
+	})
+	c.method("removeAttribute", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this).removeAttr(toString(arg(args, 0)))
+		return nil
+	})
+	c.method("removeAttributeNS", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		n.syncAttrs()
+		if i := n.attrIndexNS(nullableString(arg(args, 0)), toString(arg(args, 1))); i >= 0 {
+			name := n.attrs[i].name
+			n.attrs = append(n.attrs[:i:i], n.attrs[i+1:]...)
+			changed()
+			n.attrChanged(name)
+		}
+		return nil
+	})
+	c.method("hasAttribute", func(this js.Value, args []js.Value) interface{} {
+		return thisNode(this).hasAttr(toString(arg(args, 0)))
+	})
+	c.method("hasAttributeNS", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		return n.attrIndexNS(nullableString(arg(args, 0)), toString(arg(args, 1))) >= 0
+	})
+	c.method("hasAttributes", func(this js.Value, args []js.Value) interface{} {
+		return len(thisNode(this).syncedAttrs()) != 0
+	})
+	c.method("getAttributeNames", func(this js.Value, args []js.Value) interface{} {
+		var names []interface{}
+		for _, a := range thisNode(this).syncedAttrs() {
+			names = append(names, a.name)
+		}
+		return newArray(names)
+	})
+	c.method("toggleAttribute", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		name := toString(arg(args, 0))
+		force := arg(args, 1)
+		has := n.hasAttr(name)
+		switch {
+		case has && (force.IsUndefined() || !force.Truthy()):
+			n.removeAttr(name)
+			return false
+		case !has && (force.IsUndefined() || force.Truthy()):
+			n.setAttr(name, "")
+			return true
+		}
+		return has
+	})
+	matches := func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		return compileSelector(toString(arg(args, 0))).match(n, n)
+	}
+	c.method("matches", matches)
+	c.method("webkitMatchesSelector", matches)
+	c.method("closest", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		sel := compileSelector(toString(arg(args, 0)))
+		for p := n; p.isElement(); p = p.parent {
+			if sel.match(p, n) {
+				return p.jsValue()
+			}
+		}
+		return nil
+	})
+	c.method("insertAdjacentElement", func(this js.Value, args []js.Value) interface{} {
+		el := argNode(args, 1, "insertAdjacentElement", "Element")
+		if !insertAdjacent(thisNode(this), toString(arg(args, 0)), el) {
+			return nil
+		}
+		return el.jsValue()
+	})
+	c.method("insertAdjacentText", func(this js.Value, args []js.Value) interface{} {
+		insertAdjacent(thisNode(this), toString(arg(args, 0)), newText(toString(arg(args, 1))))
+		return nil
+	})
+	c.method("insertAdjacentHTML", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		pos := strings.ToLower(toString(arg(args, 0)))
+		ctx := n
+		if pos == "beforebegin" || pos == "afterend" {
+			ctx = n.parent
+			if ctx == nil || ctx.typ == documentNode {
+				throwDOMException("NoModificationAllowedError", "The element has no parent.")
+			}
+		}
+		if !ctx.isElement() {
+			ctx = newElement("body", nsHTML, "")
+		}
+		f := newFragment()
+		for _, c := range parseFragment(toString(arg(args, 1)), ctx) {
+			f.appendChild(c)
+		}
+		insertAdjacent(n, pos, f)
+		return nil
+	})
+	c.method("getBoundingClientRect", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this)
+		return newRect(0, 0, 0, 0)
+	})
+	c.method("getClientRects", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this)
+		return newArray(nil)
+	})
+	for _, name := range []string{"scrollIntoView", "scroll", "scrollTo", "scrollBy"} {
+		c.method(name, func(this js.Value, args []js.Value) interface{} {
+			thisNode(this)
+			return nil
+		})
+	}
+	c.method("attachShadow", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		opts := arg(args, 0)
+		mode := ""
+		if opts.Valid() {
+			mode = toString(opts.Get("mode"))
+		}
+		if mode != "open" && mode != "closed" {
+			throwTypeError("Failed to execute 'attachShadow' on 'Element': The provided value '" + mode + "' is not a valid enum value of type ShadowRootMode.")
+		}
+		if !n.canAttachShadow() {
+			throwDOMException("NotSupportedError", "Failed to execute 'attachShadow' on 'Element': This element does not support attachShadow")
+		}
+		if n.shadow != nil {
+			throwDOMException("NotSupportedError", "Failed to execute 'attachShadow' on 'Element': Shadow root cannot be created on a host which already hosts a shadow tree.")
+		}
+		s := newFragment()
+		s.host, s.mode = n, mode
+		n.shadow = s
+		return s.jsValue()
+	})
+	parentNodeMixin(c)
+	childNodeMixin(c)
+
+	setupHTMLElements()
+
+	svgElementClass = newClass("SVGElement", elementClass, nil)
+	defineStyled(svgElementClass)
+}
+
+// canAttachShadow checks if a shadow root can be attached to the element.
+func (n *node) canAttachShadow() bool {
+	if !n.isHTML() {
+		return false
+	}
+	if strings.Contains(n.name, "-") {
+		return true
+	}
+	switch n.name {
+	case "article", "aside", "blockquote", "body", "div", "footer", "h1", "h2", "h3", "h4", "h5", "h6",
+		"header", "main", "nav", "p", "section", "span":
+		return true
+	}
+	return false
+}
+
+// insertAdjacent inserts a node at a given position relative to the element.
+func insertAdjacent(n *node, pos string, c *node) bool {
+	switch strings.ToLower(pos) {
+	case "beforebegin":
+		if n.parent == nil {
+			return false
+		}
+		n.parent.insertBefore(c, n)
+	case "afterbegin":
+		n.insertBefore(c, n.firstChild())
+	case "beforeend":
+		n.insertBefore(c, nil)
+	case "afterend":
+		if n.parent == nil {
+			return false
+		}
+		n.parent.insertBefore(c, n.next())
+	default:
+		throwDOMException("SyntaxError", "The value provided ('"+pos+"') is not one of 'beforeBegin', 'afterBegin', 'beforeEnd', or 'afterEnd'.")
+	}
+	return true
+}
+
+// defineStyled defines properties that are common for HTML and SVG elements.
+func defineStyled(c *class) {
+	c.getter("style", func(this js.Value) interface{} {
+		return styleOf(thisNode(this))
+	}, func(this js.Value, v js.Value) {
+		n := thisNode(this)
+		if v.InstanceOf(styleClass.ctor) {
+			v = js.ValueOf(cssText(v))
+		}
+		setCSSText(styleOf(n), nullableString(v))
+	})
+	c.getter("dataset", func(this js.Value) interface{} {
+		return datasetOf(thisNode(this))
+	}, nil)
+	c.getter("tabIndex", func(this js.Value) interface{} {
+		n := thisNode(this)
+		def := -1
+		if n.focusable() || n.isHTML() && (n.name == "input" || n.name == "button" || n.name == "select" || n.name == "textarea") {
+			def = 0
+		}
+		return attrInt(n, "tabindex", def)
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setAttr("tabindex", strconv.Itoa(v.Int()))
+	})
+	reflectString(c, "nonce", "nonce")
+	reflectBool(c, "autofocus", "autofocus")
+	c.method("focus", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this).focus()
+		return nil
+	})
+	c.method("blur", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this).blur()
+		return nil
+	})
+}
+
+func setupHTMLElements() {
+	htmlElementClass = newClass("HTMLElement", elementClass, nil)
+	c := htmlElementClass
+	defineStyled(c)
+	reflectString(c, "title", "title")
+	reflectString(c, "lang", "lang")
+	reflectString(c, "accessKey", "accesskey")
+	reflectBool(c, "hidden", "hidden")
+	reflectBool(c, "inert", "inert")
+	reflectEnum(c, "dir", "dir", "", "ltr", "rtl", "auto")
+	c.getter("accessKeyLabel", func(this js.Value) interface{} {
+		thisNode(this)
+		return ""
+	}, nil)
+	c.getter("translate", func(this js.Value) interface{} {
+		for n := thisNode(this); n.isHTML(); n = n.parent {
+			if v, ok := n.getAttr("translate"); ok {
+				return strings.ToLower(v) != "no"
+			}
+		}
+		return true
+	}, func(this js.Value, v js.Value) {
+		s := "no"
+		if v.Truthy() {
+			s = "yes"
+		}
+		thisNode(this).setAttr("translate", s)
+	})
+	c.getter("spellcheck", func(this js.Value) interface{} {
+		for n := thisNode(this); n.isHTML(); n = n.parent {
+			if v, ok := n.getAttr("spellcheck"); ok {
+				return strings.ToLower(v) != "false"
+			}
+		}
+		return true
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setAttr("spellcheck", strconv.FormatBool(v.Truthy()))
+	})
+	c.getter("draggable", func(this js.Value) interface{} {
+		n := thisNode(this)
+		if v, ok := n.getAttr("draggable"); ok {
+			return strings.ToLower(v) == "true"
+		}
+		return n.is("img") || (n.is("a") && n.hasAttr("href"))
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setAttr("draggable", strconv.FormatBool(v.Truthy()))
+	})
+	c.getter("contentEditable", func(this js.Value) interface{} {
+		v, ok := thisNode(this).getAttr("contenteditable")
+		if !ok {
+			return "inherit"
+		}
+		switch v = strings.ToLower(v); v {
+		case "", "true":
+			return "true"
+		case "false", "plaintext-only":
+			return v
+		}
+		return "inherit"
+	}, func(this js.Value, v js.Value) {
+		n := thisNode(this)
+		switch s := strings.ToLower(toString(v)); s {
+		case "inherit":
+			n.removeAttr("contenteditable")
+		case "true", "false", "plaintext-only":
+			n.setAttr("contenteditable", s)
+		default:
+			throwDOMException("SyntaxError", "Failed to set the 'contentEditable' property on 'HTMLElement': The value provided ('"+s+"') is not one of 'true', 'false', 'plaintext-only', or 'inherit'.")
+		}
+	})
+	c.getter("isContentEditable", func(this js.Value) interface{} {
+		return thisNode(this).contentEditable()
+	}, nil)
+	c.getter("innerText", func(this js.Value) interface{} {
+		return thisNode(this).textContent()
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setTextContent(nullableString(v))
+	})
+	for _, name := range []string{"offsetTop", "offsetLeft", "offsetWidth", "offsetHeight"} {
+		c.getter(name, func(this js.Value) interface{} {
+			thisNode(this)
+			return 0
+		}, nil)
+	}
+	c.getter("offsetParent", func(this js.Value) interface{} {
+		thisNode(this)
+		return nil
+	}, nil)
+	c.method("click", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this).click()
+		return nil
+	})
+
+	classes := make(map[string]*class)
+	for tag, name := range htmlTags {
+		cl := classes[name]
+		if cl == nil {
+			cl = newClass(name, htmlElementClass, nil)
+			classes[name] = cl
+		}
+		htmlClasses[tag] = cl
+	}
+
+	c = htmlClass("a")
+	reflectURL(c, "href", "href")
+	reflectString(c, "target", "target")
+	reflectString(c, "download", "download")
+	reflectString(c, "rel", "rel")
+	reflectString(c, "hreflang", "hreflang")
+	reflectString(c, "type", "type")
+	c.getter("text", func(this js.Value) interface{} {
+		return thisNode(this).textContent()
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setTextContent(toString(v))
+	})
+
+	c = htmlClass("img")
+	reflectURL(c, "src", "src")
+	reflectString(c, "alt", "alt")
+	reflectString(c, "srcset", "srcset")
+	reflectString(c, "sizes", "sizes")
+	reflectString(c, "loading", "loading")
+	reflectInt(c, "width", "width", 0)
+	reflectInt(c, "height", "height", 0)
+	for _, name := range []string{"naturalWidth", "naturalHeight"} {
+		c.getter(name, func(this js.Value) interface{} {
+			thisNode(this)
+			return 0
+		}, nil)
+	}
+	c.getter("complete", func(this js.Value) interface{} {
+		thisNode(this)
+		return true
+	}, nil)
+
+	c = htmlClass("canvas")
+	reflectInt(c, "width", "width", 300)
+	reflectInt(c, "height", "height", 150)
+	c.method("getContext", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this)
+		return nil
+	})
+	c.method("toDataURL", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this)
+		return "data:,"
+	})
+
+	c = htmlClass("script")
+	reflectURL(c, "src", "src")
+	reflectString(c, "type", "type")
+	reflectString(c, "integrity", "integrity")
+	reflectBool(c, "async", "async")
+	reflectBool(c, "defer", "defer")
+	reflectBool(c, "noModule", "nomodule")
+	c.getter("text", func(this js.Value) interface{} {
+		return thisNode(this).childText()
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setTextContent(toString(v))
+	})
+
+	c = htmlClass("link")
+	reflectURL(c, "href", "href")
+	reflectString(c, "rel", "rel")
+	reflectString(c, "type", "type")
+	reflectString(c, "media", "media")
+
+	c = htmlClass("style")
+	reflectString(c, "media", "media")
+
+	c = htmlClass("meta")
+	reflectString(c, "name", "name")
+	reflectString(c, "content", "content")
+	reflectString(c, "httpEquiv", "http-equiv")
+
+	c = htmlClass("title")
+	c.getter("text", func(this js.Value) interface{} {
+		return thisNode(this).childText()
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setTextContent(toString(v))
+	})
+
+	c = htmlClass("ol")
+	reflectBool(c, "reversed", "reversed")
+	reflectInt(c, "start", "start", 1)
+	reflectString(c, "type", "type")
+
+	c = htmlClass("li")
+	reflectInt(c, "value", "value", 0)
+
+	c = htmlClass("label")
+	reflectString(c, "htmlFor", "for")
+	c.getter("control", func(this js.Value) interface{} {
+		return jsOf(thisNode(this).labelControl())
+	}, nil)
+
+	c = htmlClass("template")
+	c.getter("content", func(this js.Value) interface{} {
+		return jsOf(thisNode(this).content)
+	}, nil)
+
+	setupForms()
+}
+
+// formControls lists local names of elements that are listed in HTMLFormElement.elements.
+var formControls = map[string]bool{
+	"button": true, "fieldset": true, "input": true, "object": true, "output": true, "select": true, "textarea": true,
+}
+
+func setupForms() {
+	c := htmlClass("form")
+	reflectURL(c, "action", "action")
+	reflectString(c, "name", "name")
+	reflectString(c, "target", "target")
+	reflectBool(c, "noValidate", "novalidate")
+	reflectEnum(c, "method", "method", "get", "post", "dialog")
+	reflectEnum(c, "enctype", "enctype", "application/x-www-form-urlencoded", "multipart/form-data", "text/plain")
+	elements := func(n *node) func() []*node {
+		return func() []*node {
+			return n.hostRoot().descendants(func(c *node) bool {
+				return c.isHTML() && formControls[c.name] && c.form() == n
+			})
+		}
+	}
+	c.getter("elements", func(this js.Value) interface{} {
+		return newCollection(collectionClass, elements(thisNode(this)))
+	}, nil)
+	c.getter("length", func(this js.Value) interface{} {
+		return len(elements(thisNode(this))())
+	}, nil)
+	c.method("submit", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this)
+		return nil
+	})
+	c.method("requestSubmit", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this).submitForm()
+		return nil
+	})
+	c.method("reset", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this).resetForm()
+		return nil
+	})
+	for _, name := range []string{"checkValidity", "reportValidity"} {
+		c.method(name, func(this js.Value, args []js.Value) interface{} {
+			thisNode(this)
+			return true
+		})
+	}
+
+	// common properties of form controls
+	control := func(c *class) {
+		reflectString(c, "name", "name")
+		reflectBool(c, "disabled", "disabled")
+		c.getter("form", func(this js.Value) interface{} {
+			return jsOf(thisNode(this).form())
+		}, nil)
+		c.getter("labels", func(this js.Value) interface{} {
+			n := thisNode(this)
+			return newCollection(nodeListClass, func() []*node {
+				return n.hostRoot().descendants(func(c *node) bool {
+					return c.is("label") && c.labelControl() == n
+				})
+			})
+		}, nil)
+		for _, name := range []string{"checkValidity", "reportValidity"} {
+			c.method(name, func(this js.Value, args []js.Value) interface{} {
+				thisNode(this)
+				return true
+			})
+		}
+		c.method("setCustomValidity", func(this js.Value, args []js.Value) interface{} {
+			thisNode(this)
+			return nil
+		})
+	}
+
+	c = htmlClass("button")
+	control(c)
+	reflectEnum(c, "type", "type", "submit", "reset", "button")
+	reflectString(c, "value", "value")
+
+	c = htmlClass("input")
+	control(c)
+	setupInput(c)
+
+	c = htmlClass("textarea")
+	control(c)
+	reflectString(c, "placeholder", "placeholder")
+	reflectBool(c, "readOnly", "readonly")
+	reflectBool(c, "required", "required")
+	reflectInt(c, "rows", "rows", 2)
+	reflectInt(c, "cols", "cols", 20)
+	reflectInt(c, "maxLength", "maxlength", -1)
+	reflectInt(c, "minLength", "minlength", -1)
+	reflectString(c, "wrap", "wrap")
+	c.getter("type", func(this js.Value) interface{} {
+		thisNode(this)
+		return "textarea"
+	}, nil)
+	c.getter("defaultValue", func(this js.Value) interface{} {
+		return thisNode(this).childText()
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setTextContent(toString(v))
+	})
+	c.getter("value", func(this js.Value) interface{} {
+		n := thisNode(this)
+		if n.value != nil {
+			return *n.value
+		}
+		return n.childText()
+	}, func(this js.Value, v js.Value) {
+		s := nullableString(v)
+		thisNode(this).value = &s
+	})
+	c.getter("textLength", func(this js.Value) interface{} {
+		n := thisNode(this)
+		return n.jsValue().Get("value").Length()
+	}, nil)
+	c.method("select", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this)
+		return nil
+	})
+
+	c = htmlClass("select")
+	control(c)
+	setupSelect(c)
+}
+
+// inputType returns a normalized type of an input element.
+func (n *node) inputType() string {
+	if !n.is("input") {
+		return ""
+	}
+	v, _ := n.getAttr("type")
+	switch v = strings.ToLower(v); v {
+	case "hidden", "search", "tel", "url", "email", "password", "date", "month", "week", "time",
+		"datetime-local", "number", "range", "color", "checkbox", "radio", "file", "submit",
+		"image", "reset", "button":
+		return v
+	}
+	return "text"
+}
+
+// isChecked checks if a checkbox or a radio button is checked.
+func (n *node) isChecked() bool {
+	if n.checked != nil {
+		return *n.checked
+	}
+	return n.hasAttr("checked")
+}
+
+// setChecked sets the checkedness of a checkbox or a radio button.
+func (n *node) setChecked(v bool) {
+	n.checked = &v
+	if v && n.inputType() == "radio" {
+		for _, r := range n.radioGroup() {
+			if r != n {
+				f := false
+				r.checked = &f
+			}
+		}
+	}
+	changed()
+}
+
+// radioGroup returns all radio buttons in the same group as the element.
+func (n *node) radioGroup() []*node {
+	name, _ := n.getAttr("name")
+	if name == "" {
+		return []*node{n}
+	}
+	form := n.form()
+	return n.root().descendants(func(c *node) bool {
+		v, _ := c.getAttr("name")
+		return c.inputType() == "radio" && v == name && c.form() == form
+	})
+}
+
+func setupInput(c *class) {
+	c.getter("type", func(this js.Value) interface{} {
+		return thisNode(this).inputType()
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setAttr("type", toString(v))
+	})
+	for prop, name := range map[string]string{
+		"placeholder": "placeholder", "min": "min", "max": "max", "step": "step", "pattern": "pattern",
+		"accept": "accept", "alt": "alt", "autocomplete": "autocomplete", "inputMode": "inputmode",
+	} {
+		reflectString(c, prop, name)
+	}
+	reflectURL(c, "src", "src")
+	reflectBool(c, "readOnly", "readonly")
+	reflectBool(c, "required", "required")
+	reflectBool(c, "multiple", "multiple")
+	reflectInt(c, "maxLength", "maxlength", -1)
+	reflectInt(c, "minLength", "minlength", -1)
+	reflectInt(c, "size", "size", 20)
+	reflectString(c, "defaultValue", "value")
+	reflectBool(c, "defaultChecked", "checked")
+
+	// valueMode reports how the value of the input is stored: "value" for a dirty value,
+	// "default" for the value attribute and "default/on" for checkboxes and radio buttons.
+	valueMode := func(n *node) string {
+		switch n.inputType() {
+		case "hidden", "submit", "image", "reset", "button":
+			return "default"
+		case "checkbox", "radio":
+			return "default/on"
+		}
+		return "value"
+	}
+	c.getter("value", func(this js.Value) interface{} {
+		n := thisNode(this)
+		switch valueMode(n) {
+		case "default":
+			v, _ := n.getAttr("value")
+			return v
+		case "default/on":
+			if v, ok := n.getAttr("value"); ok {
+				return v
+			}
+			return "on"
+		}
+		if n.value != nil {
+			return *n.value
+		}
+		v, _ := n.getAttr("value")
+		return sanitizeValue(n.inputType(), v)
+	}, func(this js.Value, v js.Value) {
+		n := thisNode(this)
+		s := nullableString(v)
+		if valueMode(n) != "value" {
+			n.setAttr("value", s)
+			return
+		}
+		s = sanitizeValue(n.inputType(), s)
+		n.value = &s
+	})
+	c.getter("valueAsNumber", func(this js.Value) interface{} {
+		n := thisNode(this)
+		switch n.inputType() {
+		case "number", "range":
+			f, err := strconv.ParseFloat(toString(n.jsValue().Get("value")), 64)
+			if err == nil {
+				return f
+			}
+		}
+		return math.NaN()
+	}, func(this js.Value, v js.Value) {
+		n := thisNode(this)
+		f := v.Float()
+		if math.IsNaN(f) {
+			n.jsValue().Set("value", "")
+		} else {
+			n.jsValue().Set("value", f)
+		}
+	})
+	c.getter("checked", func(this js.Value) interface{} {
+		return thisNode(this).isChecked()
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setChecked(v.Truthy())
+	})
+	c.getter("indeterminate", func(this js.Value) interface{} {
+		thisNode(this)
+		return false
+	}, func(this js.Value, v js.Value) {})
+	for _, name := range []string{"select", "setSelectionRange", "setRangeText"} {
+		c.method(name, func(this js.Value, args []js.Value) interface{} {
+			thisNode(this)
+			return nil
+		})
+	}
+}
+
+// sanitizeValue implements a value sanitization algorithm for a few input types.
+func sanitizeValue(typ, v string) string {
+	switch typ {
+	case "text", "search", "tel", "password":
+		return strings.NewReplacer("\r", "", "\n", "").Replace(v)
+	case "email", "url":
+		return strings.TrimSpace(strings.NewReplacer("\r", "", "\n", "").Replace(v))
+	case "number":
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return ""
+		}
+	case "color":
+		if len(v) != 7 || v[0] != '#' {
+			return "#000000"
+		}
+		if _, err := strconv.ParseUint(v[1:], 16, 32); err != nil {
+			return "#000000"
+		}
+		return strings.ToLower(v)
+	}
+	return v
+}
+
+// options returns all option elements of a select element.
+func (n *node) options() []*node {
+	return n.descendants(func(c *node) bool { return c.is("option") })
+}
+
+// optionSelected checks if an option is selected, without considering the default selection of the select.
+func (n *node) optionSelected() bool {
+	if n.selected != nil {
+		return *n.selected
+	}
+	return n.hasAttr("selected")
+}
+
+// optionValue returns a value of the option element.
+func (n *node) optionValue() string {
+	if v, ok := n.getAttr("value"); ok {
+		return v
+	}
+	return n.optionText()
+}
+
+// optionText returns a text of the option element with collapsed whitespace.
+func (n *node) optionText() string {
+	return strings.Join(strings.Fields(n.textContent()), " ")
+}
+
+// selectOf returns a select element that contains the option.
+func (n *node) selectOf() *node {
+	for p := n.parent; p != nil; p = p.parent {
+		if p.is("select") {
+			return p
+		}
+		if !p.is("optgroup") {
+			break
+		}
+	}
+	return nil
+}
+
+// selectedIndex returns an index of the selected option of a select element, or -1.
+func (n *node) selectedIndex() int {
+	opts := n.options()
+	for i, o := range opts {
+		if o.optionSelected() {
+			return i
+		}
+	}
+	if n.hasAttr("multiple") || attrInt(n, "size", 1) > 1 {
+		return -1
+	}
+	for i, o := range opts {
+		if !o.disabled() {
+			return i
+		}
+	}
+	return -1
+}
+
+// selectOption selects an option of a select element with a given index and deselects all others.
+func (n *node) selectOption(idx int) {
+	for i, o := range n.options() {
+		v := i == idx
+		o.selected = &v
+	}
+	changed()
+}
+
+func setupSelect(c *class) {
+	reflectBool(c, "multiple", "multiple")
+	reflectBool(c, "required", "required")
+	reflectInt(c, "size", "size", 0)
+	c.getter("type", func(this js.Value) interface{} {
+		if thisNode(this).hasAttr("multiple") {
+			return "select-multiple"
+		}
+		return "select-one"
+	}, nil)
+	c.getter("options", func(this js.Value) interface{} {
+		return newCollection(collectionClass, thisNode(this).options)
+	}, nil)
+	c.getter("length", func(this js.Value) interface{} {
+		return len(thisNode(this).options())
+	}, nil)
+	c.method("item", func(this js.Value, args []js.Value) interface{} {
+		opts := thisNode(this).options()
+		if i := arg(args, 0).Int(); i >= 0 && i < len(opts) {
+			return opts[i].jsValue()
+		}
+		return nil
+	})
+	c.getter("selectedOptions", func(this js.Value) interface{} {
+		n := thisNode(this)
+		return newCollection(collectionClass, func() []*node {
+			var out []*node
+			idx := n.selectedIndex()
+			for i, o := range n.options() {
+				if o.optionSelected() || i == idx {
+					out = append(out, o)
+				}
+			}
+			return out
+		})
+	}, nil)
+	c.getter("selectedIndex", func(this js.Value) interface{} {
+		return thisNode(this).selectedIndex()
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).selectOption(v.Int())
+	})
+	c.getter("value", func(this js.Value) interface{} {
+		n := thisNode(this)
+		if i := n.selectedIndex(); i >= 0 {
+			return n.options()[i].optionValue()
+		}
+		return ""
+	}, func(this js.Value, v js.Value) {
+		n := thisNode(this)
+		s := toString(v)
+		idx := -1
+		for i, o := range n.options() {
+			if o.optionValue() == s {
+				idx = i
+				break
+			}
+		}
+		n.selectOption(idx)
+	})
+
+	c = htmlClass("option")
+	reflectBool(c, "disabled", "disabled")
+	reflectString(c, "label", "label")
+	reflectBool(c, "defaultSelected", "selected")
+	c.getter("value", func(this js.Value) interface{} {
+		return thisNode(this).optionValue()
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setAttr("value", toString(v))
+	})
+	c.getter("text", func(this js.Value) interface{} {
+		return thisNode(this).optionText()
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setTextContent(toString(v))
+	})
+	c.getter("index", func(this js.Value) interface{} {
+		n := thisNode(this)
+		if s := n.selectOf(); s != nil {
+			for i, o := range s.options() {
+				if o == n {
+					return i
+				}
+			}
+		}
+		return 0
+	}, nil)
+	c.getter("selected", func(this js.Value) interface{} {
+		n := thisNode(this)
+		if s := n.selectOf(); s != nil {
+			if i := s.selectedIndex(); i >= 0 {
+				return s.options()[i] == n || n.optionSelected()
+			}
+		}
+		return n.optionSelected()
+	}, func(this js.Value, v js.Value) {
+		n := thisNode(this)
+		sel := v.Truthy()
+		if s := n.selectOf(); s != nil && sel && !s.hasAttr("multiple") {
+			for _, o := range s.options() {
+				f := false
+				o.selected = &f
+			}
+		}
+		n.selected = &sel
+		changed()
+	})
+	c.getter("form", func(this js.Value) interface{} {
+		if s := thisNode(this).selectOf(); s != nil {
+			return jsOf(s.form())
+		}
+		return nil
+	}, nil)
+}
@@ -0,0 +1,590 @@
+//+build !wasm
+
+package headless
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dennwc/dom/js"
+)
+
+// Event phases.
+const (
+	phaseNone = iota
+	phaseCapturing
+	phaseAtTarget
+	phaseBubbling
+)
+
+// listener is a registered event listener.
+type listener struct {
+	typ     string
+	fnc     js.Value
+	capture bool
+	once    bool
+	passive bool
+	removed bool
+}
+
+// eventTarget stores listeners of a single event target.
+type eventTarget struct {
+	listeners []*listener
+}
+
+// event is an internal state of a JS Event object.
+type event struct {
+	typ        string
+	bubbles    bool
+	cancelable bool
+	composed   bool
+	trusted    bool
+	timeStamp  float64
+	fields     map[string]js.Value // fields of event subclasses
+
+	target, current js.Value
+	path            []js.Value
+	phase           int
+	dispatching     bool
+	stop            bool
+	stopImmediate   bool
+	canceled        bool
+	inPassive       bool
+}
+
+var (
+	// mu protects event targets and the registry of events. Unlike the rest of the DOM, events can be
+	// dispatched by the event loop, for example when a message is delivered to MessagePort.
+	mu      sync.Mutex
+	targets = make(map[js.Ref]*eventTarget)
+	events  = make(map[js.Ref]*event)
+
+	startTime = time.Now()
+
+	eventTargetClass *class
+	eventClass       *class
+	mouseEventClass  *class
+	focusEventClass  *class
+)
+
+// targetOf returns listeners of an event target. It must be called with mu held.
+func targetOf(v js.Value) *eventTarget {
+	t := targets[v.Ref]
+	if t == nil {
+		t = &eventTarget{}
+		targets[v.Ref] = t
+	}
+	return t
+}
+
+func eventOf(v js.Value) *event {
+	mu.Lock()
+	e := events[v.Ref]
+	mu.Unlock()
+	if e == nil {
+		throwTypeError("Illegal invocation")
+	}
+	return e
+}
+
+// listenerOptions parses the options argument of addEventListener and removeEventListener.
+func listenerOptions(opts js.Value) (l listener, signal js.Value) {
+	switch opts.Type() {
+	case js.TypeBoolean:
+		l.capture = opts.Bool()
+	case js.TypeObject:
+		l.capture = opts.Get("capture").Truthy()
+		l.once = opts.Get("once").Truthy()
+		l.passive = opts.Get("passive").Truthy()
+		signal = opts.Get("signal")
+	}
+	return
+}
+
+func addEventListener(this js.Value, typ string, fnc js.Value, opts js.Value) {
+	if !fnc.Valid() {
+		return
+	}
+	l, signal := listenerOptions(opts)
+	if signal.Valid() && signal.Get("aborted").Bool() {
+		return
+	}
+	mu.Lock()
+	t := targetOf(this)
+	for _, l2 := range t.listeners {
+		if l2.typ == typ && l2.fnc == fnc && l2.capture == l.capture {
+			mu.Unlock()
+			return
+		}
+	}
+	l.typ, l.fnc = typ, fnc
+	pl := &l
+	t.listeners = append(t.listeners, pl)
+	mu.Unlock()
+	if signal.Valid() {
+		var cb js.Func
+		cb = js.FuncOf(func(_ js.Value, _ []js.Value) interface{} {
+			mu.Lock()
+			removeListener(t, pl)
+			mu.Unlock()
+			cb.Release()
+			return nil
+		})
+		signal.Call("addEventListener", "abort", cb, js.Obj{"once": true})
+	}
+}
+
+// removeListener removes the listener from the target. It must be called with mu held.
+func removeListener(t *eventTarget, l *listener) {
+	l.removed = true
+	for i, l2 := range t.listeners {
+		if l2 == l {
+			t.listeners = append(t.listeners[:i:i], t.listeners[i+1:]...)
+			return
+		}
+	}
+}
+
+func removeEventListener(this js.Value, typ string, fnc js.Value, opts js.Value) {
+	mu.Lock()
+	defer mu.Unlock()
+	t := targets[this.Ref]
+	if t == nil {
+		return
+	}
+	l, _ := listenerOptions(opts)
+	for _, l2 := range t.listeners {
+		if l2.typ == typ && l2.fnc == fnc && l2.capture == l.capture {
+			removeListener(t, l2)
+			return
+		}
+	}
+}
+
+// parentTargetFunc returns the parent of the event target in the event path, if any.
+// It is set by the DOM implementation.
+var parentTargetFunc func(t js.Value, e *event) (js.Value, bool)
+
+// dispatchEvent implements EventTarget.dispatchEvent.
+func dispatchEvent(target js.Value, ev js.Value) bool {
+	e := eventOf(ev)
+	if e.dispatching {
+		throwDOMException("InvalidStateError", "The event is already being dispatched.")
+	}
+	e.dispatching = true
+	e.target = target
+	e.stop, e.stopImmediate = false, false
+	e.path = []js.Value{target}
+	for cur := target; parentTargetFunc != nil; {
+		p, ok := parentTargetFunc(cur, e)
+		if !ok {
+			break
+		}
+		e.path = append(e.path, p)
+		cur = p
+	}
+	path := e.path
+
+	e.phase = phaseCapturing
+	for i := len(path) - 1; i > 0 && !e.stop; i-- {
+		invokeListeners(path[i], ev, e, true, false)
+	}
+	if !e.stop {
+		e.phase = phaseAtTarget
+		invokeListeners(path[0], ev, e, true, true)
+	}
+	if e.bubbles {
+		e.phase = phaseBubbling
+		for i := 1; i < len(path) && !e.stop; i++ {
+			invokeListeners(path[i], ev, e, false, true)
+		}
+	}
+	e.phase = phaseNone
+	e.current = null
+	e.dispatching = false
+	e.path = nil
+	return !e.canceled
+}
+
+func invokeListeners(cur js.Value, ev js.Value, e *event, capture, bubble bool) {
+	mu.Lock()
+	t := targets[cur.Ref]
+	var list []*listener
+	if t != nil {
+		list = append(list, t.listeners...)
+	}
+	mu.Unlock()
+	if t == nil {
+		return
+	}
+	e.current = cur
+	for _, l := range list {
+		mu.Lock()
+		skip := l.removed || l.typ != e.typ || (l.capture && !capture) || (!l.capture && !bubble)
+		if !skip && l.once {
+			removeListener(t, l)
+		}
+		mu.Unlock()
+		if skip {
+			continue
+		}
+		e.inPassive = l.passive
+		callListener(l.fnc, cur, ev)
+		e.inPassive = false
+		if e.stopImmediate {
+			break
+		}
+	}
+}
+
+// callListener calls an event listener and reports exceptions thrown by it, the same way as browsers do.
+func callListener(fnc js.Value, this js.Value, ev js.Value) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(js.Error)
+			if !ok {
+				panic(r)
+			}
+			fmt.Fprintln(os.Stderr, "Uncaught", js.Value{Ref: e.Value}.Get("stack").String())
+		}
+	}()
+	if fnc.Type() == js.TypeFunction {
+		fnc.Call("call", this, ev)
+	} else if h := fnc.Get("handleEvent"); h.Type() == js.TypeFunction {
+		h.Call("call", fnc, ev)
+	}
+}
+
+// initEvent initializes an internal state of a new event object.
+func initEvent(this js.Value, typ string, init js.Value) *event {
+	e := &event{
+		typ:       typ,
+		timeStamp: float64(time.Since(startTime)) / float64(time.Millisecond),
+		current:   null,
+		target:    null,
+	}
+	if init.Valid() {
+		e.bubbles = init.Get("bubbles").Truthy()
+		e.cancelable = init.Get("cancelable").Truthy()
+		e.composed = init.Get("composed").Truthy()
+	}
+	mu.Lock()
+	events[this.Ref] = e
+	mu.Unlock()
+	return e
+}
+
+// newEvent creates a new event of a given class. Init values are set the same way as in a constructor.
+func newEvent(c *class, typ string, init js.Obj) js.Value {
+	return c.ctor.New(typ, init)
+}
+
+// eventField describes a field of an event subclass initialized from a dictionary.
+type eventField struct {
+	name string
+	def  func() interface{}
+}
+
+// def returns a default value constructor for a constant value.
+func def(v interface{}) func() interface{} {
+	return func() interface{} { return v }
+}
+
+// newEventClass defines an Event subclass with given fields. Fields are read from the init dictionary
+// passed to the constructor, or are set to default values.
+func newEventClass(name string, parent *class, fields ...eventField) *class {
+	var all []eventField
+	for p := parent; p != nil && p != eventClass; p = eventParents[p] {
+		all = append(all, eventFields[p]...)
+	}
+	all = append(all, fields...)
+	c := newClass(name, parent, func(this js.Value, args []js.Value) {
+		if len(args) == 0 {
+			throwTypeError("Failed to construct '" + name + "': 1 argument required, but only 0 present.")
+		}
+		init := arg(args, 1)
+		e := initEvent(this, args[0].String(), init)
+		e.fields = make(map[string]js.Value)
+		for _, f := range all {
+			v := js.Value{}
+			if init.Valid() {
+				v = init.Get(f.name)
+			}
+			if v.IsUndefined() {
+				v = js.ValueOf(f.def())
+			}
+			e.fields[f.name] = v
+		}
+	})
+	for _, f := range fields {
+		f := f
+		c.getter(f.name, func(this js.Value) interface{} {
+			return eventOf(this).fields[f.name]
+		}, nil)
+	}
+	eventParents[c] = parent
+	eventFields[c] = fields
+	return c
+}
+
+var (
+	eventParents = make(map[*class]*class)
+	eventFields  = make(map[*class][]eventField)
+)
+
+func setupEvents() {
+	eventTargetClass = newClass("EventTarget", nil, func(this js.Value, args []js.Value) {})
+	et := eventTargetClass
+	et.method("addEventListener", func(this js.Value, args []js.Value) interface{} {
+		addEventListener(this, toString(arg(args, 0)), arg(args, 1), arg(args, 2))
+		return nil
+	})
+	et.method("removeEventListener", func(this js.Value, args []js.Value) interface{} {
+		removeEventListener(this, toString(arg(args, 0)), arg(args, 1), arg(args, 2))
+		return nil
+	})
+	et.method("dispatchEvent", func(this js.Value, args []js.Value) interface{} {
+		ev := arg(args, 0)
+		mu.Lock()
+		e := events[ev.Ref]
+		mu.Unlock()
+		if e == nil {
+			throwTypeError("Failed to execute 'dispatchEvent' on 'EventTarget': parameter 1 is not of type 'Event'.")
+		}
+		return dispatchEvent(this, ev)
+	})
+
+	eventClass = newClass("Event", nil, func(this js.Value, args []js.Value) {
+		if len(args) == 0 {
+			throwTypeError("Failed to construct 'Event': 1 argument required, but only 0 present.")
+		}
+		initEvent(this, args[0].String(), arg(args, 1))
+	})
+	ec := eventClass
+	ec.constant("NONE", phaseNone)
+	ec.constant("CAPTURING_PHASE", phaseCapturing)
+	ec.constant("AT_TARGET", phaseAtTarget)
+	ec.constant("BUBBLING_PHASE", phaseBubbling)
+	ec.getter("type", func(this js.Value) interface{} { return eventOf(this).typ }, nil)
+	ec.getter("bubbles", func(this js.Value) interface{} { return eventOf(this).bubbles }, nil)
+	ec.getter("cancelable", func(this js.Value) interface{} { return eventOf(this).cancelable }, nil)
+	ec.getter("composed", func(this js.Value) interface{} { return eventOf(this).composed }, nil)
+	ec.getter("isTrusted", func(this js.Value) interface{} { return eventOf(this).trusted }, nil)
+	ec.getter("timeStamp", func(this js.Value) interface{} { return eventOf(this).timeStamp }, nil)
+	ec.getter("eventPhase", func(this js.Value) interface{} { return eventOf(this).phase }, nil)
+	ec.getter("target", func(this js.Value) interface{} { return eventOf(this).target }, nil)
+	ec.getter("srcElement", func(this js.Value) interface{} { return eventOf(this).target }, nil)
+	ec.getter("currentTarget", func(this js.Value) interface{} { return eventOf(this).current }, nil)
+	ec.getter("defaultPrevented", func(this js.Value) interface{} { return eventOf(this).canceled }, nil)
+	ec.getter("returnValue", func(this js.Value) interface{} {
+		return !eventOf(this).canceled
+	}, func(this js.Value, v js.Value) {
+		if e := eventOf(this); !v.Truthy() && e.cancelable {
+			e.canceled = true
+		}
+	})
+	ec.getter("cancelBubble", func(this js.Value) interface{} {
+		return eventOf(this).stop
+	}, func(this js.Value, v js.Value) {
+		if v.Truthy() {
+			eventOf(this).stop = true
+		}
+	})
+	composedPath := func(this js.Value) interface{} {
+		e := eventOf(this)
+		arr := make([]interface{}, 0, len(e.path))
+		for _, p := range e.path {
+			arr = append(arr, p)
+		}
+		return newArray(arr)
+	}
+	ec.method("composedPath", func(this js.Value, args []js.Value) interface{} {
+		return composedPath(this)
+	})
+	// legacy Chrome property, used by dom.BaseEvent.Path
+	ec.getter("path", composedPath, nil)
+	ec.method("initEvent", func(this js.Value, args []js.Value) interface{} {
+		if e := eventOf(this); !e.dispatching {
+			e.typ = toString(arg(args, 0))
+			e.bubbles = arg(args, 1).Truthy()
+			e.cancelable = arg(args, 2).Truthy()
+			e.stop, e.stopImmediate, e.canceled = false, false, false
+		}
+		return nil
+	})
+	ec.method("preventDefault", func(this js.Value, args []js.Value) interface{} {
+		if e := eventOf(this); e.cancelable && !e.inPassive {
+			e.canceled = true
+		}
+		return nil
+	})
+	ec.method("stopPropagation", func(this js.Value, args []js.Value) interface{} {
+		eventOf(this).stop = true
+		return nil
+	})
+	ec.method("stopImmediatePropagation", func(this js.Value, args []js.Value) interface{} {
+		e := eventOf(this)
+		e.stop, e.stopImmediate = true, true
+		return nil
+	})
+
+	nullValue := def(nil)
+	emptyArray := func() interface{} { return newArray(nil) }
+	modifiers := []eventField{
+		{"ctrlKey", def(false)}, {"shiftKey", def(false)},
+		{"altKey", def(false)}, {"metaKey", def(false)},
+	}
+	newEventClass("CustomEvent", eventClass, eventField{"detail", nullValue})
+	newEventClass("ErrorEvent", eventClass,
+		eventField{"message", def("")}, eventField{"filename", def("")},
+		eventField{"lineno", def(0)}, eventField{"colno", def(0)},
+		eventField{"error", nullValue},
+	)
+	messageEventClass = newEventClass("MessageEvent", eventClass,
+		eventField{"data", nullValue}, eventField{"origin", def("")},
+		eventField{"lastEventId", def("")}, eventField{"source", nullValue},
+		eventField{"ports", emptyArray},
+	)
+	ui := newEventClass("UIEvent", eventClass, eventField{"view", nullValue}, eventField{"detail", def(0)})
+	mouseEventClass = newEventClass("MouseEvent", ui, append(modifiers,
+		eventField{"screenX", def(0)}, eventField{"screenY", def(0)},
+		eventField{"clientX", def(0)}, eventField{"clientY", def(0)},
+		eventField{"pageX", def(0)}, eventField{"pageY", def(0)},
+		eventField{"offsetX", def(0)}, eventField{"offsetY", def(0)},
+		eventField{"movementX", def(0)}, eventField{"movementY", def(0)},
+		eventField{"button", def(0)}, eventField{"buttons", def(0)},
+		eventField{"relatedTarget", nullValue},
+	)...)
+	newEventClass("KeyboardEvent", ui, append(modifiers,
+		eventField{"key", def("")}, eventField{"code", def("")},
+		eventField{"location", def(0)}, eventField{"repeat", def(false)},
+		eventField{"isComposing", def(false)},
+		eventField{"charCode", def(0)}, eventField{"keyCode", def(0)},
+	)...)
+	focusEventClass = newEventClass("FocusEvent", ui, eventField{"relatedTarget", nullValue})
+	newEventClass("InputEvent", ui,
+		eventField{"data", nullValue}, eventField{"inputType", def("")},
+		eventField{"isComposing", def(false)},
+	)
+	newEventClass("WheelEvent", mouseEventClass,
+		eventField{"deltaX", def(0)}, eventField{"deltaY", def(0)},
+		eventField{"deltaZ", def(0)}, eventField{"deltaMode", def(0)},
+	)
+	newEventClass("PointerEvent", mouseEventClass,
+		eventField{"pointerId", def(0)},
+		eventField{"width", def(1)}, eventField{"height", def(1)},
+		eventField{"pressure", def(0)}, eventField{"tangentialPressure", def(0)},
+		eventField{"tiltX", def(0)}, eventField{"tiltY", def(0)},
+		eventField{"twist", def(0)}, eventField{"pointerType", def("")},
+		eventField{"isPrimary", def(false)},
+	)
+	newEventClass("DragEvent", mouseEventClass, eventField{"dataTransfer", nullValue})
+	newEventClass("TouchEvent", ui, append(modifiers,
+		eventField{"touches", emptyArray}, eventField{"targetTouches", emptyArray},
+		eventField{"changedTouches", emptyArray},
+	)...)
+	setupTouch()
+	setupAbort()
+}
+
+// setupTouch defines a Touch class that describes a single touch point of a TouchEvent.
+func setupTouch() {
+	fields := []string{
+		"identifier", "target", "clientX", "clientY", "screenX", "screenY", "pageX", "pageY",
+		"radiusX", "radiusY", "rotationAngle", "force",
+	}
+	newClass("Touch", nil, func(this js.Value, args []js.Value) {
+		init := arg(args, 0)
+		if !init.Valid() {
+			throwTypeError("Failed to construct 'Touch': 1 argument required, but only 0 present.")
+		}
+		for _, name := range fields {
+			v := init.Get(name)
+			if v.IsUndefined() {
+				switch name {
+				case "target":
+					throwTypeError("Failed to construct 'Touch': required member target is undefined.")
+				case "identifier":
+					throwTypeError("Failed to construct 'Touch': required member identifier is undefined.")
+				}
+				v = js.ValueOf(0)
+			}
+			this.Set(name, v)
+		}
+	})
+}
+
+// abortSignal is an internal state of an AbortSignal object.
+type abortSignal struct {
+	aborted bool
+	reason  js.Value
+}
+
+var signals = make(map[js.Ref]*abortSignal)
+
+func signalOf(v js.Value) *abortSignal {
+	s := signals[v.Ref]
+	if s == nil {
+		throwTypeError("Illegal invocation")
+	}
+	return s
+}
+
+func abortSignalWith(s js.Value, reason js.Value) {
+	st := signalOf(s)
+	if st.aborted {
+		return
+	}
+	if reason.IsUndefined() {
+		reason = js.New("DOMException", "signal is aborted without reason", "AbortError")
+	}
+	st.aborted, st.reason = true, reason
+	dispatchEvent(s, newEvent(eventClass, "abort", nil))
+}
+
+func setupAbort() {
+	sig := newClass("AbortSignal", eventTargetClass, nil)
+	newSignal := func() js.Value {
+		s := sig.create()
+		signals[s.Ref] = &abortSignal{reason: js.Value{}}
+		return s
+	}
+	sig.getter("aborted", func(this js.Value) interface{} {
+		return signalOf(this).aborted
+	}, nil)
+	sig.getter("reason", func(this js.Value) interface{} {
+		return signalOf(this).reason
+	}, nil)
+	sig.method("throwIfAborted", func(this js.Value, args []js.Value) interface{} {
+		if s := signalOf(this); s.aborted {
+			panic(js.Error{Value: s.reason.Ref})
+		}
+		return nil
+	})
+	defineValue(sig.ctor, "abort", js.FuncOf(func(_ js.Value, args []js.Value) interface{} {
+		s := newSignal()
+		abortSignalWith(s, arg(args, 0))
+		return s
+	}))
+	defineValue(sig.ctor, "timeout", js.FuncOf(func(_ js.Value, args []js.Value) interface{} {
+		s := newSignal()
+		var cb js.Func
+		cb = js.FuncOf(func(_ js.Value, _ []js.Value) interface{} {
+			cb.Release()
+			abortSignalWith(s, js.New("DOMException", "signal timed out", "TimeoutError"))
+			return nil
+		})
+		js.Get("setTimeout").Invoke(cb, arg(args, 0))
+		return s
+	}))
+
+	ctrl := newClass("AbortController", nil, func(this js.Value, args []js.Value) {
+		defineValue(this, "signal", newSignal())
+	})
+	ctrl.method("abort", func(this js.Value, args []js.Value) interface{} {
+		abortSignalWith(this.Get("signal"), arg(args, 0))
+		return nil
+	})
+}
@@ -0,0 +1,130 @@
+//+build !wasm
+
+package headless
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/dennwc/dom/js"
+)
+
+func init() {
+	if js.Get("document").Valid() {
+		return
+	}
+	setupEvents()
+	setupMessages()
+	setupNodes()
+	setupCollections()
+	setupAttributes()
+	setupStyle()
+	setupElements()
+	setupDocument()
+	setupWindow()
+	parentTargetFunc = parentTarget
+
+	doc = &node{typ: documentNode}
+	defineValue(window, "document", doc.jsValue())
+	Reset()
+}
+
+// parentTarget returns the next event target in the event path.
+func parentTarget(t js.Value, e *event) (js.Value, bool) {
+	if t == window {
+		return js.Value{}, false
+	}
+	n := nodeOf(t)
+	switch {
+	case n == nil:
+		return js.Value{}, false
+	case n.parent != nil:
+		return n.parent.jsValue(), true
+	case n.host != nil:
+		if !e.composed {
+			return js.Value{}, false
+		}
+		return n.host.jsValue(), true
+	case n == doc:
+		return window, true
+	}
+	return js.Value{}, false
+}
+
+// resetAttrs updates style and dataset objects after the attributes of the element were replaced.
+func (n *node) resetAttrs() {
+	if n.style.Valid() {
+		v := ""
+		if i := n.attrIndex("style"); i >= 0 {
+			v = n.attrs[i].value
+		}
+		setCSSText(n.style, v)
+	}
+	if n.dataset.Valid() {
+		for _, k := range keysOf(n.dataset) {
+			deleteProp(n.dataset, k)
+		}
+		for _, a := range n.attrs {
+			if strings.HasPrefix(a.name, "data-") {
+				n.dataset.Set(dataKey(a.name), a.value)
+			}
+		}
+	}
+}
+
+// replaceWith puts the node in place of another node, and moves attributes and children of the other node to it.
+func (n *node) replaceWith(o *node) {
+	o.parent.insert(n, o)
+	o.detach()
+	n.attrs = o.attrs
+	n.resetAttrs()
+	n.replaceAll(append([]*node{}, o.children...))
+}
+
+// setDocument replaces the contents of the document with the contents of a given document node.
+// Existing html, head and body elements are preserved, so references to them remain valid.
+func setDocument(d *node) {
+	root, nroot := doc.documentElement(), d.documentElement()
+	if root.is("html") && nroot.is("html") {
+		if head, nhead := doc.head(), d.head(); head != nil && nhead != nil {
+			head.replaceWith(nhead)
+		}
+		if body, nbody := doc.body(), d.body(); body.is("body") && nbody.is("body") {
+			body.replaceWith(nbody)
+		}
+		root.replaceWith(nroot)
+	}
+	focused = nil
+	doc.replaceAll(append([]*node{}, d.children...))
+}
+
+// SetHTML replaces the contents of the document with a given HTML document.
+//
+// Existing html, head and body elements are preserved, so package-level variables like dom.Body remain valid.
+func SetHTML(s string) error {
+	d, err := parseDocument(s)
+	if err != nil {
+		return err
+	}
+	setDocument(d)
+	return nil
+}
+
+// HTML returns the serialized contents of the document.
+func HTML() string {
+	return outerHTML(doc)
+}
+
+// Reset resets the document to an empty HTML document. It also removes all event listeners, clears the storage
+// and cookies and resets the document location.
+func Reset() {
+	targets = make(map[js.Ref]*eventTarget)
+	cookies = nil
+	location, _ = url.Parse(defaultURL)
+	for _, s := range []js.Value{localStorage, sessionStorage} {
+		storages[s.Ref] = &storage{vals: make(map[string]string)}
+	}
+	if err := SetHTML(defaultDoc); err != nil {
+		panic(err)
+	}
+}
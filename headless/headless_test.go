@@ -0,0 +1,251 @@
+//+build !wasm
+
+package headless
+
+import (
+	"testing"
+
+	"github.com/dennwc/dom/js"
+	"github.com/stretchr/testify/require"
+)
+
+func document() js.Value {
+	return js.Get("document")
+}
+
+func TestCreateElement(t *testing.T) {
+	Reset()
+	d := document()
+	body := d.Get("body")
+	require.True(t, body.Valid())
+
+	div := d.Call("createElement", "DIV")
+	require.Equal(t, "DIV", div.Get("tagName").String())
+	require.True(t, div.InstanceOfClass("HTMLDivElement"))
+	require.True(t, div.InstanceOfClass("Element"))
+	div.Set("id", "main")
+	div.Set("innerHTML", `<p class="a b">x &amp; y</p><br>`)
+	body.Call("appendChild", div)
+
+	require.Equal(t, 2, div.Get("childNodes").Length())
+	p := div.Get("firstChild")
+	require.Equal(t, "P", p.Get("nodeName").String())
+	require.Equal(t, "x & y", p.Get("textContent").String())
+	require.True(t, p.Get("parentNode").Ref == div.Ref)
+	require.Equal(t, `<div id="main"><p class="a b">x &amp; y</p><br></div>`, div.Get("outerHTML").String())
+	require.Equal(t, `<!DOCTYPE html><html><head></head><body><div id="main"><p class="a b">x &amp; y</p><br></div></body></html>`, HTML())
+	require.True(t, d.Call("getElementById", "main").Ref == div.Ref)
+
+	div.Call("remove")
+	require.True(t, d.Call("getElementById", "main").IsNull())
+}
+
+func TestQuerySelector(t *testing.T) {
+	err := SetHTML(`<ul id="list"><li class="x">1</li><li>2</li><li class="x y">3</li></ul>`)
+	require.NoError(t, err)
+	d := document()
+
+	list := d.Call("querySelectorAll", "#list > li.x")
+	require.Equal(t, 2, list.Length())
+	require.Equal(t, "3", list.Index(1).Get("textContent").String())
+
+	li := d.Call("querySelector", "li:nth-child(2)")
+	require.Equal(t, "2", li.Get("textContent").String())
+	require.True(t, li.Call("matches", "ul li:not(.x)").Bool())
+	require.Equal(t, "list", li.Call("closest", "ul").Get("id").String())
+
+	items := d.Call("getElementsByClassName", "x")
+	require.Equal(t, 2, items.Length())
+	d.Call("querySelector", "li:first-child").Get("classList").Call("remove", "x")
+	require.Equal(t, 1, items.Length())
+
+	func() {
+		defer func() {
+			e, ok := recover().(js.Error)
+			require.True(t, ok)
+			require.Equal(t, "SyntaxError", js.Value{Ref: e.Value}.Get("name").String())
+		}()
+		d.Call("querySelector", "li[")
+	}()
+}
+
+func TestAttributes(t *testing.T) {
+	Reset()
+	el := document().Call("createElement", "div")
+	el.Call("setAttribute", "style", "color: red; margin-top: 1px")
+	st := el.Get("style")
+	require.Equal(t, "red", st.Get("color").String())
+	require.Equal(t, "1px", st.Get("marginTop").String())
+	st.Set("color", "blue")
+	require.Equal(t, "color: blue; margin-top: 1px;", el.Call("getAttribute", "style").String())
+
+	el.Get("dataset").Set("fooBar", "1")
+	require.Equal(t, "1", el.Call("getAttribute", "data-foo-bar").String())
+
+	cl := el.Get("classList")
+	cl.Call("add", "a", "b")
+	cl.Call("toggle", "a")
+	require.Equal(t, "b", el.Get("className").String())
+	require.True(t, cl.Call("contains", "b").Bool())
+	require.True(t, el.Call("hasAttribute", "class").Bool())
+	el.Call("removeAttribute", "class")
+	require.Equal(t, 0, cl.Length())
+}
+
+func TestEvents(t *testing.T) {
+	Reset()
+	d := document()
+	body := d.Get("body")
+	btn := d.Call("createElement", "button")
+	body.Call("appendChild", btn)
+
+	var got []string
+	listen := func(target js.Value, name string, opts interface{}, fnc func(e js.Value)) {
+		target.Call("addEventListener", "click", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			got = append(got, name)
+			if fnc != nil {
+				fnc(args[0])
+			}
+			return nil
+		}), opts)
+	}
+	listen(btn, "btn", js.Obj{"once": true}, func(e js.Value) {
+		e.Call("preventDefault")
+	})
+	listen(body, "body", nil, nil)
+	listen(d, "capture", true, nil)
+	listen(js.Get("window"), "window", nil, nil)
+
+	ok := btn.Call("dispatchEvent", js.New("MouseEvent", "click", js.Obj{"bubbles": true, "cancelable": true}))
+	require.False(t, ok.Bool())
+	require.Equal(t, []string{"capture", "btn", "body", "window"}, got)
+
+	got = nil
+	btn.Call("click")
+	require.Equal(t, []string{"capture", "body", "window"}, got)
+
+	got = nil
+	ok = btn.Call("dispatchEvent", js.New("Event", "click"))
+	require.True(t, ok.Bool())
+	require.Equal(t, []string{"capture"}, got)
+}
+
+func TestInput(t *testing.T) {
+	err := SetHTML(`<form><input type="checkbox" name="c"><input type="text" value="a"></form>`)
+	require.NoError(t, err)
+	d := document()
+	check := d.Call("querySelector", "input[type=checkbox]")
+	text := d.Call("querySelector", "input[type=text]")
+
+	changed := 0
+	check.Call("addEventListener", "change", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		changed++
+		return nil
+	}))
+	check.Call("click")
+	require.True(t, check.Get("checked").Bool())
+	require.Equal(t, 1, changed)
+	require.Equal(t, 1, d.Call("querySelectorAll", ":checked").Length())
+
+	require.Equal(t, "a", text.Get("value").String())
+	text.Set("value", "b")
+	require.Equal(t, "b", text.Get("value").String())
+	require.Equal(t, "a", text.Call("getAttribute", "value").String())
+
+	text.Call("focus")
+	require.True(t, d.Get("activeElement").Ref == text.Ref)
+	d.Call("querySelector", "form").Call("reset")
+	require.Equal(t, "a", text.Get("value").String())
+	require.False(t, check.Get("checked").Bool())
+}
+
+func TestSetHTML(t *testing.T) {
+	Reset()
+	d := document()
+	body, head := d.Get("body"), d.Get("head")
+
+	err := SetHTML(`<html lang="en"><head><title> A  title </title></head><body class="x"><p>text</p></body></html>`)
+	require.NoError(t, err)
+	require.True(t, d.Get("body").Ref == body.Ref)
+	require.True(t, d.Get("head").Ref == head.Ref)
+	require.Equal(t, "A title", d.Get("title").String())
+	require.Equal(t, "x", body.Get("className").String())
+	require.Equal(t, "en", d.Get("documentElement").Call("getAttribute", "lang").String())
+	require.Equal(t, "<p>text</p>", body.Get("innerHTML").String())
+
+	Reset()
+	require.True(t, d.Get("body").Ref == body.Ref)
+	require.Equal(t, "", body.Get("innerHTML").String())
+	require.Equal(t, "", body.Get("className").String())
+}
+
+func TestStorage(t *testing.T) {
+	Reset()
+	st := js.Get("window").Get("localStorage")
+	st.Call("setItem", "a", 1)
+	require.Equal(t, "1", st.Call("getItem", "a").String())
+	require.Equal(t, 1, st.Get("length").Int())
+	require.True(t, st.Call("getItem", "b").IsNull())
+
+	Reset()
+	require.Equal(t, 0, st.Get("length").Int())
+}
+
+func TestEventError(t *testing.T) {
+	Reset()
+	ev := js.New("Event", "error")
+	js.Get("window").Call("dispatchEvent", ev)
+	err := js.NewError(ev).(js.Error)
+	require.Equal(t, "Event", err.Name())
+	require.Equal(t, "JavaScript error: error event on Window", err.Error())
+
+	cause := js.New("TypeError", "bad")
+	err = js.NewError(js.New("ErrorEvent", "error", js.Obj{"message": "failed", "error": cause})).(js.Error)
+	require.Equal(t, "ErrorEvent", err.Name())
+	require.Equal(t, "failed", err.Message())
+	require.Equal(t, js.Error{Value: cause.Ref}, err.Cause())
+}
+
+func TestFuncScopeListener(t *testing.T) {
+	Reset()
+	body := document().Get("body")
+	s := js.NewFuncScope()
+	n := 0
+	s.AddEventListener(body, "click", func(v js.Value) {
+		n++
+	})
+	body.Call("click")
+	s.Release()
+	body.Call("click")
+	require.Equal(t, 1, n)
+}
+
+func TestMessageChannel(t *testing.T) {
+	ch := js.New("MessageChannel")
+	p1, p2 := ch.Get("port1"), ch.Get("port2")
+
+	got := make(chan js.Value, 2)
+	cb := js.NewEventCallback(func(e js.Value) {
+		got <- e
+	})
+	defer cb.Release()
+
+	buf := js.New("Uint8Array", 3).Get("buffer")
+	p1.Call("postMessage", js.Obj{"buf": buf}, []interface{}{buf})
+	require.Equal(t, 0, buf.Get("byteLength").Int())
+
+	// messages are queued until the port is started
+	p2.Set("onmessage", cb)
+	e := <-got
+	require.True(t, e.InstanceOfClass("MessageEvent"))
+	require.Equal(t, 3, e.Get("data", "buf").Get("byteLength").Int())
+
+	p1.Call("close")
+	p2.Call("postMessage", "dropped")
+	p1.Call("postMessage", "dropped")
+	select {
+	case e = <-got:
+		t.Fatal("unexpected message:", e.Get("data"))
+	default:
+	}
+}
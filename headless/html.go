@@ -0,0 +1,182 @@
+//+build !wasm
+
+package headless
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// shortNS maps namespace URIs to namespace names used by the HTML parser.
+var shortNS = map[string]string{
+	nsHTML:   "",
+	nsSVG:    "svg",
+	nsMathML: "math",
+}
+
+// attrNS maps attribute namespaces used by the HTML parser to namespace URIs.
+var attrNS = map[string]string{
+	"xlink": nsXLink,
+	"xml":   nsXML,
+	"xmlns": nsXMLNS,
+}
+
+// fromHTML converts a node returned by the HTML parser.
+func fromHTML(h *html.Node) *node {
+	var n *node
+	switch h.Type {
+	case html.TextNode:
+		return newText(h.Data)
+	case html.CommentNode:
+		return newComment(h.Data)
+	case html.DoctypeNode:
+		n = &node{typ: doctypeNode, name: h.Data}
+		for _, a := range h.Attr {
+			switch a.Key {
+			case "public":
+				n.publicID = a.Val
+			case "system":
+				n.systemID = a.Val
+			}
+		}
+		return n
+	case html.DocumentNode:
+		n = &node{typ: documentNode}
+	case html.ElementNode:
+		ns := nsHTML
+		switch h.Namespace {
+		case "svg":
+			ns = nsSVG
+		case "math":
+			ns = nsMathML
+		}
+		n = newElement(h.Data, ns, "")
+		for _, a := range h.Attr {
+			at := attr{name: a.Key, value: a.Val}
+			if a.Namespace != "" {
+				at.ns = attrNS[a.Namespace]
+				at.name = a.Namespace + ":" + a.Key
+			}
+			n.attrs = append(n.attrs, at)
+		}
+	default:
+		return nil
+	}
+	p := n
+	if n.content != nil {
+		p = n.content
+	}
+	for c := h.FirstChild; c != nil; c = c.NextSibling {
+		if cn := fromHTML(c); cn != nil {
+			cn.parent = p
+			p.children = append(p.children, cn)
+		}
+	}
+	return n
+}
+
+// parseFragment parses an HTML fragment in the context of a given element.
+func parseFragment(s string, ctx *node) []*node {
+	name, ns := "body", ""
+	if ctx.isElement() {
+		name, ns = ctx.name, shortNS[ctx.ns]
+	}
+	hctx := &html.Node{
+		Type: html.ElementNode, Data: name, Namespace: ns,
+		DataAtom: atom.Lookup([]byte(name)),
+	}
+	list, err := html.ParseFragment(strings.NewReader(s), hctx)
+	if err != nil {
+		throwDOMException("SyntaxError", err.Error())
+	}
+	out := make([]*node, 0, len(list))
+	for _, h := range list {
+		if n := fromHTML(h); n != nil {
+			out = append(out, n)
+		}
+	}
+	changed()
+	return out
+}
+
+// parseDocument parses a full HTML document.
+func parseDocument(s string) (*node, error) {
+	h, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		return nil, err
+	}
+	return fromHTML(h), nil
+}
+
+// voidElements lists HTML elements that have no end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "basefont": true, "bgsound": true, "br": true, "col": true, "embed": true,
+	"frame": true, "hr": true, "img": true, "input": true, "keygen": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
+// rawTextElements lists HTML elements with text content that is serialized without escaping.
+var rawTextElements = map[string]bool{
+	"style": true, "script": true, "xmp": true, "iframe": true, "noembed": true, "noframes": true,
+	"plaintext": true, "noscript": true,
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "\u00a0", "&nbsp;", "<", "&lt;", ">", "&gt;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "\u00a0", "&nbsp;", `"`, "&quot;")
+)
+
+// innerHTML serializes children of a node the same way as Element.innerHTML does.
+func innerHTML(n *node) string {
+	var buf strings.Builder
+	writeChildren(&buf, n)
+	return buf.String()
+}
+
+// outerHTML serializes the node the same way as Element.outerHTML does.
+func outerHTML(n *node) string {
+	var buf strings.Builder
+	writeNode(&buf, n, nil)
+	return buf.String()
+}
+
+func writeChildren(buf *strings.Builder, n *node) {
+	p := n
+	if n.content != nil {
+		p = n.content
+	}
+	for _, c := range p.children {
+		writeNode(buf, c, n)
+	}
+}
+
+func writeNode(buf *strings.Builder, n, parent *node) {
+	switch n.typ {
+	case elementNode:
+		name := n.qualifiedName()
+		buf.WriteString("<" + name)
+		for _, a := range n.syncedAttrs() {
+			buf.WriteString(" " + a.name + `="` + attrEscaper.Replace(a.value) + `"`)
+		}
+		buf.WriteString(">")
+		if n.isHTML() && voidElements[n.name] {
+			return
+		}
+		writeChildren(buf, n)
+		buf.WriteString("</" + name + ">")
+	case textNode:
+		if parent.isHTML() && rawTextElements[parent.name] {
+			buf.WriteString(n.data)
+		} else {
+			buf.WriteString(textEscaper.Replace(n.data))
+		}
+	case commentNode:
+		buf.WriteString("<!--" + n.data + "-->")
+	case doctypeNode:
+		buf.WriteString("<!DOCTYPE " + n.name + ">")
+	case documentNode, fragmentNode:
+		writeChildren(buf, n)
+	}
+}
@@ -0,0 +1,320 @@
+//+build !wasm
+
+package headless
+
+import (
+	"sync"
+
+	"github.com/dennwc/dom/js"
+)
+
+// messageTarget is a common state of objects that receive messages, like MessagePort and BroadcastChannel.
+type messageTarget struct {
+	v         js.Value
+	started   bool
+	closed    bool
+	scheduled bool
+	queue     []portMessage
+	handler   js.Value // onmessage
+	listener  js.Value // calls the handler
+}
+
+// messagePort is an internal state of a MessagePort object.
+type messagePort struct {
+	messageTarget
+	peer *messagePort
+}
+
+// broadcastChannel is an internal state of a BroadcastChannel object.
+type broadcastChannel struct {
+	messageTarget
+	name string
+}
+
+// portMessage is a message queued for delivery to a port.
+type portMessage struct {
+	data  js.Value
+	ports []interface{}
+}
+
+var (
+	// portsMu protects the state of all ports and channels, since messages can be posted from any goroutine
+	// and are delivered by the event loop.
+	portsMu    sync.Mutex
+	ports      = make(map[js.Ref]*messagePort)
+	broadcasts = make(map[js.Ref]*broadcastChannel)
+
+	messagePortClass      *class
+	messageEventClass     *class
+	broadcastChannelClass *class
+)
+
+func portOf(v js.Value) *messagePort {
+	portsMu.Lock()
+	p := ports[v.Ref]
+	portsMu.Unlock()
+	if p == nil {
+		throwTypeError("Illegal invocation")
+	}
+	return p
+}
+
+// newMessagePorts creates an entangled pair of ports.
+func newMessagePorts() (js.Value, js.Value) {
+	a := &messagePort{messageTarget: messageTarget{v: messagePortClass.create()}}
+	b := &messagePort{messageTarget: messageTarget{v: messagePortClass.create()}, peer: a}
+	a.peer = b
+	portsMu.Lock()
+	ports[a.v.Ref] = a
+	ports[b.v.Ref] = b
+	portsMu.Unlock()
+	return a.v, b.v
+}
+
+// transferList returns a transfer list from the second argument of postMessage.
+// It accepts both an array and an options object.
+func transferList(v js.Value) js.Value {
+	switch {
+	case v.IsUndefined() || v.IsNull():
+		return js.Value{}
+	case js.Get("Array").Call("isArray", v).Bool():
+		return v
+	}
+	return v.Get("transfer")
+}
+
+// post clones the message and queues it for delivery to the peer port.
+func (p *messagePort) post(msg, transfer js.Value) {
+	opts := js.Obj{}
+	var moved []interface{}
+	if transfer.Valid() {
+		opts["transfer"] = transfer
+		for _, t := range transfer.Slice() {
+			if t.Ref == p.v.Ref {
+				throwDOMException("DataCloneError", "Failed to execute 'postMessage' on 'MessagePort': Port at index 0 contains the source port.")
+			}
+			if t.InstanceOf(messagePortClass.ctor) {
+				moved = append(moved, t)
+			}
+		}
+	}
+	data := js.Get("structuredClone").Invoke(msg, opts)
+
+	portsMu.Lock()
+	defer portsMu.Unlock()
+	peer := p.peer
+	if p.closed || peer == nil || peer.closed {
+		return
+	}
+	peer.push(portMessage{data: data, ports: moved})
+}
+
+// push queues a message for delivery. It must be called with portsMu held.
+func (p *messageTarget) push(m portMessage) {
+	p.queue = append(p.queue, m)
+	p.schedule()
+}
+
+// schedule queues a task that delivers pending messages. It must be called with portsMu held.
+func (p *messageTarget) schedule() {
+	if !p.started || p.scheduled || len(p.queue) == 0 {
+		return
+	}
+	p.scheduled = true
+	var cb js.Func
+	cb = js.FuncOf(func(_ js.Value, _ []js.Value) interface{} {
+		cb.Release()
+		p.deliver()
+		return nil
+	})
+	js.Get("setTimeout").Invoke(cb, 0)
+}
+
+// deliver dispatches all pending messages to the port.
+func (p *messageTarget) deliver() {
+	portsMu.Lock()
+	list := p.queue
+	p.queue, p.scheduled = nil, false
+	portsMu.Unlock()
+	for _, m := range list {
+		portsMu.Lock()
+		closed := p.closed
+		portsMu.Unlock()
+		if closed {
+			return
+		}
+		dispatchEvent(p.v, newEvent(messageEventClass, "message", js.Obj{
+			"data":  m.data,
+			"ports": newArray(m.ports),
+		}))
+	}
+}
+
+func (p *messageTarget) start() {
+	portsMu.Lock()
+	p.started = true
+	p.schedule()
+	portsMu.Unlock()
+}
+
+// close disentangles the port. Messages posted to or from it are discarded.
+func (p *messagePort) close() {
+	portsMu.Lock()
+	p.closed = true
+	p.queue = nil
+	if p.peer != nil {
+		p.peer.peer = nil
+		p.peer = nil
+	}
+	portsMu.Unlock()
+}
+
+// setupMessages defines MessageChannel and MessagePort classes. Both ports of a channel live in the same realm,
+// thus messages are cloned with structuredClone, and transferred objects are passed by reference.
+func setupMessages() {
+	messagePortClass = newClass("MessagePort", eventTargetClass, nil)
+	c := messagePortClass
+	c.method("postMessage", func(this js.Value, args []js.Value) interface{} {
+		if len(args) == 0 {
+			throwTypeError("Failed to execute 'postMessage' on 'MessagePort': 1 argument required, but only 0 present.")
+		}
+		portOf(this).post(args[0], transferList(arg(args, 1)))
+		return nil
+	})
+	c.method("start", func(this js.Value, args []js.Value) interface{} {
+		portOf(this).start()
+		return nil
+	})
+	c.method("close", func(this js.Value, args []js.Value) interface{} {
+		portOf(this).close()
+		return nil
+	})
+	defineOnMessage(c, func(this js.Value) *messageTarget {
+		return &portOf(this).messageTarget
+	})
+
+	newClass("MessageChannel", nil, func(this js.Value, args []js.Value) {
+		a, b := newMessagePorts()
+		defineValue(this, "port1", a)
+		defineValue(this, "port2", b)
+	})
+	setupBroadcast()
+}
+
+// defineOnMessage defines onmessage property on a class of message targets.
+func defineOnMessage(c *class, targetOf func(this js.Value) *messageTarget) {
+	c.getter("onmessage", func(this js.Value) interface{} {
+		p := targetOf(this)
+		portsMu.Lock()
+		h := p.handler
+		portsMu.Unlock()
+		if !h.Valid() {
+			return nil
+		}
+		return h
+	}, func(this js.Value, v js.Value) {
+		p := targetOf(this)
+		if !p.listener.Valid() {
+			f := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+				portsMu.Lock()
+				h := p.handler
+				portsMu.Unlock()
+				if h.Type() == js.TypeFunction {
+					h.Call("call", this, arg(args, 0))
+				}
+				return nil
+			})
+			p.listener = js.Value{Ref: f.Value}
+			addEventListener(this, "message", p.listener, js.Value{})
+		}
+		if v.Type() != js.TypeFunction {
+			v = js.Value{}
+		}
+		portsMu.Lock()
+		p.handler = v
+		portsMu.Unlock()
+		if v.Valid() {
+			// setting onmessage implicitly starts the port
+			p.start()
+		}
+	})
+}
+
+func broadcastOf(v js.Value) *broadcastChannel {
+	portsMu.Lock()
+	b := broadcasts[v.Ref]
+	portsMu.Unlock()
+	if b == nil {
+		throwTypeError("Illegal invocation")
+	}
+	return b
+}
+
+// post clones the message and queues it for delivery to all other channels with the same name.
+func (b *broadcastChannel) post(msg js.Value) {
+	portsMu.Lock()
+	closed := b.closed
+	portsMu.Unlock()
+	if closed {
+		throwDOMException("InvalidStateError", "Failed to execute 'postMessage' on 'BroadcastChannel': Channel is closed")
+	}
+	data := js.Get("structuredClone").Invoke(msg)
+
+	portsMu.Lock()
+	defer portsMu.Unlock()
+	for _, o := range broadcastList {
+		if o != b && o.name == b.name && !o.closed {
+			o.push(portMessage{data: data})
+		}
+	}
+}
+
+// broadcastList is a list of open channels in order of creation, which is the order of message delivery.
+var broadcastList []*broadcastChannel
+
+// setupBroadcast defines BroadcastChannel class. Messages are delivered to channels in the same realm.
+func setupBroadcast() {
+	broadcastChannelClass = newClass("BroadcastChannel", eventTargetClass, func(this js.Value, args []js.Value) {
+		if len(args) == 0 {
+			throwTypeError("Failed to construct 'BroadcastChannel': 1 argument required, but only 0 present.")
+		}
+		b := &broadcastChannel{
+			messageTarget: messageTarget{v: this, started: true},
+			name:          toString(args[0]),
+		}
+		portsMu.Lock()
+		broadcasts[this.Ref] = b
+		broadcastList = append(broadcastList, b)
+		portsMu.Unlock()
+	})
+	c := broadcastChannelClass
+	c.getter("name", func(this js.Value) interface{} {
+		return broadcastOf(this).name
+	}, nil)
+	c.method("postMessage", func(this js.Value, args []js.Value) interface{} {
+		if len(args) == 0 {
+			throwTypeError("Failed to execute 'postMessage' on 'BroadcastChannel': 1 argument required, but only 0 present.")
+		}
+		broadcastOf(this).post(args[0])
+		return nil
+	})
+	c.method("close", func(this js.Value, args []js.Value) interface{} {
+		b := broadcastOf(this)
+		portsMu.Lock()
+		defer portsMu.Unlock()
+		if b.closed {
+			return nil
+		}
+		b.closed, b.queue = true, nil
+		for i, o := range broadcastList {
+			if o == b {
+				broadcastList = append(broadcastList[:i:i], broadcastList[i+1:]...)
+				break
+			}
+		}
+		return nil
+	})
+	defineOnMessage(c, func(this js.Value) *messageTarget {
+		return &broadcastOf(this).messageTarget
+	})
+}
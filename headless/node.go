@@ -0,0 +1,1240 @@
+//+build !wasm
+
+package headless
+
+import (
+	"strings"
+	"unicode/utf16"
+
+	"github.com/dennwc/dom/js"
+)
+
+// Node types.
+const (
+	elementNode  = 1
+	textNode     = 3
+	commentNode  = 8
+	documentNode = 9
+	doctypeNode  = 10
+	fragmentNode = 11
+)
+
+// Namespaces.
+const (
+	nsHTML   = "http://www.w3.org/1999/xhtml"
+	nsSVG    = "http://www.w3.org/2000/svg"
+	nsMathML = "http://www.w3.org/1998/Math/MathML"
+	nsXLink  = "http://www.w3.org/1999/xlink"
+	nsXML    = "http://www.w3.org/XML/1998/namespace"
+	nsXMLNS  = "http://www.w3.org/2000/xmlns/"
+)
+
+// attr is an attribute of an element.
+type attr struct {
+	ns    string
+	name  string // qualified name
+	value string
+}
+
+// node is a DOM node. Its JS object is created lazily.
+type node struct {
+	typ    int
+	name   string // local name of an element or a doctype name
+	prefix string
+	ns     string
+	attrs  []attr
+	data   string // text of text and comment nodes
+
+	publicID, systemID string // doctype only
+
+	parent   *node
+	children []*node
+
+	shadow  *node  // shadow root attached to an element
+	host    *node  // host of a shadow root
+	mode    string // mode of a shadow root
+	content *node  // contents of a template element
+
+	// state of form controls that is not reflected in attributes
+	value    *string
+	checked  *bool
+	selected *bool
+
+	scrollTop, scrollLeft float64
+
+	v                   js.Value
+	style, dataset      js.Value
+	classList           js.Value
+	childNodes, childEl js.Value
+}
+
+var (
+	// nodes maps JS objects to DOM nodes.
+	nodes = make(map[js.Ref]*node)
+
+	// version is incremented on each change of the tree or attributes. It is used to refresh live collections.
+	version int
+
+	// doc is the document node.
+	doc *node
+
+	// focused is an element that has focus, if any.
+	focused *node
+)
+
+// nodeOf returns a DOM node for a JS object, or nil if the value is not a node.
+func nodeOf(v js.Value) *node {
+	return nodes[v.Ref]
+}
+
+// thisNode returns a node for a method receiver, or throws a TypeError.
+func thisNode(this js.Value) *node {
+	n := nodes[this.Ref]
+	if n == nil {
+		throwTypeError("Illegal invocation")
+	}
+	return n
+}
+
+// argNode returns a node passed as i-th argument of a method, or throws a TypeError.
+func argNode(args []js.Value, i int, method, iface string) *node {
+	n := nodes[arg(args, i).Ref]
+	if n == nil {
+		throwTypeError("Failed to execute '" + method + "' on '" + iface + "': parameter " +
+			string(rune('1'+i)) + " is not of type 'Node'.")
+	}
+	return n
+}
+
+// jsValue returns a JS object for the node, creating it if necessary.
+func (n *node) jsValue() js.Value {
+	if !n.v.Valid() {
+		n.v = classOfNode(n).create()
+		nodes[n.v.Ref] = n
+	}
+	return n.v
+}
+
+// jsOf returns a JS object for the node, or nil if the node is nil.
+func jsOf(n *node) interface{} {
+	if n == nil {
+		return nil
+	}
+	return n.jsValue()
+}
+
+// jsList converts a list of nodes to a JS array.
+func jsList(list []*node) js.Value {
+	arr := make([]interface{}, 0, len(list))
+	for _, n := range list {
+		arr = append(arr, n.jsValue())
+	}
+	return newArray(arr)
+}
+
+// changed must be called after each modification of the tree.
+func changed() {
+	version++
+}
+
+func newText(data string) *node {
+	return &node{typ: textNode, data: data}
+}
+
+func newComment(data string) *node {
+	return &node{typ: commentNode, data: data}
+}
+
+func newFragment() *node {
+	return &node{typ: fragmentNode}
+}
+
+// newElement creates an element with a given local name and namespace.
+func newElement(name, ns, prefix string) *node {
+	n := &node{typ: elementNode, name: name, ns: ns, prefix: prefix}
+	if ns == nsHTML && name == "template" {
+		n.content = newFragment()
+	}
+	return n
+}
+
+func (n *node) isElement() bool {
+	return n != nil && n.typ == elementNode
+}
+
+// isHTML checks if the node is an HTML element.
+func (n *node) isHTML() bool {
+	return n.isElement() && n.ns == nsHTML
+}
+
+// is checks if the node is an HTML element with a given local name.
+func (n *node) is(name string) bool {
+	return n.isHTML() && n.name == name
+}
+
+// qualifiedName returns a qualified name of an element.
+func (n *node) qualifiedName() string {
+	if n.prefix != "" {
+		return n.prefix + ":" + n.name
+	}
+	return n.name
+}
+
+// tagName returns a tag name of an element. It is in upper case for HTML elements.
+func (n *node) tagName() string {
+	if n.ns == nsHTML {
+		return strings.ToUpper(n.qualifiedName())
+	}
+	return n.qualifiedName()
+}
+
+// nodeName implements Node.nodeName.
+func (n *node) nodeName() string {
+	switch n.typ {
+	case elementNode:
+		return n.tagName()
+	case textNode:
+		return "#text"
+	case commentNode:
+		return "#comment"
+	case documentNode:
+		return "#document"
+	case doctypeNode:
+		return n.name
+	case fragmentNode:
+		return "#document-fragment"
+	}
+	return ""
+}
+
+// index returns the position of the node in its parent.
+func (n *node) index() int {
+	if n.parent == nil {
+		return 0
+	}
+	for i, c := range n.parent.children {
+		if c == n {
+			return i
+		}
+	}
+	return -1
+}
+
+func (n *node) prev() *node {
+	if n.parent == nil {
+		return nil
+	}
+	if i := n.index(); i > 0 {
+		return n.parent.children[i-1]
+	}
+	return nil
+}
+
+func (n *node) next() *node {
+	if n.parent == nil {
+		return nil
+	}
+	if i := n.index(); i+1 < len(n.parent.children) {
+		return n.parent.children[i+1]
+	}
+	return nil
+}
+
+func (n *node) firstChild() *node {
+	if len(n.children) == 0 {
+		return nil
+	}
+	return n.children[0]
+}
+
+func (n *node) lastChild() *node {
+	if len(n.children) == 0 {
+		return nil
+	}
+	return n.children[len(n.children)-1]
+}
+
+// elements returns child elements of the node.
+func (n *node) elements() []*node {
+	var out []*node
+	for _, c := range n.children {
+		if c.typ == elementNode {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (n *node) prevElement() *node {
+	for p := n.prev(); p != nil; p = p.prev() {
+		if p.typ == elementNode {
+			return p
+		}
+	}
+	return nil
+}
+
+func (n *node) nextElement() *node {
+	for p := n.next(); p != nil; p = p.next() {
+		if p.typ == elementNode {
+			return p
+		}
+	}
+	return nil
+}
+
+// parentElement returns the parent of the node if it's an element.
+func (n *node) parentElement() *node {
+	if n.parent.isElement() {
+		return n.parent
+	}
+	return nil
+}
+
+// walk calls fnc for each descendant of the node in tree order. If fnc returns false, the walk stops.
+func (n *node) walk(fnc func(c *node) bool) bool {
+	for _, c := range n.children {
+		if !fnc(c) || !c.walk(fnc) {
+			return false
+		}
+	}
+	return true
+}
+
+// descendants returns all descendant elements that match a given function.
+func (n *node) descendants(match func(c *node) bool) []*node {
+	var out []*node
+	n.walk(func(c *node) bool {
+		if c.typ == elementNode && match(c) {
+			out = append(out, c)
+		}
+		return true
+	})
+	return out
+}
+
+// find returns the first descendant element that matches a given function.
+func (n *node) find(match func(c *node) bool) *node {
+	var out *node
+	n.walk(func(c *node) bool {
+		if c.typ == elementNode && match(c) {
+			out = c
+			return false
+		}
+		return true
+	})
+	return out
+}
+
+// contains checks if the node is an inclusive ancestor of c.
+func (n *node) contains(c *node) bool {
+	for ; c != nil; c = c.parent {
+		if c == n {
+			return true
+		}
+	}
+	return false
+}
+
+// hostContains checks if the node is a shadow-including inclusive ancestor of c.
+func (n *node) hostContains(c *node) bool {
+	for c != nil {
+		if c == n {
+			return true
+		}
+		if c.parent == nil {
+			c = c.host
+		} else {
+			c = c.parent
+		}
+	}
+	return false
+}
+
+// root returns the root of the node's tree.
+func (n *node) root() *node {
+	for n.parent != nil {
+		n = n.parent
+	}
+	return n
+}
+
+// hostRoot returns the shadow-including root of the node.
+func (n *node) hostRoot() *node {
+	r := n.root()
+	for r.host != nil {
+		r = r.host.root()
+	}
+	return r
+}
+
+// connected checks if the node is in the document.
+func (n *node) connected() bool {
+	return n.hostRoot() == doc
+}
+
+// textContent implements a getter of Node.textContent for elements and fragments.
+func (n *node) textContent() string {
+	switch n.typ {
+	case textNode, commentNode:
+		return n.data
+	}
+	var buf strings.Builder
+	n.walk(func(c *node) bool {
+		if c.typ == textNode {
+			buf.WriteString(c.data)
+		}
+		return true
+	})
+	return buf.String()
+}
+
+// childText returns concatenated data of child text nodes.
+func (n *node) childText() string {
+	var buf strings.Builder
+	for _, c := range n.children {
+		if c.typ == textNode {
+			buf.WriteString(c.data)
+		}
+	}
+	return buf.String()
+}
+
+// setTextContent replaces all children with a single text node.
+func (n *node) setTextContent(s string) {
+	switch n.typ {
+	case textNode, commentNode:
+		n.data = s
+		changed()
+		return
+	case elementNode, fragmentNode:
+	default:
+		return
+	}
+	var list []*node
+	if s != "" {
+		list = append(list, newText(s))
+	}
+	n.replaceAll(list)
+}
+
+// insertBefore validates and inserts a node (or children of a fragment) before ref, or appends it if ref is nil.
+func (n *node) insertBefore(c, ref *node) {
+	n.validateInsert(c, ref)
+	if ref == c {
+		ref = c.next()
+	}
+	n.insert(c, ref)
+}
+
+// validateInsert checks that c can be inserted to the node before ref.
+func (n *node) validateInsert(c, ref *node) {
+	switch n.typ {
+	case documentNode, fragmentNode, elementNode:
+	default:
+		throwDOMException("HierarchyRequestError", "This node type does not support this method.")
+	}
+	if c.hostContains(n) {
+		throwDOMException("HierarchyRequestError", "The new child element contains the parent.")
+	}
+	if ref != nil && ref.parent != n {
+		throwDOMException("NotFoundError", "The node before which the new node is to be inserted is not a child of this node.")
+	}
+	switch c.typ {
+	case documentNode:
+		throwDOMException("HierarchyRequestError", "Nodes of type '#document' may not be inserted inside nodes of type '"+n.nodeName()+"'.")
+	case doctypeNode:
+		if n.typ != documentNode {
+			throwDOMException("HierarchyRequestError", "Nodes of type '"+c.nodeName()+"' may not be inserted inside nodes of type '"+n.nodeName()+"'.")
+		}
+	case textNode:
+		if n.typ == documentNode {
+			throwDOMException("HierarchyRequestError", "Nodes of type '#text' may not be inserted inside nodes of type '#document'.")
+		}
+	}
+	if n.typ == documentNode {
+		elems := 0
+		if c.typ == elementNode {
+			elems = 1
+		} else if c.typ == fragmentNode {
+			elems = len(c.elements())
+		}
+		if elems > 1 || (elems == 1 && n.documentElement() != nil && n.documentElement() != ref) {
+			throwDOMException("HierarchyRequestError", "Only one element on document allowed.")
+		}
+	}
+}
+
+// insert inserts a node (or children of a fragment) before ref without validation.
+func (n *node) insert(c, ref *node) {
+	var list []*node
+	if c.typ == fragmentNode {
+		list = append(list, c.children...)
+		c.replaceAll(nil)
+	} else {
+		c.detach()
+		list = []*node{c}
+	}
+	i := len(n.children)
+	if ref != nil {
+		i = ref.index()
+	}
+	for _, c := range list {
+		c.parent = n
+	}
+	children := make([]*node, 0, len(n.children)+len(list))
+	children = append(children, n.children[:i]...)
+	children = append(children, list...)
+	children = append(children, n.children[i:]...)
+	n.children = children
+	changed()
+}
+
+// appendChild appends a node without validation.
+func (n *node) appendChild(c *node) {
+	n.insert(c, nil)
+}
+
+// detach removes the node from its parent.
+func (n *node) detach() {
+	p := n.parent
+	if p == nil {
+		return
+	}
+	if i := n.index(); i >= 0 {
+		p.children = append(p.children[:i:i], p.children[i+1:]...)
+	}
+	n.parent = nil
+	if focused != nil && n.hostContains(focused) {
+		focused = nil
+	}
+	changed()
+}
+
+// replaceAll replaces all children of the node with a given list.
+func (n *node) replaceAll(list []*node) {
+	for _, c := range append([]*node{}, n.children...) {
+		c.detach()
+	}
+	for _, c := range list {
+		n.insert(c, nil)
+	}
+	changed()
+}
+
+// replaceChild replaces the old child with a new node.
+func (n *node) replaceChild(c, old *node) {
+	if old.parent != n {
+		throwDOMException("NotFoundError", "The node to be replaced is not a child of this node.")
+	}
+	ref := old.next()
+	if ref == c {
+		ref = c.next()
+	}
+	if old != c {
+		n.validateInsert(c, old)
+		old.detach()
+	}
+	n.insert(c, ref)
+}
+
+// removeChild removes a child of the node.
+func (n *node) removeChild(c *node) {
+	if c.parent != n {
+		throwDOMException("NotFoundError", "The node to be removed is not a child of this node.")
+	}
+	c.detach()
+}
+
+// documentElement returns the root element of the document.
+func (n *node) documentElement() *node {
+	for _, c := range n.children {
+		if c.typ == elementNode {
+			return c
+		}
+	}
+	return nil
+}
+
+// clone implements Node.cloneNode.
+func (n *node) clone(deep bool) *node {
+	c := &node{
+		typ: n.typ, name: n.name, prefix: n.prefix, ns: n.ns, data: n.data,
+		publicID: n.publicID, systemID: n.systemID,
+	}
+	if n.typ == elementNode {
+		c.attrs = append([]attr{}, n.syncedAttrs()...)
+	}
+	if n.value != nil {
+		v := *n.value
+		c.value = &v
+	}
+	if n.checked != nil {
+		v := *n.checked
+		c.checked = &v
+	}
+	if n.selected != nil {
+		v := *n.selected
+		c.selected = &v
+	}
+	if n.content != nil {
+		c.content = newFragment()
+		if deep {
+			for _, ch := range n.content.children {
+				c.content.appendChild(ch.clone(true))
+			}
+		}
+	}
+	if deep {
+		for _, ch := range n.children {
+			c.appendChild(ch.clone(true))
+		}
+	}
+	return c
+}
+
+// normalize implements Node.normalize.
+func (n *node) normalize() {
+	for i := 0; i < len(n.children); i++ {
+		c := n.children[i]
+		if c.typ != textNode {
+			c.normalize()
+			continue
+		}
+		for i+1 < len(n.children) && n.children[i+1].typ == textNode {
+			next := n.children[i+1]
+			c.data += next.data
+			next.detach()
+		}
+		if c.data == "" {
+			c.detach()
+			i--
+		}
+	}
+	changed()
+}
+
+// equal implements Node.isEqualNode.
+func (n *node) equal(o *node) bool {
+	if n.typ != o.typ || n.name != o.name || n.ns != o.ns || n.prefix != o.prefix ||
+		n.data != o.data || n.publicID != o.publicID || n.systemID != o.systemID {
+		return false
+	}
+	a1, a2 := n.syncedAttrs(), o.syncedAttrs()
+	if len(a1) != len(a2) || len(n.children) != len(o.children) {
+		return false
+	}
+	for _, a := range a1 {
+		found := false
+		for _, b := range a2 {
+			if a == b {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for i, c := range n.children {
+		if !c.equal(o.children[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Document position flags.
+const (
+	posDisconnected = 0x01
+	posPreceding    = 0x02
+	posFollowing    = 0x04
+	posContains     = 0x08
+	posContainedBy  = 0x10
+	posImplSpecific = 0x20
+)
+
+// ancestors returns the path from the root to the node.
+func (n *node) ancestors() []*node {
+	var path []*node
+	for ; n != nil; n = n.parent {
+		path = append([]*node{n}, path...)
+	}
+	return path
+}
+
+// comparePosition implements Node.compareDocumentPosition.
+func (n *node) comparePosition(o *node) int {
+	if n == o {
+		return 0
+	}
+	p1, p2 := n.ancestors(), o.ancestors()
+	if p1[0] != p2[0] {
+		return posDisconnected | posImplSpecific | posFollowing
+	}
+	i := 0
+	for i < len(p1) && i < len(p2) && p1[i] == p2[i] {
+		i++
+	}
+	switch {
+	case i == len(p2):
+		return posContains | posPreceding
+	case i == len(p1):
+		return posContainedBy | posFollowing
+	case p1[i].index() > p2[i].index():
+		return posPreceding
+	}
+	return posFollowing
+}
+
+// utf16Range validates an offset and a count of a CharacterData method and returns character data as UTF-16.
+func (n *node) utf16Range(off, count int) ([]uint16, int) {
+	u := utf16.Encode([]rune(n.data))
+	if off < 0 || off > len(u) {
+		throwDOMException("IndexSizeError", "The offset is greater than the node's length.")
+	}
+	if count < 0 || off+count > len(u) {
+		count = len(u) - off
+	}
+	return u, count
+}
+
+// replaceData implements CharacterData.replaceData.
+func (n *node) replaceData(off, count int, s string) {
+	u, count := n.utf16Range(off, count)
+	out := append([]uint16{}, u[:off]...)
+	out = append(out, utf16.Encode([]rune(s))...)
+	out = append(out, u[off+count:]...)
+	n.data = string(utf16.Decode(out))
+	changed()
+}
+
+// Classes of DOM nodes.
+var (
+	nodeClass        *class
+	charDataClass    *class
+	textClass        *class
+	commentClass     *class
+	doctypeClass     *class
+	fragmentClass    *class
+	shadowRootClass  *class
+	documentClass    *class
+	nodeListClass    *class
+	collectionClass  *class
+	elementClass     *class
+	htmlElementClass *class
+	svgElementClass  *class
+	htmlClasses      = make(map[string]*class)
+)
+
+// classOfNode returns a JS class for a node.
+func classOfNode(n *node) *class {
+	switch n.typ {
+	case elementNode:
+		switch n.ns {
+		case nsHTML:
+			if c := htmlClasses[n.name]; c != nil {
+				return c
+			}
+			return htmlElementClass
+		case nsSVG:
+			return svgElementClass
+		}
+		return elementClass
+	case textNode:
+		return textClass
+	case commentNode:
+		return commentClass
+	case documentNode:
+		return documentClass
+	case doctypeNode:
+		return doctypeClass
+	case fragmentNode:
+		if n.host != nil {
+			return shadowRootClass
+		}
+		return fragmentClass
+	}
+	return nodeClass
+}
+
+// nodesFromArgs converts arguments of ParentNode and ChildNode methods to a single node.
+// Strings are converted to text nodes.
+func nodesFromArgs(args []js.Value) *node {
+	if len(args) == 1 {
+		if n := nodeOf(args[0]); n != nil {
+			return n
+		}
+	}
+	f := newFragment()
+	for _, a := range args {
+		n := nodeOf(a)
+		if n == nil {
+			n = newText(toString(a))
+		}
+		f.validateInsert(n, nil)
+		f.insert(n, nil)
+	}
+	return f
+}
+
+func setupNodes() {
+	nodeClass = newClass("Node", eventTargetClass, nil)
+	c := nodeClass
+	for name, v := range map[string]int{
+		"ELEMENT_NODE": elementNode, "ATTRIBUTE_NODE": 2, "TEXT_NODE": textNode,
+		"CDATA_SECTION_NODE": 4, "PROCESSING_INSTRUCTION_NODE": 7, "COMMENT_NODE": commentNode,
+		"DOCUMENT_NODE": documentNode, "DOCUMENT_TYPE_NODE": doctypeNode, "DOCUMENT_FRAGMENT_NODE": fragmentNode,
+
+		"DOCUMENT_POSITION_DISCONNECTED": posDisconnected, "DOCUMENT_POSITION_PRECEDING": posPreceding,
+		"DOCUMENT_POSITION_FOLLOWING": posFollowing, "DOCUMENT_POSITION_CONTAINS": posContains,
+		"DOCUMENT_POSITION_CONTAINED_BY": posContainedBy, "DOCUMENT_POSITION_IMPLEMENTATION_SPECIFIC": posImplSpecific,
+	} {
+		c.constant(name, v)
+	}
+	c.getter("nodeType", func(this js.Value) interface{} {
+		return thisNode(this).typ
+	}, nil)
+	c.getter("nodeName", func(this js.Value) interface{} {
+		return thisNode(this).nodeName()
+	}, nil)
+	c.getter("baseURI", func(this js.Value) interface{} {
+		return location.String()
+	}, nil)
+	c.getter("isConnected", func(this js.Value) interface{} {
+		return thisNode(this).connected()
+	}, nil)
+	c.getter("ownerDocument", func(this js.Value) interface{} {
+		if n := thisNode(this); n.typ == documentNode {
+			return nil
+		}
+		return doc.jsValue()
+	}, nil)
+	c.getter("parentNode", func(this js.Value) interface{} {
+		return jsOf(thisNode(this).parent)
+	}, nil)
+	c.getter("parentElement", func(this js.Value) interface{} {
+		return jsOf(thisNode(this).parentElement())
+	}, nil)
+	c.getter("childNodes", func(this js.Value) interface{} {
+		n := thisNode(this)
+		if !n.childNodes.Valid() {
+			n.childNodes = newCollection(nodeListClass, func() []*node { return n.children })
+		}
+		return n.childNodes
+	}, nil)
+	c.getter("firstChild", func(this js.Value) interface{} {
+		return jsOf(thisNode(this).firstChild())
+	}, nil)
+	c.getter("lastChild", func(this js.Value) interface{} {
+		return jsOf(thisNode(this).lastChild())
+	}, nil)
+	c.getter("previousSibling", func(this js.Value) interface{} {
+		return jsOf(thisNode(this).prev())
+	}, nil)
+	c.getter("nextSibling", func(this js.Value) interface{} {
+		return jsOf(thisNode(this).next())
+	}, nil)
+	c.getter("nodeValue", func(this js.Value) interface{} {
+		switch n := thisNode(this); n.typ {
+		case textNode, commentNode:
+			return n.data
+		}
+		return nil
+	}, func(this js.Value, v js.Value) {
+		switch n := thisNode(this); n.typ {
+		case textNode, commentNode:
+			n.setTextContent(nullableString(v))
+		}
+	})
+	c.getter("textContent", func(this js.Value) interface{} {
+		switch n := thisNode(this); n.typ {
+		case documentNode, doctypeNode:
+			return nil
+		default:
+			return n.textContent()
+		}
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setTextContent(nullableString(v))
+	})
+	c.method("hasChildNodes", func(this js.Value, args []js.Value) interface{} {
+		return len(thisNode(this).children) != 0
+	})
+	c.method("appendChild", func(this js.Value, args []js.Value) interface{} {
+		ch := argNode(args, 0, "appendChild", "Node")
+		thisNode(this).insertBefore(ch, nil)
+		return ch.jsValue()
+	})
+	c.method("insertBefore", func(this js.Value, args []js.Value) interface{} {
+		ch := argNode(args, 0, "insertBefore", "Node")
+		var ref *node
+		if r := arg(args, 1); r.Valid() {
+			ref = argNode(args, 1, "insertBefore", "Node")
+		}
+		thisNode(this).insertBefore(ch, ref)
+		return ch.jsValue()
+	})
+	c.method("removeChild", func(this js.Value, args []js.Value) interface{} {
+		ch := argNode(args, 0, "removeChild", "Node")
+		thisNode(this).removeChild(ch)
+		return ch.jsValue()
+	})
+	c.method("replaceChild", func(this js.Value, args []js.Value) interface{} {
+		ch := argNode(args, 0, "replaceChild", "Node")
+		old := argNode(args, 1, "replaceChild", "Node")
+		thisNode(this).replaceChild(ch, old)
+		return old.jsValue()
+	})
+	c.method("cloneNode", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		if n.typ == documentNode || n.host != nil {
+			throwDOMException("NotSupportedError", "The node cannot be cloned.")
+		}
+		return n.clone(arg(args, 0).Truthy()).jsValue()
+	})
+	c.method("contains", func(this js.Value, args []js.Value) interface{} {
+		o := nodeOf(arg(args, 0))
+		return o != nil && thisNode(this).contains(o)
+	})
+	c.method("isSameNode", func(this js.Value, args []js.Value) interface{} {
+		return thisNode(this) == nodeOf(arg(args, 0))
+	})
+	c.method("isEqualNode", func(this js.Value, args []js.Value) interface{} {
+		o := nodeOf(arg(args, 0))
+		return o != nil && thisNode(this).equal(o)
+	})
+	c.method("compareDocumentPosition", func(this js.Value, args []js.Value) interface{} {
+		return thisNode(this).comparePosition(argNode(args, 0, "compareDocumentPosition", "Node"))
+	})
+	c.method("getRootNode", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		if opts := arg(args, 0); opts.Valid() && opts.Get("composed").Truthy() {
+			return n.hostRoot().jsValue()
+		}
+		return n.root().jsValue()
+	})
+	c.method("normalize", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this).normalize()
+		return nil
+	})
+
+	setupCharacterData()
+
+	doctypeClass = newClass("DocumentType", nodeClass, nil)
+	doctypeClass.getter("name", func(this js.Value) interface{} {
+		return thisNode(this).name
+	}, nil)
+	doctypeClass.getter("publicId", func(this js.Value) interface{} {
+		return thisNode(this).publicID
+	}, nil)
+	doctypeClass.getter("systemId", func(this js.Value) interface{} {
+		return thisNode(this).systemID
+	}, nil)
+	childNodeMixin(doctypeClass)
+
+	fragmentClass = newClass("DocumentFragment", nodeClass, func(this js.Value, args []js.Value) {
+		n := newFragment()
+		n.v = this
+		nodes[this.Ref] = n
+	})
+	parentNodeMixin(fragmentClass)
+	fragmentClass.method("getElementById", func(this js.Value, args []js.Value) interface{} {
+		return jsOf(thisNode(this).elementByID(toString(arg(args, 0))))
+	})
+
+	shadowRootClass = newClass("ShadowRoot", fragmentClass, nil)
+	shadowRootClass.getter("host", func(this js.Value) interface{} {
+		return jsOf(thisNode(this).host)
+	}, nil)
+	shadowRootClass.getter("mode", func(this js.Value) interface{} {
+		return thisNode(this).mode
+	}, nil)
+	shadowRootClass.getter("innerHTML", func(this js.Value) interface{} {
+		return innerHTML(thisNode(this))
+	}, func(this js.Value, v js.Value) {
+		n := thisNode(this)
+		n.replaceAll(parseFragment(nullableString(v), n.host))
+	})
+	shadowRootClass.getter("activeElement", func(this js.Value) interface{} {
+		n := thisNode(this)
+		for f := focused; f != nil; f = f.root().host {
+			if f.root() == n {
+				return f.jsValue()
+			}
+		}
+		return nil
+	}, nil)
+}
+
+// elementByID returns the first descendant element with a given id.
+func (n *node) elementByID(id string) *node {
+	if id == "" {
+		return nil
+	}
+	return n.find(func(c *node) bool {
+		v, ok := c.getAttr("id")
+		return ok && v == id
+	})
+}
+
+// nullableString converts a value to a string, treating null as an empty string.
+func nullableString(v js.Value) string {
+	if v.IsNull() {
+		return ""
+	}
+	return toString(v)
+}
+
+func setupCharacterData() {
+	charDataClass = newClass("CharacterData", nodeClass, nil)
+	c := charDataClass
+	c.getter("data", func(this js.Value) interface{} {
+		return thisNode(this).data
+	}, func(this js.Value, v js.Value) {
+		thisNode(this).setTextContent(nullableString(v))
+	})
+	c.getter("length", func(this js.Value) interface{} {
+		return len(utf16.Encode([]rune(thisNode(this).data)))
+	}, nil)
+	c.method("appendData", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		n.setTextContent(n.data + toString(arg(args, 0)))
+		return nil
+	})
+	c.method("insertData", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this).replaceData(arg(args, 0).Int(), 0, toString(arg(args, 1)))
+		return nil
+	})
+	c.method("deleteData", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this).replaceData(arg(args, 0).Int(), arg(args, 1).Int(), "")
+		return nil
+	})
+	c.method("replaceData", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this).replaceData(arg(args, 0).Int(), arg(args, 1).Int(), toString(arg(args, 2)))
+		return nil
+	})
+	c.method("substringData", func(this js.Value, args []js.Value) interface{} {
+		off := arg(args, 0).Int()
+		u, count := thisNode(this).utf16Range(off, arg(args, 1).Int())
+		return string(utf16.Decode(u[off : off+count]))
+	})
+	c.getter("previousElementSibling", func(this js.Value) interface{} {
+		return jsOf(thisNode(this).prevElement())
+	}, nil)
+	c.getter("nextElementSibling", func(this js.Value) interface{} {
+		return jsOf(thisNode(this).nextElement())
+	}, nil)
+	childNodeMixin(c)
+
+	textClass = newClass("Text", charDataClass, func(this js.Value, args []js.Value) {
+		n := newText(optString(arg(args, 0), ""))
+		n.v = this
+		nodes[this.Ref] = n
+	})
+	textClass.getter("wholeText", func(this js.Value) interface{} {
+		n := thisNode(this)
+		for p := n.prev(); p != nil && p.typ == textNode; p = p.prev() {
+			n = p
+		}
+		var buf strings.Builder
+		for ; n != nil && n.typ == textNode; n = n.next() {
+			buf.WriteString(n.data)
+		}
+		return buf.String()
+	}, nil)
+	textClass.method("splitText", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		off := arg(args, 0).Int()
+		u, _ := n.utf16Range(off, 0)
+		t := newText(string(utf16.Decode(u[off:])))
+		n.data = string(utf16.Decode(u[:off]))
+		if n.parent != nil {
+			n.parent.insert(t, n.next())
+		}
+		changed()
+		return t.jsValue()
+	})
+
+	commentClass = newClass("Comment", charDataClass, func(this js.Value, args []js.Value) {
+		n := newComment(optString(arg(args, 0), ""))
+		n.v = this
+		nodes[this.Ref] = n
+	})
+}
+
+// parentNodeMixin defines methods of the ParentNode mixin on a class.
+func parentNodeMixin(c *class) {
+	c.getter("children", func(this js.Value) interface{} {
+		n := thisNode(this)
+		if !n.childEl.Valid() {
+			n.childEl = newCollection(collectionClass, n.elements)
+		}
+		return n.childEl
+	}, nil)
+	c.getter("firstElementChild", func(this js.Value) interface{} {
+		for _, ch := range thisNode(this).children {
+			if ch.typ == elementNode {
+				return ch.jsValue()
+			}
+		}
+		return nil
+	}, nil)
+	c.getter("lastElementChild", func(this js.Value) interface{} {
+		list := thisNode(this).children
+		for i := len(list) - 1; i >= 0; i-- {
+			if list[i].typ == elementNode {
+				return list[i].jsValue()
+			}
+		}
+		return nil
+	}, nil)
+	c.getter("childElementCount", func(this js.Value) interface{} {
+		return len(thisNode(this).elements())
+	}, nil)
+	c.method("append", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this).insertBefore(nodesFromArgs(args), nil)
+		return nil
+	})
+	c.method("prepend", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		n.insertBefore(nodesFromArgs(args), n.firstChild())
+		return nil
+	})
+	c.method("replaceChildren", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		f := nodesFromArgs(args)
+		n.validateInsert(f, nil)
+		n.replaceAll(nil)
+		n.insert(f, nil)
+		return nil
+	})
+	c.method("querySelector", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		sel := compileSelector(toString(arg(args, 0)))
+		return jsOf(n.find(func(c *node) bool {
+			return sel.match(c, n)
+		}))
+	})
+	c.method("querySelectorAll", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		sel := compileSelector(toString(arg(args, 0)))
+		list := n.descendants(func(c *node) bool {
+			return sel.match(c, n)
+		})
+		return newCollection(nodeListClass, func() []*node { return list })
+	})
+	c.method("getElementsByTagName", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		name := toString(arg(args, 0))
+		lower := strings.ToLower(name)
+		return newCollection(collectionClass, func() []*node {
+			return n.descendants(func(c *node) bool {
+				if name == "*" {
+					return true
+				}
+				if c.ns == nsHTML {
+					return c.qualifiedName() == lower
+				}
+				return c.qualifiedName() == name
+			})
+		})
+	})
+	c.method("getElementsByTagNameNS", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		ns, name := nullableString(arg(args, 0)), toString(arg(args, 1))
+		return newCollection(collectionClass, func() []*node {
+			return n.descendants(func(c *node) bool {
+				return (ns == "*" || c.ns == ns) && (name == "*" || c.name == name)
+			})
+		})
+	})
+	c.method("getElementsByClassName", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		classes := strings.Fields(toString(arg(args, 0)))
+		return newCollection(collectionClass, func() []*node {
+			if len(classes) == 0 {
+				return nil
+			}
+			return n.descendants(func(c *node) bool {
+				for _, cl := range classes {
+					if !c.hasClass(cl) {
+						return false
+					}
+				}
+				return true
+			})
+		})
+	})
+}
+
+// argNodes returns a set of nodes passed as arguments.
+func argNodes(args []js.Value) map[*node]bool {
+	set := make(map[*node]bool)
+	for _, a := range args {
+		if n := nodeOf(a); n != nil {
+			set[n] = true
+		}
+	}
+	return set
+}
+
+// childNodeMixin defines methods of the ChildNode mixin on a class.
+func childNodeMixin(c *class) {
+	c.method("remove", func(this js.Value, args []js.Value) interface{} {
+		thisNode(this).detach()
+		return nil
+	})
+	c.method("before", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		p := n.parent
+		if p == nil {
+			return nil
+		}
+		set := argNodes(args)
+		prev := n.prev()
+		for prev != nil && set[prev] {
+			prev = prev.prev()
+		}
+		f := nodesFromArgs(args)
+		ref := p.firstChild()
+		if prev != nil {
+			ref = prev.next()
+		}
+		p.insertBefore(f, ref)
+		return nil
+	})
+	c.method("after", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		p := n.parent
+		if p == nil {
+			return nil
+		}
+		ref := n.viableNext(argNodes(args))
+		p.insertBefore(nodesFromArgs(args), ref)
+		return nil
+	})
+	c.method("replaceWith", func(this js.Value, args []js.Value) interface{} {
+		n := thisNode(this)
+		p := n.parent
+		if p == nil {
+			return nil
+		}
+		ref := n.viableNext(argNodes(args))
+		f := nodesFromArgs(args)
+		if n.parent == p {
+			p.replaceChild(f, n)
+		} else {
+			p.insertBefore(f, ref)
+		}
+		return nil
+	})
+}
+
+// viableNext returns the first following sibling of the node that is not in the set.
+func (n *node) viableNext(set map[*node]bool) *node {
+	next := n.next()
+	for next != nil && set[next] {
+		next = next.next()
+	}
+	return next
+}
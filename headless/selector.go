@@ -0,0 +1,606 @@
+//+build !wasm
+
+package headless
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// selector is a compiled list of CSS selectors.
+type selector []complexSelector
+
+// complexSelector is a list of compound selectors joined with combinators.
+type complexSelector struct {
+	parts []compound
+	combs []byte // combinators between parts: ' ', '>', '+' or '~'
+}
+
+// compound is a compound selector: a type selector and a list of conditions.
+type compound struct {
+	tag   string // local name or "*"
+	conds []func(n, scope *node) bool
+}
+
+// selectors caches compiled selectors.
+var selectors = make(map[string]selector)
+
+// compileSelector compiles a list of CSS selectors. It throws a SyntaxError for invalid selectors.
+func compileSelector(s string) selector {
+	if sel, ok := selectors[s]; ok {
+		return sel
+	}
+	p := &selParser{s: s}
+	sel, err := p.parseList(false)
+	if err != nil {
+		throwDOMException("SyntaxError", "'"+s+"' is not a valid selector.")
+	}
+	if len(selectors) > 1024 {
+		selectors = make(map[string]selector)
+	}
+	selectors[s] = sel
+	return sel
+}
+
+// match checks if the element matches any selector in the list.
+func (sel selector) match(n, scope *node) bool {
+	if !n.isElement() {
+		return false
+	}
+	for _, c := range sel {
+		if c.matchAt(len(c.parts)-1, n, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c complexSelector) matchAt(i int, n, scope *node) bool {
+	if !c.parts[i].match(n, scope) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	switch c.combs[i-1] {
+	case '>':
+		p := n.parentElement()
+		return p != nil && c.matchAt(i-1, p, scope)
+	case '+':
+		p := n.prevElement()
+		return p != nil && c.matchAt(i-1, p, scope)
+	case '~':
+		for p := n.prevElement(); p != nil; p = p.prevElement() {
+			if c.matchAt(i-1, p, scope) {
+				return true
+			}
+		}
+	default:
+		for p := n.parentElement(); p != nil; p = p.parentElement() {
+			if c.matchAt(i-1, p, scope) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c compound) match(n, scope *node) bool {
+	if c.tag != "*" {
+		if n.ns == nsHTML {
+			if n.name != strings.ToLower(c.tag) {
+				return false
+			}
+		} else if n.name != c.tag {
+			return false
+		}
+	}
+	for _, f := range c.conds {
+		if !f(n, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+var errSelector = errors.New("invalid selector")
+
+// selParser is a parser of CSS selectors.
+type selParser struct {
+	s   string
+	pos int
+}
+
+func (p *selParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *selParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+// skipSpace skips whitespace and reports if any was skipped.
+func (p *selParser) skipSpace() bool {
+	start := p.pos
+	for !p.eof() && strings.IndexByte(" \t\n\r\f", p.peek()) >= 0 {
+		p.pos++
+	}
+	return p.pos != start
+}
+
+func isNameByte(c byte, first bool) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c >= 0x80, c == '\\':
+		return true
+	case c >= '0' && c <= '9':
+		return !first
+	case c == '-':
+		return true
+	}
+	return false
+}
+
+// ident parses a CSS identifier.
+func (p *selParser) ident() (string, error) {
+	if p.eof() || !isNameByte(p.peek(), true) {
+		return "", errSelector
+	}
+	var buf strings.Builder
+	for !p.eof() && isNameByte(p.peek(), false) {
+		c := p.peek()
+		if c != '\\' {
+			buf.WriteByte(c)
+			p.pos++
+			continue
+		}
+		p.pos++
+		if p.eof() {
+			return "", errSelector
+		}
+		buf.WriteString(p.escape())
+	}
+	s := buf.String()
+	if s == "-" || strings.HasPrefix(s, "-") && len(s) > 1 && s[1] >= '0' && s[1] <= '9' {
+		return "", errSelector
+	}
+	return s, nil
+}
+
+// escape parses an escape sequence after a backslash.
+func (p *selParser) escape() string {
+	start := p.pos
+	for p.pos < len(p.s) && p.pos-start < 6 && strings.IndexByte("0123456789abcdefABCDEF", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	if p.pos == start {
+		r, size := utf8.DecodeRuneInString(p.s[p.pos:])
+		p.pos += size
+		return string(r)
+	}
+	code, _ := strconv.ParseUint(p.s[start:p.pos], 16, 32)
+	if !p.eof() && strings.IndexByte(" \t\n\r\f", p.peek()) >= 0 {
+		p.pos++
+	}
+	if code == 0 || code > utf8.MaxRune {
+		return "\ufffd"
+	}
+	return string(rune(code))
+}
+
+// str parses a quoted string.
+func (p *selParser) str() (string, error) {
+	q := p.peek()
+	p.pos++
+	var buf strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == q:
+			p.pos++
+			return buf.String(), nil
+		case c == '\\':
+			p.pos++
+			if !p.eof() {
+				buf.WriteString(p.escape())
+			}
+		default:
+			buf.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", errSelector
+}
+
+// parseList parses a list of complex selectors. If nested is set, the list ends with a closing parenthesis.
+func (p *selParser) parseList(nested bool) (selector, error) {
+	var sel selector
+	for {
+		p.skipSpace()
+		c, err := p.parseComplex()
+		if err != nil {
+			return nil, err
+		}
+		sel = append(sel, c)
+		p.skipSpace()
+		switch {
+		case p.eof():
+			if nested {
+				return nil, errSelector
+			}
+			return sel, nil
+		case p.peek() == ',':
+			p.pos++
+		case p.peek() == ')' && nested:
+			p.pos++
+			return sel, nil
+		default:
+			return nil, errSelector
+		}
+	}
+}
+
+func (p *selParser) parseComplex() (complexSelector, error) {
+	var c complexSelector
+	cp, err := p.parseCompound()
+	if err != nil {
+		return c, err
+	}
+	c.parts = append(c.parts, cp)
+	for {
+		space := p.skipSpace()
+		if p.eof() || p.peek() == ',' || p.peek() == ')' {
+			return c, nil
+		}
+		comb := byte(' ')
+		switch p.peek() {
+		case '>', '+', '~':
+			comb = p.peek()
+			p.pos++
+			p.skipSpace()
+		default:
+			if !space {
+				return c, errSelector
+			}
+		}
+		cp, err := p.parseCompound()
+		if err != nil {
+			return c, err
+		}
+		c.combs = append(c.combs, comb)
+		c.parts = append(c.parts, cp)
+	}
+}
+
+func (p *selParser) parseCompound() (compound, error) {
+	c := compound{tag: "*"}
+	start := p.pos
+	if p.peek() == '*' {
+		p.pos++
+	} else if !p.eof() && isNameByte(p.peek(), true) {
+		name, err := p.ident()
+		if err != nil {
+			return c, err
+		}
+		c.tag = name
+	}
+	for !p.eof() {
+		var (
+			f   func(n, scope *node) bool
+			err error
+		)
+		switch p.peek() {
+		case '#':
+			p.pos++
+			var id string
+			id, err = p.ident()
+			f = func(n, _ *node) bool {
+				v, ok := n.getAttr("id")
+				return ok && v == id
+			}
+		case '.':
+			p.pos++
+			var cl string
+			cl, err = p.ident()
+			f = func(n, _ *node) bool {
+				return n.hasClass(cl)
+			}
+		case '[':
+			p.pos++
+			f, err = p.parseAttr()
+		case ':':
+			p.pos++
+			f, err = p.parsePseudo()
+		default:
+			if p.pos == start {
+				return c, errSelector
+			}
+			return c, nil
+		}
+		if err != nil {
+			return c, err
+		}
+		c.conds = append(c.conds, f)
+	}
+	if p.pos == start {
+		return c, errSelector
+	}
+	return c, nil
+}
+
+// parseAttr parses an attribute selector after an opening bracket.
+func (p *selParser) parseAttr() (func(n, scope *node) bool, error) {
+	p.skipSpace()
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.peek() == ']' {
+		p.pos++
+		return func(n, _ *node) bool {
+			return n.hasAttr(name)
+		}, nil
+	}
+	var op byte
+	switch c := p.peek(); c {
+	case '=':
+		op = c
+		p.pos++
+	case '~', '|', '^', '$', '*':
+		op = c
+		p.pos++
+		if p.peek() != '=' {
+			return nil, errSelector
+		}
+		p.pos++
+	default:
+		return nil, errSelector
+	}
+	p.skipSpace()
+	var val string
+	if c := p.peek(); c == '"' || c == '\'' {
+		val, err = p.str()
+	} else {
+		val, err = p.ident()
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	fold := false
+	switch p.peek() {
+	case 'i', 'I':
+		fold = true
+		p.pos++
+		p.skipSpace()
+	case 's', 'S':
+		p.pos++
+		p.skipSpace()
+	}
+	if p.peek() != ']' {
+		return nil, errSelector
+	}
+	p.pos++
+	if fold {
+		val = strings.ToLower(val)
+	}
+	return func(n, _ *node) bool {
+		v, ok := n.getAttr(name)
+		if !ok {
+			return false
+		}
+		if fold {
+			v = strings.ToLower(v)
+		}
+		switch op {
+		case '=':
+			return v == val
+		case '~':
+			return val != "" && indexOf(strings.Fields(v), val) >= 0
+		case '|':
+			return v == val || strings.HasPrefix(v, val+"-")
+		case '^':
+			return val != "" && strings.HasPrefix(v, val)
+		case '$':
+			return val != "" && strings.HasSuffix(v, val)
+		case '*':
+			return val != "" && strings.Contains(v, val)
+		}
+		return false
+	}, nil
+}
+
+// parseNth parses an argument of :nth-* pseudo-classes in the form of "an+b".
+func (p *selParser) parseNth() (a, b int, err error) {
+	end := strings.IndexByte(p.s[p.pos:], ')')
+	if end < 0 {
+		return 0, 0, errSelector
+	}
+	s := strings.ToLower(strings.Replace(p.s[p.pos:p.pos+end], " ", "", -1))
+	p.pos += end + 1
+	switch s {
+	case "odd":
+		return 2, 1, nil
+	case "even":
+		return 2, 0, nil
+	}
+	i := strings.IndexByte(s, 'n')
+	if i < 0 {
+		b, err = strconv.Atoi(s)
+		return 0, b, err
+	}
+	switch as := s[:i]; as {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		if a, err = strconv.Atoi(as); err != nil {
+			return 0, 0, errSelector
+		}
+	}
+	if bs := s[i+1:]; bs != "" {
+		if bs[0] != '+' && bs[0] != '-' {
+			return 0, 0, errSelector
+		}
+		if b, err = strconv.Atoi(bs); err != nil {
+			return 0, 0, errSelector
+		}
+	}
+	return a, b, nil
+}
+
+// nthMatch checks if a 1-based index matches the "an+b" expression.
+func nthMatch(a, b, i int) bool {
+	if a == 0 {
+		return i == b
+	}
+	return (i-b)%a == 0 && (i-b)/a >= 0
+}
+
+// siblingIndex returns a 1-based index of the element among its siblings. If sameType is set, only elements of
+// the same type are counted. If last is set, the index is counted from the end.
+func siblingIndex(n *node, sameType, last bool) int {
+	i := 1
+	step := (*node).prevElement
+	if last {
+		step = (*node).nextElement
+	}
+	for s := step(n); s != nil; s = step(s) {
+		if !sameType || (s.name == n.name && s.ns == n.ns) {
+			i++
+		}
+	}
+	return i
+}
+
+// parsePseudo parses a pseudo-class after a colon.
+func (p *selParser) parsePseudo() (func(n, scope *node) bool, error) {
+	if p.peek() == ':' {
+		// pseudo-elements never match elements
+		p.pos++
+		if _, err := p.ident(); err != nil {
+			return nil, err
+		}
+		return func(n, _ *node) bool { return false }, nil
+	}
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	name = strings.ToLower(name)
+	if p.peek() == '(' {
+		p.pos++
+		p.skipSpace()
+		switch name {
+		case "not", "is", "where", "matches":
+			sel, err := p.parseList(true)
+			if err != nil {
+				return nil, err
+			}
+			if name == "not" {
+				return func(n, scope *node) bool { return !sel.match(n, scope) }, nil
+			}
+			return func(n, scope *node) bool { return sel.match(n, scope) }, nil
+		case "nth-child", "nth-last-child", "nth-of-type", "nth-last-of-type":
+			a, b, err := p.parseNth()
+			if err != nil {
+				return nil, err
+			}
+			sameType := strings.HasSuffix(name, "of-type")
+			last := strings.HasPrefix(name, "nth-last")
+			return func(n, _ *node) bool {
+				return nthMatch(a, b, siblingIndex(n, sameType, last))
+			}, nil
+		}
+		return nil, errSelector
+	}
+	var f func(n, scope *node) bool
+	switch name {
+	case "first-child":
+		f = func(n, _ *node) bool { return n.prevElement() == nil }
+	case "last-child":
+		f = func(n, _ *node) bool { return n.nextElement() == nil }
+	case "only-child":
+		f = func(n, _ *node) bool { return n.prevElement() == nil && n.nextElement() == nil }
+	case "first-of-type":
+		f = func(n, _ *node) bool { return siblingIndex(n, true, false) == 1 }
+	case "last-of-type":
+		f = func(n, _ *node) bool { return siblingIndex(n, true, true) == 1 }
+	case "only-of-type":
+		f = func(n, _ *node) bool { return siblingIndex(n, true, false) == 1 && siblingIndex(n, true, true) == 1 }
+	case "root":
+		f = func(n, _ *node) bool { return n.parent != nil && n.parent.typ == documentNode }
+	case "scope":
+		f = func(n, scope *node) bool {
+			if scope != nil && scope.typ != elementNode {
+				return n.parent == scope && n.parent.typ == documentNode
+			}
+			return n == scope
+		}
+	case "empty":
+		f = func(n, _ *node) bool {
+			for _, c := range n.children {
+				if c.typ == elementNode || (c.typ == textNode && c.data != "") {
+					return false
+				}
+			}
+			return true
+		}
+	case "checked":
+		f = func(n, _ *node) bool {
+			switch {
+			case n.is("input"):
+				t := n.inputType()
+				return (t == "checkbox" || t == "radio") && n.isChecked()
+			case n.is("option"):
+				return n.jsValue().Get("selected").Bool()
+			}
+			return false
+		}
+	case "disabled":
+		f = func(n, _ *node) bool { return n.disabled() }
+	case "enabled":
+		f = func(n, _ *node) bool {
+			return n.isHTML() && (formControls[n.name] || n.name == "option" || n.name == "optgroup") && !n.disabled()
+		}
+	case "required", "optional":
+		req := name == "required"
+		f = func(n, _ *node) bool {
+			if !n.is("input") && !n.is("select") && !n.is("textarea") {
+				return false
+			}
+			return n.hasAttr("required") == req
+		}
+	case "read-only", "read-write":
+		rw := name == "read-write"
+		f = func(n, _ *node) bool {
+			w := false
+			switch {
+			case n.is("input"), n.is("textarea"):
+				w = !n.hasAttr("readonly") && !n.disabled()
+			default:
+				w = n.contentEditable()
+			}
+			return w == rw
+		}
+	case "focus", "focus-visible":
+		f = func(n, _ *node) bool { return n == focused }
+	case "focus-within":
+		f = func(n, _ *node) bool { return focused != nil && n.hostContains(focused) }
+	case "link", "any-link":
+		f = func(n, _ *node) bool { return (n.is("a") || n.is("area")) && n.hasAttr("href") }
+	case "defined":
+		f = func(n, _ *node) bool { return true }
+	case "hover", "active", "visited", "target":
+		f = func(n, _ *node) bool { return false }
+	default:
+		return nil, errSelector
+	}
+	return f, nil
+}
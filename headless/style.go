@@ -0,0 +1,376 @@
+//+build !wasm
+
+package headless
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dennwc/dom/js"
+)
+
+var (
+	styleClass     *class
+	tokenListClass *class
+	stringMapClass *class
+)
+
+// tokenLists maps DOMTokenList objects to elements.
+var tokenLists = make(map[js.Ref]*node)
+
+// cssName converts a property name of CSSStyleDeclaration to a CSS property name.
+func cssName(key string) string {
+	if strings.HasPrefix(key, "--") {
+		return key
+	}
+	if key == "cssFloat" {
+		return "float"
+	}
+	var buf strings.Builder
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; c >= 'A' && c <= 'Z' {
+			buf.WriteByte('-')
+			buf.WriteByte(c - 'A' + 'a')
+			continue
+		}
+		buf.WriteByte(key[i])
+	}
+	s := buf.String()
+	if strings.HasPrefix(s, "webkit-") || strings.HasPrefix(s, "moz-") || strings.HasPrefix(s, "ms-") {
+		s = "-" + s
+	}
+	return s
+}
+
+// cssKey converts a CSS property name to a property name of CSSStyleDeclaration.
+func cssKey(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "--") {
+		return name
+	}
+	name = strings.ToLower(name)
+	if name == "float" {
+		return "cssFloat"
+	}
+	name = strings.TrimPrefix(name, "-")
+	return dataKey(name)
+}
+
+// cssText serializes a style object.
+func cssText(style js.Value) string {
+	var parts []string
+	for _, k := range keysOf(style) {
+		v := toString(style.Get(k))
+		if v == "" {
+			continue
+		}
+		parts = append(parts, cssName(k)+": "+v+";")
+	}
+	return strings.Join(parts, " ")
+}
+
+// splitCSS splits a list of CSS declarations, ignoring separators inside strings and parentheses.
+func splitCSS(s string) []string {
+	var (
+		out   []string
+		depth int
+		quote byte
+		last  int
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == ';' && depth == 0:
+			out = append(out, s[last:i])
+			last = i + 1
+		}
+	}
+	return append(out, s[last:])
+}
+
+// setCSSText replaces all properties of a style object with properties parsed from CSS text.
+func setCSSText(style js.Value, s string) {
+	for _, k := range keysOf(style) {
+		deleteProp(style, k)
+	}
+	for _, decl := range splitCSS(s) {
+		i := strings.IndexByte(decl, ':')
+		if i < 0 {
+			continue
+		}
+		name, val := strings.TrimSpace(decl[:i]), strings.TrimSpace(decl[i+1:])
+		val = strings.TrimSpace(strings.TrimSuffix(val, "!important"))
+		if name == "" || val == "" {
+			continue
+		}
+		style.Set(cssKey(name), val)
+	}
+}
+
+// styleOf returns a style object of the element, creating it if necessary.
+func styleOf(n *node) js.Value {
+	if !n.style.Valid() {
+		v, _ := n.getAttr("style")
+		n.style = styleClass.create()
+		setCSSText(n.style, v)
+	}
+	return n.style
+}
+
+// datasetOf returns a dataset object of the element, creating it if necessary.
+func datasetOf(n *node) js.Value {
+	if !n.dataset.Valid() {
+		n.dataset = stringMapClass.create()
+		for _, a := range n.attrs {
+			if strings.HasPrefix(a.name, "data-") {
+				n.dataset.Set(dataKey(a.name), a.value)
+			}
+		}
+	}
+	return n.dataset
+}
+
+// classListOf returns a classList object of the element, creating it if necessary.
+func classListOf(n *node) js.Value {
+	if !n.classList.Valid() {
+		n.classList = tokenListClass.create()
+		tokenLists[n.classList.Ref] = n
+	}
+	refreshTokens(n.classList, n)
+	return n.classList
+}
+
+// uniqueTokens returns a list of tokens without duplicates.
+func uniqueTokens(list []string) []string {
+	out := make([]string, 0, len(list))
+	seen := make(map[string]bool, len(list))
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// refreshTokens updates index properties of the token list and returns current tokens.
+func refreshTokens(v js.Value, n *node) []string {
+	list := uniqueTokens(n.classes())
+	for i, s := range list {
+		v.SetIndex(i, s)
+	}
+	for _, k := range keysOf(v) {
+		if i, err := strconv.Atoi(k); err == nil && i >= len(list) {
+			deleteProp(v, k)
+		}
+	}
+	return list
+}
+
+// tokensOf returns an element and current tokens for a DOMTokenList method receiver.
+func tokensOf(this js.Value) (*node, []string) {
+	n := tokenLists[this.Ref]
+	if n == nil {
+		throwTypeError("Illegal invocation")
+	}
+	return n, refreshTokens(this, n)
+}
+
+// checkTokens validates tokens passed to DOMTokenList methods.
+func checkTokens(args []js.Value) []string {
+	out := make([]string, 0, len(args))
+	for _, a := range args {
+		s := toString(a)
+		if s == "" {
+			throwDOMException("SyntaxError", "The token provided must not be empty.")
+		}
+		if strings.ContainsAny(s, " \t\n\f\r") {
+			throwDOMException("InvalidCharacterError", "The token provided ('"+s+"') contains HTML space characters, which are not valid in tokens.")
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// setTokens updates the class attribute of the element.
+func setTokens(this js.Value, n *node, list []string) {
+	if len(list) == 0 && !n.hasAttr("class") {
+		return
+	}
+	n.setAttr("class", strings.Join(list, " "))
+	refreshTokens(this, n)
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func setupStyle() {
+	styleClass = newClass("CSSStyleDeclaration", nil, nil)
+	c := styleClass
+	c.getter("cssText", func(this js.Value) interface{} {
+		return cssText(this)
+	}, func(this js.Value, v js.Value) {
+		setCSSText(this, nullableString(v))
+	})
+	c.getter("length", func(this js.Value) interface{} {
+		n := 0
+		for _, k := range keysOf(this) {
+			if toString(this.Get(k)) != "" {
+				n++
+			}
+		}
+		return n
+	}, nil)
+	c.method("item", func(this js.Value, args []js.Value) interface{} {
+		i := arg(args, 0).Int()
+		for _, k := range keysOf(this) {
+			if toString(this.Get(k)) == "" {
+				continue
+			}
+			if i == 0 {
+				return cssName(k)
+			}
+			i--
+		}
+		return ""
+	})
+	c.method("getPropertyValue", func(this js.Value, args []js.Value) interface{} {
+		v := this.Get(cssKey(toString(arg(args, 0))))
+		if v.IsUndefined() {
+			return ""
+		}
+		return toString(v)
+	})
+	c.method("getPropertyPriority", func(this js.Value, args []js.Value) interface{} {
+		return ""
+	})
+	c.method("setProperty", func(this js.Value, args []js.Value) interface{} {
+		key := cssKey(toString(arg(args, 0)))
+		if v := nullableString(arg(args, 1)); v != "" {
+			this.Set(key, v)
+		} else {
+			deleteProp(this, key)
+		}
+		return nil
+	})
+	c.method("removeProperty", func(this js.Value, args []js.Value) interface{} {
+		key := cssKey(toString(arg(args, 0)))
+		v := this.Get(key)
+		deleteProp(this, key)
+		if v.IsUndefined() {
+			return ""
+		}
+		return toString(v)
+	})
+
+	stringMapClass = newClass("DOMStringMap", nil, nil)
+
+	tokenListClass = newClass("DOMTokenList", nil, nil)
+	c = tokenListClass
+	c.getter("length", func(this js.Value) interface{} {
+		_, list := tokensOf(this)
+		return len(list)
+	}, nil)
+	c.getter("value", func(this js.Value) interface{} {
+		n, _ := tokensOf(this)
+		v, _ := n.getAttr("class")
+		return v
+	}, func(this js.Value, v js.Value) {
+		n, _ := tokensOf(this)
+		n.setAttr("class", toString(v))
+		refreshTokens(this, n)
+	})
+	c.method("toString", func(this js.Value, args []js.Value) interface{} {
+		n, _ := tokensOf(this)
+		v, _ := n.getAttr("class")
+		return v
+	})
+	c.method("item", func(this js.Value, args []js.Value) interface{} {
+		_, list := tokensOf(this)
+		if i := arg(args, 0).Int(); i >= 0 && i < len(list) {
+			return list[i]
+		}
+		return nil
+	})
+	c.method("contains", func(this js.Value, args []js.Value) interface{} {
+		_, list := tokensOf(this)
+		return indexOf(list, toString(arg(args, 0))) >= 0
+	})
+	c.method("add", func(this js.Value, args []js.Value) interface{} {
+		n, list := tokensOf(this)
+		for _, s := range checkTokens(args) {
+			if indexOf(list, s) < 0 {
+				list = append(list, s)
+			}
+		}
+		setTokens(this, n, list)
+		return nil
+	})
+	c.method("remove", func(this js.Value, args []js.Value) interface{} {
+		n, list := tokensOf(this)
+		for _, s := range checkTokens(args) {
+			if i := indexOf(list, s); i >= 0 {
+				list = append(list[:i:i], list[i+1:]...)
+			}
+		}
+		setTokens(this, n, list)
+		return nil
+	})
+	c.method("toggle", func(this js.Value, args []js.Value) interface{} {
+		n, list := tokensOf(this)
+		s := checkTokens([]js.Value{arg(args, 0)})[0]
+		force := arg(args, 1)
+		i := indexOf(list, s)
+		if i >= 0 {
+			if force.IsUndefined() || !force.Truthy() {
+				setTokens(this, n, append(list[:i:i], list[i+1:]...))
+				return false
+			}
+			return true
+		}
+		if !force.IsUndefined() && !force.Truthy() {
+			return false
+		}
+		setTokens(this, n, append(list, s))
+		return true
+	})
+	c.method("replace", func(this js.Value, args []js.Value) interface{} {
+		n, list := tokensOf(this)
+		toks := checkTokens([]js.Value{arg(args, 0), arg(args, 1)})
+		i := indexOf(list, toks[0])
+		if i < 0 {
+			return false
+		}
+		list[i] = toks[1]
+		setTokens(this, n, uniqueTokens(list))
+		return true
+	})
+	c.method("supports", func(this js.Value, args []js.Value) interface{} {
+		return true
+	})
+	c.method("forEach", func(this js.Value, args []js.Value) interface{} {
+		_, list := tokensOf(this)
+		for i, s := range list {
+			arg(args, 0).Call("call", arg(args, 1), s, i, this)
+		}
+		return nil
+	})
+}
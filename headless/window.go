@@ -0,0 +1,328 @@
+//+build !wasm
+
+package headless
+
+import (
+	"encoding/base64"
+	"net/url"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/dennwc/dom/js"
+)
+
+const (
+	defaultURL = "http://localhost/"
+	defaultDoc = "<!DOCTYPE html><html><head></head><body></body></html>"
+)
+
+var (
+	window   js.Value
+	location *url.URL
+
+	windowClass   *class
+	locationClass *class
+	storageClass  *class
+)
+
+func setupWindow() {
+	location, _ = url.Parse(defaultURL)
+	window = js.Get("globalThis")
+
+	windowClass = newClass("Window", eventTargetClass, nil)
+	objectClass.Call("setPrototypeOf", window, windowClass.proto)
+	window.Set("window", window)
+	window.Set("self", window)
+
+	c := windowClass
+	c.getter("location", func(this js.Value) interface{} {
+		return locationObj
+	}, func(this js.Value, v js.Value) {
+		setLocation(toString(v))
+	})
+	c.getter("localStorage", func(this js.Value) interface{} {
+		return localStorage
+	}, nil)
+	c.getter("sessionStorage", func(this js.Value) interface{} {
+		return sessionStorage
+	}, nil)
+	for name, v := range map[string]interface{}{
+		"innerWidth": 1024, "innerHeight": 768, "outerWidth": 1024, "outerHeight": 768,
+		"scrollX": 0, "scrollY": 0, "pageXOffset": 0, "pageYOffset": 0, "devicePixelRatio": 1,
+	} {
+		defineValue(window, name, v)
+	}
+	window.Set("navigator", js.ValueOf(js.Obj{
+		"userAgent":           "Mozilla/5.0 (" + runtime.GOOS + ") Go headless",
+		"language":            "en-US",
+		"languages":           []interface{}{"en-US", "en"},
+		"platform":            runtime.GOOS,
+		"onLine":              true,
+		"cookieEnabled":       false,
+		"hardwareConcurrency": runtime.NumCPU(),
+	}))
+	window.Set("performance", js.ValueOf(js.Obj{
+		"timeOrigin": float64(startTime.UnixNano()) / float64(time.Millisecond),
+		"now": js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			return float64(time.Since(startTime)) / float64(time.Millisecond)
+		}),
+	}))
+	method := func(name string, fnc func(args []js.Value) interface{}) {
+		defineValue(window, name, js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			return fnc(args)
+		}))
+	}
+	method("getComputedStyle", func(args []js.Value) interface{} {
+		n := nodeOf(arg(args, 0))
+		if !n.isElement() {
+			throwTypeError("Failed to execute 'getComputedStyle' on 'Window': parameter 1 is not of type 'Element'.")
+		}
+		st := styleClass.create()
+		if n.style.Valid() || n.hasAttr("style") {
+			setCSSText(st, cssText(styleOf(n)))
+		}
+		return st
+	})
+	method("requestAnimationFrame", func(args []js.Value) interface{} {
+		fnc := arg(args, 0)
+		var cb js.Func
+		cb = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			cb.Release()
+			fnc.Invoke(float64(time.Since(startTime)) / float64(time.Millisecond))
+			return nil
+		})
+		return js.Get("setTimeout").Invoke(cb, 16)
+	})
+	method("cancelAnimationFrame", func(args []js.Value) interface{} {
+		js.Get("clearTimeout").Invoke(arg(args, 0))
+		return nil
+	})
+	method("btoa", func(args []js.Value) interface{} {
+		s := toString(arg(args, 0))
+		b := make([]byte, 0, len(s))
+		for _, r := range s {
+			if r > 0xff {
+				throwDOMException("InvalidCharacterError", "Failed to execute 'btoa' on 'Window': The string to be encoded contains characters outside of the Latin1 range.")
+			}
+			b = append(b, byte(r))
+		}
+		return base64.StdEncoding.EncodeToString(b)
+	})
+	method("atob", func(args []js.Value) interface{} {
+		s := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(" \t\n\f\r", r) {
+				return -1
+			}
+			return r
+		}, toString(arg(args, 0)))
+		b, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+		if err != nil {
+			throwDOMException("InvalidCharacterError", "Failed to execute 'atob' on 'Window': The string to be decoded is not correctly encoded.")
+		}
+		r := make([]rune, len(b))
+		for i, c := range b {
+			r[i] = rune(c)
+		}
+		return string(r)
+	})
+	method("open", func(args []js.Value) interface{} {
+		return nil
+	})
+	for _, name := range []string{"alert", "focus", "blur", "scroll", "scrollTo", "scrollBy", "print", "close"} {
+		method(name, func(args []js.Value) interface{} {
+			return nil
+		})
+	}
+
+	setupLocation()
+	setupStorage()
+}
+
+var locationObj js.Value
+
+// setLocation changes the document location. Navigation is not performed, but "hashchange" event is fired
+// if only the fragment is changed.
+func setLocation(s string) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		throwDOMException("SyntaxError", "'"+s+"' is not a valid URL.")
+	}
+	old := location
+	location = old.ResolveReference(u)
+	if old.Fragment != location.Fragment {
+		o1, o2 := *old, *location
+		o1.Fragment, o2.Fragment = "", ""
+		if o1 == o2 {
+			dispatchTrusted(window, newEvent(eventClass, "hashchange", nil))
+		}
+	}
+}
+
+func setupLocation() {
+	locationClass = newClass("Location", nil, nil)
+	c := locationClass
+	part := func(name string, get func(u *url.URL) string, set func(u *url.URL, s string)) {
+		c.getter(name, func(this js.Value) interface{} {
+			return get(location)
+		}, func(this js.Value, v js.Value) {
+			u := *location
+			set(&u, toString(v))
+			setLocation(u.String())
+		})
+	}
+	part("href", func(u *url.URL) string {
+		return u.String()
+	}, func(u *url.URL, s string) {
+		*u = url.URL{Path: s}
+		if v, err := url.Parse(s); err == nil {
+			*u = *v
+		}
+	})
+	part("protocol", func(u *url.URL) string {
+		return u.Scheme + ":"
+	}, func(u *url.URL, s string) {
+		u.Scheme = strings.TrimSuffix(s, ":")
+	})
+	part("host", func(u *url.URL) string {
+		return u.Host
+	}, func(u *url.URL, s string) {
+		u.Host = s
+	})
+	part("hostname", func(u *url.URL) string {
+		return u.Hostname()
+	}, func(u *url.URL, s string) {
+		if p := u.Port(); p != "" {
+			s += ":" + p
+		}
+		u.Host = s
+	})
+	part("port", func(u *url.URL) string {
+		return u.Port()
+	}, func(u *url.URL, s string) {
+		u.Host = u.Hostname()
+		if s != "" {
+			u.Host += ":" + s
+		}
+	})
+	part("pathname", func(u *url.URL) string {
+		if u.Path == "" {
+			return "/"
+		}
+		return u.EscapedPath()
+	}, func(u *url.URL, s string) {
+		u.Path, u.RawPath = s, ""
+	})
+	part("search", func(u *url.URL) string {
+		if u.RawQuery == "" {
+			return ""
+		}
+		return "?" + u.RawQuery
+	}, func(u *url.URL, s string) {
+		u.RawQuery = strings.TrimPrefix(s, "?")
+	})
+	part("hash", func(u *url.URL) string {
+		if u.Fragment == "" {
+			return ""
+		}
+		return "#" + u.Fragment
+	}, func(u *url.URL, s string) {
+		u.Fragment = strings.TrimPrefix(s, "#")
+	})
+	c.getter("origin", func(this js.Value) interface{} {
+		return location.Scheme + "://" + location.Host
+	}, nil)
+	assign := func(this js.Value, args []js.Value) interface{} {
+		setLocation(toString(arg(args, 0)))
+		return nil
+	}
+	c.method("assign", assign)
+	c.method("replace", assign)
+	c.method("reload", func(this js.Value, args []js.Value) interface{} {
+		return nil
+	})
+	c.method("toString", func(this js.Value, args []js.Value) interface{} {
+		return location.String()
+	})
+	locationObj = c.create()
+}
+
+// storage is an internal state of a Storage object.
+type storage struct {
+	keys []string
+	vals map[string]string
+}
+
+var (
+	storages = make(map[js.Ref]*storage)
+
+	localStorage, sessionStorage js.Value
+)
+
+func storageOf(this js.Value) *storage {
+	s := storages[this.Ref]
+	if s == nil {
+		throwTypeError("Illegal invocation")
+	}
+	return s
+}
+
+func (s *storage) remove(key string) {
+	if _, ok := s.vals[key]; !ok {
+		return
+	}
+	delete(s.vals, key)
+	for i, k := range s.keys {
+		if k == key {
+			s.keys = append(s.keys[:i:i], s.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+func newStorage() js.Value {
+	v := storageClass.create()
+	storages[v.Ref] = &storage{vals: make(map[string]string)}
+	return v
+}
+
+func setupStorage() {
+	storageClass = newClass("Storage", nil, nil)
+	c := storageClass
+	c.getter("length", func(this js.Value) interface{} {
+		return len(storageOf(this).keys)
+	}, nil)
+	c.method("key", func(this js.Value, args []js.Value) interface{} {
+		s := storageOf(this)
+		if i := arg(args, 0).Int(); i >= 0 && i < len(s.keys) {
+			return s.keys[i]
+		}
+		return nil
+	})
+	c.method("getItem", func(this js.Value, args []js.Value) interface{} {
+		if v, ok := storageOf(this).vals[toString(arg(args, 0))]; ok {
+			return v
+		}
+		return nil
+	})
+	c.method("setItem", func(this js.Value, args []js.Value) interface{} {
+		s := storageOf(this)
+		k, v := toString(arg(args, 0)), toString(arg(args, 1))
+		if _, ok := s.vals[k]; !ok {
+			s.keys = append(s.keys, k)
+		}
+		s.vals[k] = v
+		return nil
+	})
+	c.method("removeItem", func(this js.Value, args []js.Value) interface{} {
+		storageOf(this).remove(toString(arg(args, 0)))
+		return nil
+	})
+	c.method("clear", func(this js.Value, args []js.Value) interface{} {
+		s := storageOf(this)
+		s.keys, s.vals = nil, make(map[string]string)
+		return nil
+	})
+	localStorage = newStorage()
+	sessionStorage = newStorage()
+}
@@ -0,0 +1,6 @@
+//+build !wasm
+
+package dom
+
+// On non-wasm builds the package uses a pure-Go DOM implementation for tests.
+import _ "github.com/dennwc/dom/headless"
@@ -27,11 +27,11 @@ func (inp *Input) SetName(name string) {
 func (inp *Input) SetValue(val interface{}) {
 	inp.v.Set("value", val)
 }
-func (inp *Input) OnChange(h EventHandler) {
-	inp.AddEventListener("change", h)
+func (inp *Input) OnChange(h EventHandler) func() {
+	return inp.AddEventListener("change", h)
 }
-func (inp *Input) OnInput(h EventHandler) {
-	inp.AddEventListener("input", h)
+func (inp *Input) OnInput(h EventHandler) func() {
+	return inp.AddEventListener("input", h)
 }
 
 func (d *Document) NewButton(s string) *Button {
@@ -49,6 +49,6 @@ type Button struct {
 	Element
 }
 
-func (b *Button) OnClick(h EventHandler) {
-	b.AddEventListener("click", h)
+func (b *Button) OnClick(h EventHandler) func() {
+	return b.AddEventListener("click", h)
 }
@@ -0,0 +1,337 @@
+package js
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	batchRunner     Value
+	batchRunnerOnce sync.Once
+)
+
+// batch opcodes
+const (
+	batchGet = 1 + iota
+	batchSet
+	batchCall
+	batchNew
+)
+
+// batch operand kinds
+const (
+	operUndefined = iota
+	operNull
+	operNumber
+	operBool
+	operString
+	operHandle
+	operValue
+	operGlobal
+)
+
+// Batch records operations on JS values and executes all of them in a single call to JS.
+//
+// Each call between Go and JS has a significant cost, thus building a large DOM tree element by element
+// might be slow. Batch allows to record such operations as a compact instruction buffer and then run them
+// in one go. Operations that produce values return handles that can be used as arguments of later operations,
+// and can be resolved to values after the batch is executed.
+//
+// Arguments of operations can be handles of the same batch, strings, numbers, booleans, nil, or any value
+// accepted by ValueOf. For example:
+//
+//	b := js.NewBatch()
+//	doc := js.Get("document")
+//	table := b.Call(doc, "createElement", "table")
+//	for i := 0; i < 1000; i++ {
+//		tr := b.Call(doc, "createElement", "tr")
+//		b.Set(tr, "textContent", strconv.Itoa(i))
+//		b.Append(table, tr)
+//	}
+//	b.Append(js.Get("document", "body"), table)
+//	err := b.Run()
+//
+// Batch is not safe for concurrent use.
+type Batch struct {
+	ops   []float64
+	strs  []string
+	index map[string]int
+	vals  []interface{}
+	n     int // number of handles
+
+	done  bool
+	res   Value   // JS array with values of handles
+	slots []Value // values of handles, if executed by Go
+}
+
+// Handle is a reference to a value that will be produced by a batch operation.
+type Handle struct {
+	b *Batch
+	i int
+}
+
+// NewBatch creates a new empty batch.
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// Len returns the number of handles allocated by the batch.
+func (b *Batch) Len() int {
+	return b.n
+}
+
+// Reset clears the batch, so it can be reused. Handles returned previously become invalid.
+func (b *Batch) Reset() {
+	*b = Batch{ops: b.ops[:0]}
+}
+
+func (b *Batch) checkRun() {
+	if b.done {
+		panic("js: batch was already executed")
+	}
+}
+
+func (b *Batch) handle() Handle {
+	h := Handle{b: b, i: b.n}
+	b.n++
+	b.ops = append(b.ops, float64(h.i))
+	return h
+}
+
+func (b *Batch) str(s string) {
+	i, ok := b.index[s]
+	if !ok {
+		if b.index == nil {
+			b.index = make(map[string]int)
+		}
+		i = len(b.strs)
+		b.strs = append(b.strs, s)
+		b.index[s] = i
+	}
+	b.ops = append(b.ops, float64(i))
+}
+
+func (b *Batch) operand(kind int, x float64) {
+	b.ops = append(b.ops, float64(kind), x)
+}
+
+// value encodes an operand.
+func (b *Batch) value(v interface{}) {
+	switch v := v.(type) {
+	case Handle:
+		if v.b != b {
+			panic("js: handle belongs to a different batch")
+		}
+		b.operand(operHandle, float64(v.i))
+	case nil:
+		b.operand(operNull, 0)
+	case bool:
+		x := 0.0
+		if v {
+			x = 1
+		}
+		b.operand(operBool, x)
+	case string:
+		b.ops = append(b.ops, operString)
+		b.str(v)
+	case int:
+		b.operand(operNumber, float64(v))
+	case int8:
+		b.operand(operNumber, float64(v))
+	case int16:
+		b.operand(operNumber, float64(v))
+	case int32:
+		b.operand(operNumber, float64(v))
+	case int64:
+		b.operand(operNumber, float64(v))
+	case uint:
+		b.operand(operNumber, float64(v))
+	case uint8:
+		b.operand(operNumber, float64(v))
+	case uint16:
+		b.operand(operNumber, float64(v))
+	case uint32:
+		b.operand(operNumber, float64(v))
+	case uint64:
+		b.operand(operNumber, float64(v))
+	case float32:
+		b.operand(operNumber, float64(v))
+	case float64:
+		b.operand(operNumber, v)
+	default:
+		r := ValueOf(v)
+		if r.IsUndefined() {
+			b.operand(operUndefined, 0)
+			return
+		}
+		b.operand(operValue, float64(len(b.vals)))
+		b.vals = append(b.vals, r.Ref)
+	}
+}
+
+func (b *Batch) args(args []interface{}) {
+	b.ops = append(b.ops, float64(len(args)))
+	for _, a := range args {
+		b.value(a)
+	}
+}
+
+// Get records reading a property of the object.
+func (b *Batch) Get(obj interface{}, name string) Handle {
+	b.checkRun()
+	b.ops = append(b.ops, batchGet)
+	h := b.handle()
+	b.value(obj)
+	b.str(name)
+	return h
+}
+
+// Set records setting a property of the object.
+func (b *Batch) Set(obj interface{}, name string, val interface{}) {
+	b.checkRun()
+	b.ops = append(b.ops, batchSet)
+	b.value(obj)
+	b.str(name)
+	b.value(val)
+}
+
+// Call records calling a method of the object.
+func (b *Batch) Call(obj interface{}, name string, args ...interface{}) Handle {
+	b.checkRun()
+	b.ops = append(b.ops, batchCall)
+	h := b.handle()
+	b.value(obj)
+	b.str(name)
+	b.args(args)
+	return h
+}
+
+// New records creating an instance of a global class.
+func (b *Batch) New(class string, args ...interface{}) Handle {
+	b.checkRun()
+	b.ops = append(b.ops, batchNew)
+	h := b.handle()
+	b.ops = append(b.ops, operGlobal)
+	b.str(class)
+	b.args(args)
+	return h
+}
+
+// Append records appending a child node to the parent node.
+func (b *Batch) Append(parent, child interface{}) {
+	b.Call(parent, "appendChild", child)
+}
+
+// Run executes all recorded operations. Operations are executed in order.
+// If one of them throws an exception, remaining operations are not executed, and the error is returned.
+//
+// Run can only be called once. To reuse the batch, call Reset.
+func (b *Batch) Run() error {
+	if b.done {
+		return errors.New("js: batch was already executed")
+	}
+	b.done = true
+	if len(b.ops) == 0 {
+		return nil
+	}
+	batchRunnerOnce.Do(func() {
+		// Function constructor might be forbidden by Content Security Policy
+		Try(initBatchRunner)
+	})
+	if !batchRunner.Valid() {
+		b.slots = make([]Value, b.n)
+		return Try(b.run)
+	}
+	strs, err := json.Marshal(b.strs)
+	if err != nil {
+		return err
+	}
+	arr := TypedArrayOf(b.ops)
+	defer arr.Release()
+	return Try(func() {
+		b.res = batchRunner.Invoke(arr, string(strs), b.vals)
+	})
+}
+
+// run is an implementation of the batch interpreter in Go.
+// It is used when the batch cannot be executed by JS.
+func (b *Batch) run() {
+	ops, pc := b.ops, 0
+	next := func() int {
+		x := ops[pc]
+		pc++
+		return int(x)
+	}
+	str := func() string {
+		return b.strs[next()]
+	}
+	val := func() Value {
+		k, x := next(), ops[pc]
+		pc++
+		switch k {
+		case operUndefined:
+			return Value{undefined}
+		case operNull:
+			return Value{null}
+		case operNumber:
+			return ValueOf(x)
+		case operBool:
+			return ValueOf(x != 0)
+		case operString:
+			return ValueOf(b.strs[int(x)])
+		case operHandle:
+			return b.slots[int(x)]
+		case operValue:
+			return ValueOf(b.vals[int(x)])
+		case operGlobal:
+			return Get(b.strs[int(x)])
+		}
+		panic(fmt.Errorf("js: invalid batch operand kind: %d", k))
+	}
+	args := func() []interface{} {
+		out := make([]interface{}, next())
+		for i := range out {
+			out[i] = val()
+		}
+		return out
+	}
+	for pc < len(ops) {
+		switch op := next(); op {
+		case batchGet:
+			dst, obj := next(), val()
+			b.slots[dst] = obj.Get(str())
+		case batchSet:
+			obj, name := val(), str()
+			obj.Set(name, val())
+		case batchCall:
+			dst, obj, name := next(), val(), str()
+			b.slots[dst] = obj.Call(name, args()...)
+		case batchNew:
+			dst, class := next(), val()
+			b.slots[dst] = class.New(args()...)
+		default:
+			panic(fmt.Errorf("js: invalid batch opcode: %d", op))
+		}
+	}
+}
+
+// Value returns a value produced by the operation. It panics if the batch was not executed yet.
+func (h Handle) Value() Value {
+	if !h.b.done {
+		panic("js: batch was not executed yet")
+	}
+	if h.b.slots != nil {
+		return h.b.slots[h.i]
+	}
+	if !h.b.res.Valid() {
+		return Value{undefined}
+	}
+	return h.b.res.Index(h.i)
+}
+
+// JSValue implements Wrapper interface. It panics if the batch was not executed yet.
+func (h Handle) JSValue() Ref {
+	return h.Value().Ref
+}
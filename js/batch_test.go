@@ -0,0 +1,47 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch(t *testing.T) {
+	ext := ValueOf([]interface{}{})
+
+	b := NewBatch()
+	arr := b.New("Array")
+	b.Call(arr, "push", 1, "a", true, nil, Value{})
+	obj := b.New("Object")
+	b.Set(obj, "x", arr)
+	b.Set(obj, "y", "a")
+	n := b.Get(arr, "length")
+	b.Call(ext, "push", obj)
+	require.Equal(t, 5, b.Len())
+
+	require.Panics(t, func() {
+		n.Value()
+	})
+	require.NoError(t, b.Run())
+	require.Error(t, b.Run())
+	require.Panics(t, func() {
+		b.Set(obj, "z", 1)
+	})
+
+	require.Equal(t, 5, n.Value().Int())
+	require.Equal(t, 1, ext.Length())
+	o := ext.Index(0)
+	require.Equal(t, obj.Value(), o)
+	require.Equal(t, "a", o.Get("y").String())
+	a := o.Get("x")
+	require.Equal(t, 1, a.Index(0).Int())
+	require.Equal(t, "a", a.Index(1).String())
+	require.True(t, a.Index(2).Bool())
+	require.True(t, a.Index(3).IsNull())
+	require.True(t, a.Index(4).IsUndefined())
+
+	b.Reset()
+	require.Equal(t, 0, b.Len())
+	b.Call(NewObject(), "missing")
+	require.Error(t, b.Run())
+}
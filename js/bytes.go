@@ -1,7 +1,9 @@
 package js
 
 import (
+	"context"
 	"fmt"
+	"sync"
 )
 
 var _ Wrapper = TypedArray{}
@@ -40,22 +42,80 @@ func (m *Memory) Bytes() []byte {
 }
 
 // CopyFrom copies binary data from JS object into Go buffer.
+//
+// Supported sources are ArrayBuffer, DataView, any typed array, Blob, Response and ReadableStream.
+// The data must fit into the buffer.
 func (m *Memory) CopyFrom(v Wrapper) error {
-	var src Value
-	switch v := v.(type) {
-	case Value:
-		src = v
-	case TypedArray:
-		src = v.Value
-	default:
-		src = Value{v.JSValue()}
+	src := Value{v.JSValue()}
+	if arr, ok := bytesView(src); ok {
+		if n := arr.Length(); n > len(m.p) {
+			return fmt.Errorf("buffer is too small: %d < %d", len(m.p), n)
+		}
+		m.v.Call("set", arr)
+		return nil
+	}
+	if isInstanceOf(src, "ReadableStream") {
+		p, err := readStream(context.Background(), src)
+		if err != nil {
+			return err
+		} else if len(p) > len(m.p) {
+			return fmt.Errorf("buffer is too small: %d < %d", len(m.p), len(p))
+		}
+		copy(m.p, p)
+		return nil
+	}
+	data, err := readAsBuffer(context.Background(), src)
+	if err != nil {
+		return err
+	}
+	return m.CopyFrom(data)
+}
+
+func (m *Memory) JSValue() Ref {
+	return m.v.JSValue()
+}
+
+func (m *Memory) Release() {
+	m.v.Release()
+}
+
+// MMap exposes memory of p to JS.
+//
+// Release must be called to free up resources when the memory will not be used any more.
+func MMap(p []byte) *Memory {
+	v := TypedArrayOf(p)
+	return &Memory{p: p, v: v}
+}
+
+// isInstanceOf is similar to InstanceOfClass, but returns false if the class is not defined.
+func isInstanceOf(v Value, class string) bool {
+	if !v.isObject() {
+		return false
 	}
+	cl := Class(class)
+	return cl.Valid() && v.InstanceOf(cl)
+}
 
+// bytesView returns a Uint8Array view of the binary data stored in ArrayBuffer, SharedArrayBuffer,
+// DataView or any typed array. The data is not copied.
+func bytesView(v Value) (Value, bool) {
 	switch {
-	case src.InstanceOfClass("Uint8Array"):
-		m.v.Call("set", src)
-		return nil
-	case src.InstanceOfClass("Blob"):
+	case !v.isObject():
+		return Value{}, false
+	case v.InstanceOfClass("Uint8Array"):
+		return v, true
+	case v.InstanceOfClass("ArrayBuffer"), isInstanceOf(v, "SharedArrayBuffer"):
+		return New("Uint8Array", v), true
+	case Class("ArrayBuffer").Call("isView", v).Bool():
+		return New("Uint8Array", v.Get("buffer"), v.Get("byteOffset"), v.Get("byteLength")), true
+	}
+	return Value{}, false
+}
+
+// readAsBuffer reads all data from Blob or Response into a new ArrayBuffer.
+func readAsBuffer(ctx context.Context, src Value) (Value, error) {
+	switch {
+	case isInstanceOf(src, "Blob"):
 		r := New("FileReader")
 
 		cg := r.NewFuncGroup()
@@ -66,29 +126,201 @@ func (m *Memory) CopyFrom(v Wrapper) error {
 		r.Call("readAsArrayBuffer", src)
 		select {
 		case err := <-errc:
-			return err
+			return Value{}, err
+		case <-ctx.Done():
+			r.Call("abort")
+			return Value{}, ctx.Err()
 		case <-done:
 		}
-		cg.Release()
-		arr := New("Uint8Array", r.Get("result"))
-		return m.CopyFrom(arr)
-	default:
-		return fmt.Errorf("unsupported source type")
+		return r.Get("result"), nil
+	case isInstanceOf(src, "Response"):
+		res, err := src.Call("arrayBuffer").Promised().AwaitContext(ctx)
+		if err != nil {
+			return Value{}, err
+		}
+		return res[0], nil
 	}
+	return Value{}, fmt.Errorf("unsupported source type")
 }
 
-func (m *Memory) JSValue() Ref {
-	return m.v.JSValue()
+// readStream reads all chunks from ReadableStream of binary data.
+func readStream(ctx context.Context, src Value) ([]byte, error) {
+	r := src.Call("getReader")
+	defer r.Call("releaseLock")
+	var buf []byte
+	for {
+		res, err := r.Call("read").Promised().AwaitContext(ctx)
+		if err != nil {
+			r.Call("cancel")
+			return nil, err
+		}
+		chunk := res[0]
+		if chunk.Get("done").Bool() {
+			return buf, nil
+		}
+		arr, ok := bytesView(chunk.Get("value"))
+		if !ok {
+			r.Call("cancel")
+			return nil, fmt.Errorf("unsupported stream chunk type")
+		}
+		n := len(buf)
+		buf = append(buf, make([]byte, arr.Length())...)
+		if _, err = CopyBytesToGo(buf[n:], arr); err != nil {
+			return nil, err
+		}
+	}
 }
 
-func (m *Memory) Release() {
-	m.v.Release()
+// CopyBytesToGo copies bytes from JS ArrayBuffer, DataView or any typed array to dst.
+// It returns the number of bytes copied, which is the minimum of the lengths of src and dst.
+func CopyBytesToGo(dst []byte, src Wrapper) (int, error) {
+	arr, ok := bytesView(Value{src.JSValue()})
+	if !ok {
+		return 0, fmt.Errorf("unsupported source type")
+	}
+	n := arr.Length()
+	if n > len(dst) {
+		n = len(dst)
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	if n < arr.Length() {
+		arr = arr.Call("subarray", 0, n)
+	}
+	m := MMap(dst[:n])
+	m.v.Call("set", arr)
+	m.Release()
+	return n, nil
 }
 
-// MMap exposes memory of p to JS.
+// CopyBytesToJS copies bytes from src to JS ArrayBuffer, DataView or any typed array.
+// It returns the number of bytes copied, which is the minimum of the lengths of src and dst.
+func CopyBytesToJS(dst Wrapper, src []byte) (int, error) {
+	arr, ok := bytesView(Value{dst.JSValue()})
+	if !ok {
+		return 0, fmt.Errorf("unsupported destination type")
+	}
+	n := arr.Length()
+	if n > len(src) {
+		n = len(src)
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	m := MMap(src[:n])
+	arr.Call("set", m.v)
+	m.Release()
+	return n, nil
+}
+
+// ReadBytes reads all binary data from JS object.
 //
-// Release must be called to free up resources when the memory will not be used any more.
-func MMap(p []byte) *Memory {
-	v := TypedArrayOf(p)
-	return &Memory{p: p, v: v}
+// Supported sources are ArrayBuffer, DataView, any typed array, Blob, Response and ReadableStream.
+// Sources other than buffers and views are read asynchronously, so the function must not be called from
+// JS callbacks in this case.
+func ReadBytes(src Wrapper) ([]byte, error) {
+	return ReadBytesContext(context.Background(), src)
+}
+
+// ReadBytesContext is the same as ReadBytes, but allows to cancel asynchronous reads.
+func ReadBytesContext(ctx context.Context, src Wrapper) ([]byte, error) {
+	v := Value{src.JSValue()}
+	if isInstanceOf(v, "ReadableStream") {
+		return readStream(ctx, v)
+	}
+	if _, ok := bytesView(v); !ok {
+		var err error
+		v, err = readAsBuffer(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	arr, _ := bytesView(v)
+	p := make([]byte, arr.Length())
+	if _, err := CopyBytesToGo(p, arr); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+const (
+	minBufferShift = 9  // 512 bytes
+	maxBufferShift = 20 // 1 MB
+)
+
+// bufferPools holds reusable JS Uint8Arrays for each power of two size.
+var bufferPools [maxBufferShift - minBufferShift + 1]sync.Pool
+
+// bufferClass returns an index of the pool for buffers of a given size.
+// It returns -1 if buffers of this size are not pooled.
+func bufferClass(n int) int {
+	for i := range bufferPools {
+		if n <= 1<<uint(minBufferShift+i) {
+			return i
+		}
+	}
+	return -1
+}
+
+var _ Wrapper = (*Buffer)(nil)
+
+// Buffer is a Uint8Array allocated from a pool of JS buffers.
+//
+// It allows to pass binary data to JS APIs that copy it or send it somewhere, like WebSocket.send,
+// without allocating a new JS buffer each time.
+type Buffer struct {
+	arr  Value // full pooled array
+	view Value // view of the requested length
+	cls  int
+}
+
+// GetBuffer returns a Uint8Array of size n from the pool of JS buffers.
+// The contents of the buffer are undefined.
+//
+// Release must be called to return the buffer to the pool.
+func GetBuffer(n int) *Buffer {
+	cls := bufferClass(n)
+	var arr Value
+	if cls >= 0 {
+		if v, ok := bufferPools[cls].Get().(Value); ok {
+			arr = v
+		} else {
+			arr = New("Uint8Array", 1<<uint(minBufferShift+cls))
+		}
+	} else {
+		arr = New("Uint8Array", n)
+	}
+	b := &Buffer{arr: arr, view: arr, cls: cls}
+	if arr.Length() != n {
+		b.view = arr.Call("subarray", 0, n)
+	}
+	return b
+}
+
+// BufferOf returns a buffer from the pool of JS buffers filled with a copy of p.
+//
+// Release must be called to return the buffer to the pool.
+func BufferOf(p []byte) *Buffer {
+	b := GetBuffer(len(p))
+	CopyBytesToJS(b.view, p)
+	return b
+}
+
+// JSValue implements Wrapper interface.
+func (b *Buffer) JSValue() Ref {
+	return b.view.Ref
+}
+
+// Len returns the size of the buffer.
+func (b *Buffer) Len() int {
+	return b.view.Length()
+}
+
+// Release returns the buffer to the pool. Buffer must not be used after calling Release.
+func (b *Buffer) Release() {
+	if b.cls >= 0 && b.arr.Valid() {
+		bufferPools[b.cls].Put(b.arr)
+	}
+	b.arr, b.view = Value{}, Value{}
 }
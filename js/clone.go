@@ -0,0 +1,105 @@
+package js
+
+var _ Wrapper = (*Transfer)(nil)
+
+// Transfer is a list of transferable objects, like ArrayBuffer or MessagePort, that are moved
+// to the receiver by postMessage or StructuredClone instead of being copied.
+//
+// Transferred objects become unusable by the sender: ArrayBuffers are detached and their length becomes zero.
+type Transfer struct {
+	list []Value
+	seen ValueSet // objects in the list, created on the first Add
+}
+
+// NewTransfer creates a new transfer list with given objects. See Add.
+func NewTransfer(objs ...Wrapper) *Transfer {
+	t := &Transfer{}
+	for _, o := range objs {
+		t.Add(o)
+	}
+	return t
+}
+
+// Add adds a transferable object to the list. For typed arrays and DataView, the underlying buffer is transferred.
+// Objects that are already in the list are ignored.
+func (t *Transfer) Add(obj Wrapper) *Transfer {
+	v := Value{obj.JSValue()}
+	if isInstanceOf(v, "DataView") || Class("ArrayBuffer").Call("isView", v).Bool() {
+		v = v.Get("buffer")
+	}
+	if !t.seen.v.Valid() {
+		t.seen = NewValueSet()
+	} else if t.seen.Has(v) {
+		return t
+	}
+	t.seen.Add(v)
+	t.list = append(t.list, v)
+	return t
+}
+
+// Bytes copies Go bytes into a new JS buffer and adds the buffer to the transfer list.
+// It returns a Uint8Array that must be included into the message.
+//
+// Go memory cannot be transferred, thus the data is copied once, but the receiver gets it without another copy.
+func (t *Transfer) Bytes(p []byte) Value {
+	arr := New("Uint8Array", len(p))
+	if len(p) != 0 {
+		if _, err := CopyBytesToJS(arr, p); err != nil {
+			panic(err)
+		}
+	}
+	t.Add(arr)
+	return arr
+}
+
+// Len returns the number of objects in the transfer list.
+func (t *Transfer) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.list)
+}
+
+// JSValue implements Wrapper interface. It returns the transfer list as JS array.
+func (t *Transfer) JSValue() Ref {
+	arr := make([]interface{}, t.Len())
+	for i := range arr {
+		arr[i] = t.list[i].Ref
+	}
+	return ValueOf(arr).Ref
+}
+
+// Options returns an options object with the transfer list, as accepted by postMessage and structuredClone.
+func (t *Transfer) Options() Value {
+	return ValueOf(Obj{"transfer": t.JSValue()})
+}
+
+// StructuredClone creates a deep copy of a value using the structured clone algorithm, the same way as postMessage does.
+// Go values are converted with Marshal first. Objects in the transfer list are moved to the clone instead of being copied.
+// The transfer list can be nil.
+//
+// It returns an error matching ErrDataClone if the value cannot be cloned, and ErrNotSupported if the environment
+// doesn't support structuredClone.
+func StructuredClone(v interface{}, t *Transfer) (Value, error) {
+	fnc := Get("structuredClone")
+	if fnc.Type() != TypeFunction {
+		return Value{}, ErrNotSupported
+	}
+	src, err := Marshal(v)
+	if err != nil {
+		return Value{}, err
+	}
+	return fnc.TryInvoke(src, t.Options())
+}
+
+// PostMessage sends a message to a target, like Worker, MessagePort or Window, using its postMessage method.
+// Go values are converted with Marshal first. Objects in the transfer list are moved to the receiver.
+// The transfer list can be nil.
+func PostMessage(target Wrapper, msg interface{}, t *Transfer) error {
+	v, err := Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = Value{target.JSValue()}.TryCall("postMessage", v, t.Options())
+	return err
+}
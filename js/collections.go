@@ -0,0 +1,255 @@
+package js
+
+import "sync"
+
+var (
+	_ Wrapper = Map{}
+	_ Wrapper = ValueSet{}
+	_ Wrapper = WeakRef{}
+	_ Wrapper = (*FinalizationRegistry)(nil)
+)
+
+// Map is a wrapper for JS Map object. Unlike Obj, keys can be of any type, including objects.
+type Map struct {
+	v Value
+}
+
+// NewMap creates a new empty JS Map.
+func NewMap() Map {
+	return Map{New("Map")}
+}
+
+// AsMap wraps an existing JS Map object.
+func AsMap(v Value) Map {
+	return Map{v}
+}
+
+// JSValue implements Wrapper interface.
+func (m Map) JSValue() Ref {
+	return m.v.JSValue()
+}
+
+// Get returns a value associated with the key, or undefined if there is no such key.
+func (m Map) Get(key interface{}) Value {
+	return m.v.Call("get", key)
+}
+
+// Has checks if the map contains a given key.
+func (m Map) Has(key interface{}) bool {
+	return m.v.Call("has", key).Bool()
+}
+
+// Set associates the value with the key.
+func (m Map) Set(key, val interface{}) {
+	m.v.Call("set", key, val)
+}
+
+// Delete removes the key from the map. It reports if the key was present.
+func (m Map) Delete(key interface{}) bool {
+	return m.v.Call("delete", key).Bool()
+}
+
+// Clear removes all entries from the map.
+func (m Map) Clear() {
+	m.v.Call("clear")
+}
+
+// Len returns the number of entries in the map.
+func (m Map) Len() int {
+	return m.v.Get("size").Int()
+}
+
+// Range calls fnc for each entry of the map, in insertion order. Iteration stops if fnc returns false.
+func (m Map) Range(fnc func(k, v Value) bool) {
+	m.v.Call("entries").Iterate(func(e Value) bool {
+		return fnc(e.Index(0), e.Index(1))
+	})
+}
+
+// ValueSet is a wrapper for JS Set object. It is not named Set to avoid conflict with Set function.
+type ValueSet struct {
+	v Value
+}
+
+// NewValueSet creates a new JS Set with given values.
+func NewValueSet(vals ...interface{}) ValueSet {
+	s := ValueSet{New("Set")}
+	for _, v := range vals {
+		s.Add(v)
+	}
+	return s
+}
+
+// AsValueSet wraps an existing JS Set object.
+func AsValueSet(v Value) ValueSet {
+	return ValueSet{v}
+}
+
+// JSValue implements Wrapper interface.
+func (s ValueSet) JSValue() Ref {
+	return s.v.JSValue()
+}
+
+// Add adds the value to the set.
+func (s ValueSet) Add(v interface{}) {
+	s.v.Call("add", v)
+}
+
+// Has checks if the set contains a given value.
+func (s ValueSet) Has(v interface{}) bool {
+	return s.v.Call("has", v).Bool()
+}
+
+// Delete removes the value from the set. It reports if the value was present.
+func (s ValueSet) Delete(v interface{}) bool {
+	return s.v.Call("delete", v).Bool()
+}
+
+// Clear removes all values from the set.
+func (s ValueSet) Clear() {
+	s.v.Call("clear")
+}
+
+// Len returns the number of values in the set.
+func (s ValueSet) Len() int {
+	return s.v.Get("size").Int()
+}
+
+// Range calls fnc for each value of the set, in insertion order. Iteration stops if fnc returns false.
+func (s ValueSet) Range(fnc func(v Value) bool) {
+	s.v.Call("values").Iterate(fnc)
+}
+
+// WeakRef is a wrapper for JS WeakRef object. It holds a reference to the object
+// that does not prevent it from being garbage collected.
+type WeakRef struct {
+	v Value
+}
+
+// NewWeakRef creates a weak reference to a given JS object.
+func NewWeakRef(target Wrapper) WeakRef {
+	return WeakRef{New("WeakRef", target)}
+}
+
+// JSValue implements Wrapper interface.
+func (r WeakRef) JSValue() Ref {
+	return r.v.JSValue()
+}
+
+// Deref returns the target object. It returns false if the object was already garbage collected.
+func (r WeakRef) Deref() (Value, bool) {
+	v := r.v.Call("deref")
+	return v, !v.IsUndefined()
+}
+
+// FinalizationRegistry calls Go functions when JS objects are garbage collected.
+//
+// It allows to tie the lifetime of Go resources, like functions exposed to JS, to the lifetime of JS objects
+// such as DOM nodes. Note that browsers give no guarantees when, or if at all, cleanup functions are called.
+// Cleanup functions are called from JS callbacks, thus they must not block.
+type FinalizationRegistry struct {
+	v  Value
+	cb Func
+
+	mu      sync.Mutex
+	last    uint64
+	cleanup map[uint64]finalizer
+}
+
+type finalizer struct {
+	token Value
+	fnc   func()
+}
+
+// NewFinalizationRegistry creates a new finalization registry.
+// Registry must be released when it is no longer needed. See Release.
+func NewFinalizationRegistry() *FinalizationRegistry {
+	r := &FinalizationRegistry{cleanup: make(map[uint64]finalizer)}
+	r.cb = FuncOf(func(this Value, args []Value) interface{} {
+		id := uint64(args[0].Int())
+		r.mu.Lock()
+		f, ok := r.cleanup[id]
+		delete(r.cleanup, id)
+		r.mu.Unlock()
+		if ok {
+			f.fnc()
+		}
+		return nil
+	})
+	r.v = New("FinalizationRegistry", r.cb)
+	return r
+}
+
+// JSValue implements Wrapper interface.
+func (r *FinalizationRegistry) JSValue() Ref {
+	return r.v.JSValue()
+}
+
+// Register schedules fnc to be called after the target object is garbage collected.
+// The returned function cancels the registration. It is safe to call it multiple times.
+func (r *FinalizationRegistry) Register(target Wrapper, fnc func()) (cancel func()) {
+	token := NewObject()
+	r.mu.Lock()
+	if r.cleanup == nil {
+		r.mu.Unlock()
+		panic("js: Register called on released FinalizationRegistry")
+	}
+	r.last++
+	id := r.last
+	r.cleanup[id] = finalizer{token: token, fnc: fnc}
+	r.mu.Unlock()
+	r.v.Call("register", target, id, token)
+	return func() {
+		r.mu.Lock()
+		_, ok := r.cleanup[id]
+		delete(r.cleanup, id)
+		r.mu.Unlock()
+		if ok {
+			r.v.Call("unregister", token)
+		}
+	}
+}
+
+// Len returns the number of pending registrations.
+func (r *FinalizationRegistry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.cleanup)
+}
+
+// Release cancels all pending registrations and releases the registry callback.
+// Cleanup functions of pending registrations are not called.
+func (r *FinalizationRegistry) Release() {
+	r.mu.Lock()
+	cleanup := r.cleanup
+	r.cleanup = nil
+	r.mu.Unlock()
+	for _, f := range cleanup {
+		r.v.Call("unregister", f.token)
+	}
+	r.cb.Release()
+}
+
+var (
+	defaultFinalizers     *FinalizationRegistry
+	defaultFinalizersOnce sync.Once
+)
+
+// OnCollect schedules fnc to be called after the target object is garbage collected.
+// The returned function cancels the registration. It uses a shared FinalizationRegistry.
+func OnCollect(target Wrapper, fnc func()) (cancel func()) {
+	defaultFinalizersOnce.Do(func() {
+		defaultFinalizers = NewFinalizationRegistry()
+	})
+	return defaultFinalizers.Register(target, fnc)
+}
+
+// ReleaseOnCollect releases given functions after the target object is garbage collected.
+// The returned function cancels the registration without releasing the functions.
+func ReleaseOnCollect(target Wrapper, funcs ...Func) (cancel func()) {
+	return OnCollect(target, func() {
+		for _, f := range funcs {
+			f.Release()
+		}
+	})
+}
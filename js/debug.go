@@ -0,0 +1,120 @@
+package js
+
+import (
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FuncInfo describes a function that was created with FuncOf or CallbackOf, but was not released yet.
+type FuncInfo struct {
+	ID      uint64
+	Created time.Time
+	// Stack is a stack trace of the goroutine that created the function.
+	Stack string
+}
+
+var funcTrack struct {
+	last  uint64 // atomic
+	count int64  // atomic, number of live functions
+	debug int32  // atomic
+
+	mu   sync.Mutex
+	live map[uint64]*FuncInfo // only functions created in debug mode
+}
+
+// trackedFunc is a tracking state of a single function.
+type trackedFunc struct {
+	id       uint64
+	debug    bool  // function is in the live map
+	released int32 // atomic
+}
+
+// SetDebug enables or disables the debug mode.
+//
+// In debug mode, the creation time and a stack trace are recorded for each function created with FuncOf
+// or CallbackOf, so LiveFuncs can report where leaked functions were created. Otherwise only the number
+// of live functions is tracked.
+func SetDebug(enabled bool) {
+	v := int32(0)
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&funcTrack.debug, v)
+}
+
+// LiveFuncCount returns the number of Go functions exposed to JS that were not released yet.
+func LiveFuncCount() int {
+	return int(atomic.LoadInt64(&funcTrack.count))
+}
+
+// LiveFuncs returns the list of Go functions exposed to JS that were not released yet, ordered by creation time.
+//
+// Only functions created while debug mode was enabled are listed. See SetDebug.
+func LiveFuncs() []FuncInfo {
+	funcTrack.mu.Lock()
+	out := make([]FuncInfo, 0, len(funcTrack.live))
+	for _, f := range funcTrack.live {
+		out = append(out, *f)
+	}
+	funcTrack.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// trackFunc registers a new function. It only updates atomic counters, unless debug mode is enabled.
+func trackFunc() *trackedFunc {
+	t := &trackedFunc{id: atomic.AddUint64(&funcTrack.last, 1)}
+	atomic.AddInt64(&funcTrack.count, 1)
+	if atomic.LoadInt32(&funcTrack.debug) == 0 {
+		return t
+	}
+	t.debug = true
+	f := &FuncInfo{ID: t.id, Created: time.Now(), Stack: callerStack(3)}
+	funcTrack.mu.Lock()
+	if funcTrack.live == nil {
+		funcTrack.live = make(map[uint64]*FuncInfo)
+	}
+	funcTrack.live[t.id] = f
+	funcTrack.mu.Unlock()
+	return t
+}
+
+// untrackFunc marks the function as released. It's safe to call it multiple times.
+func untrackFunc(t *trackedFunc) {
+	if t == nil || !atomic.CompareAndSwapInt32(&t.released, 0, 1) {
+		return
+	}
+	atomic.AddInt64(&funcTrack.count, -1)
+	if t.debug {
+		funcTrack.mu.Lock()
+		delete(funcTrack.live, t.id)
+		funcTrack.mu.Unlock()
+	}
+}
+
+// callerStack returns a stack trace of the current goroutine, skipping a given number of frames.
+// Frames of this package are skipped as well, except for the test files.
+func callerStack(skip int) string {
+	pc := make([]uintptr, 32)
+	pc = pc[:runtime.Callers(skip, pc)]
+	frames := runtime.CallersFrames(pc)
+	const pkg = "github.com/dennwc/dom/js."
+	var buf strings.Builder
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, pkg) || strings.HasSuffix(f.File, "_test.go") {
+			buf.WriteString(f.Function + "\n\t" + f.File + ":" + strconv.Itoa(f.Line) + "\n")
+		}
+		if !more {
+			break
+		}
+	}
+	return buf.String()
+}
@@ -1,2 +1,6 @@
 // Package JS provides additional functionality on top of syscall/js package for WASM.
+//
+// On other platforms the package uses an in-memory emulation of a subset of JavaScript (objects, arrays,
+// functions, promises, timers, typed arrays, JSON, etc). It allows to unit test code that uses this package
+// with a regular "go test", without a browser. Functions that require a JS parser, like NativeFuncOf, are not supported.
 package js
@@ -0,0 +1,225 @@
+package js
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Error is a Go error that wraps a JavaScript exception, or any other JS value used as an error.
+//
+// Values thrown by JS code are returned as Error by Try functions and promises. DOMException names are mapped
+// to sentinel errors like ErrAbort, so errors.Is can be used to check them. The JS value itself can be accessed
+// by using errors.As with Error as a target.
+type Error struct {
+	Value Ref
+}
+
+// Sentinel errors that correspond to DOMException names.
+var (
+	ErrAbort         = errors.New("js: operation aborted")
+	ErrTimeout       = errors.New("js: operation timed out")
+	ErrNotAllowed    = errors.New("js: operation not allowed")
+	ErrNotSupported  = errors.New("js: operation not supported")
+	ErrNotFound      = errors.New("js: object not found")
+	ErrInvalidState  = errors.New("js: invalid state")
+	ErrQuotaExceeded = errors.New("js: quota exceeded")
+	ErrNetwork       = errors.New("js: network error")
+	ErrSecurity      = errors.New("js: security error")
+	ErrSyntax        = errors.New("js: syntax error")
+	ErrDataClone     = errors.New("js: object cannot be cloned")
+)
+
+// domErrors maps DOMException names to sentinel errors. It's a list rather than a map, thus an error that matches
+// multiple sentinels is always converted to the same DOMException.
+var domErrors = []struct {
+	name string
+	err  error
+}{
+	{"AbortError", ErrAbort},
+	{"TimeoutError", ErrTimeout},
+	{"NotAllowedError", ErrNotAllowed},
+	{"NotSupportedError", ErrNotSupported},
+	{"NotFoundError", ErrNotFound},
+	{"InvalidStateError", ErrInvalidState},
+	{"QuotaExceededError", ErrQuotaExceeded},
+	{"NetworkError", ErrNetwork},
+	{"SecurityError", ErrSecurity},
+	{"SyntaxError", ErrSyntax},
+	{"DataCloneError", ErrDataClone},
+}
+
+// NewDOMException creates a new JavaScript DOMException with a given message and name.
+// If DOMException is not supported by the environment, it creates an Error with a corresponding name.
+func NewDOMException(msg, name string) Value {
+	if cl := Class("DOMException"); cl.Valid() {
+		return cl.New(msg, name)
+	}
+	e := New("Error", msg)
+	e.Set("name", name)
+	return e
+}
+
+func (e Error) value() Value {
+	return Value{e.Value}
+}
+
+// JSValue implements Wrapper interface.
+func (e Error) JSValue() Ref {
+	return e.Value
+}
+
+// Error implements the error interface.
+func (e Error) Error() string {
+	msg := e.Message()
+	if msg == "" && e.value().isObject() && !isEvent(e.value()) {
+		// same as String(e.message) in JS
+		msg = e.value().Get("message").Ref.String()
+	}
+	return "JavaScript error: " + msg
+}
+
+// Name returns the name of JS error, for example "TypeError" or "AbortError".
+// For events it returns the name of the event class.
+func (e Error) Name() string {
+	v := e.value()
+	if !v.isObject() {
+		return ""
+	}
+	if isEvent(v) {
+		return className(v)
+	}
+	return stringProp(v, "name")
+}
+
+// Message returns a message of JS error. For primitive values it returns the value converted to a string,
+// and for events it returns a description of the event.
+func (e Error) Message() string {
+	v := e.value()
+	switch {
+	case v.isZero():
+		return ""
+	case isEvent(v):
+		return eventMessage(v)
+	case v.isObject():
+		return stringProp(v, "message")
+	}
+	return v.Ref.String()
+}
+
+// Stack returns a stack trace of JS error, if it is available.
+func (e Error) Stack() string {
+	v := e.value()
+	if !v.isObject() {
+		return ""
+	}
+	return stringProp(v, "stack")
+}
+
+// Code returns a numeric code of the error. It is set for DOMException and CloseEvent values.
+// Zero is returned if there is no code.
+func (e Error) Code() int {
+	v := e.value()
+	if !v.isObject() {
+		return 0
+	}
+	if c := v.Get("code"); c.Type() == TypeNumber {
+		return c.Int()
+	}
+	return 0
+}
+
+// Cause returns an error that caused this error, as set by the "cause" option of Error constructor.
+// For ErrorEvent it returns the error that was thrown.
+func (e Error) Cause() error {
+	v := e.value()
+	if !v.isObject() {
+		return nil
+	}
+	name := "cause"
+	if isEvent(v) {
+		name = "error"
+	}
+	c := v.Get(name)
+	if !c.Valid() {
+		return nil
+	}
+	return Error{Value: c.Ref}
+}
+
+// Unwrap is the same as Cause. It allows errors.Is and errors.As to inspect the chain of causes.
+func (e Error) Unwrap() error {
+	return e.Cause()
+}
+
+// Is reports if the error corresponds to a given sentinel error, like ErrAbort.
+func (e Error) Is(target error) bool {
+	name := e.Name()
+	for _, d := range domErrors {
+		if d.name == name {
+			return d.err == target
+		}
+	}
+	return false
+}
+
+// isError checks if err, or any error it wraps, matches a given target. It works the same way as errors.Is,
+// which is not available in Go 1.12.
+func isError(err, target error) bool {
+	for err != nil {
+		if err == target {
+			return true
+		}
+		if e, ok := err.(interface{ Is(error) bool }); ok && e.Is(target) {
+			return true
+		}
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return false
+		}
+		err = u.Unwrap()
+	}
+	return false
+}
+
+func stringProp(v Value, name string) string {
+	if p := v.Get(name); p.Type() == TypeString {
+		return p.String()
+	}
+	return ""
+}
+
+// className returns the name of the constructor of a JS object.
+func className(v Value) string {
+	c := v.Get("constructor")
+	if !c.isObject() {
+		return ""
+	}
+	return stringProp(c, "name")
+}
+
+// isEvent checks if the value is an instance of Event.
+func isEvent(v Value) bool {
+	return isInstanceOf(v, "Event")
+}
+
+// eventMessage returns a readable description of an Event used as an error.
+func eventMessage(v Value) string {
+	if m := stringProp(v, "message"); m != "" {
+		// ErrorEvent
+		return m
+	}
+	s := stringProp(v, "type") + " event"
+	if t := v.Get("target"); t.isObject() {
+		if name := className(t); name != "" {
+			s += " on " + name
+		}
+	}
+	if c := v.Get("code"); c.Type() == TypeNumber {
+		// CloseEvent
+		s += ": code " + strconv.Itoa(c.Int())
+		if r := stringProp(v, "reason"); r != "" {
+			s += ", reason: " + r
+		}
+	}
+	return s
+}
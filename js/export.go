@@ -0,0 +1,176 @@
+package js
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	exportMu sync.Mutex
+	exports  = make(map[string]Func)
+
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+var (
+	throwShim     Value
+	throwShimOnce sync.Once
+)
+
+// Export exposes a Go function fnc to JavaScript as a global function with a given name.
+//
+// JavaScript arguments are decoded into parameters of the function with Unmarshal. Missing arguments are
+// set to zero values, and variadic functions receive all the remaining arguments.
+// Results of the function are converted back with Marshal: a single result is returned as-is,
+// while multiple results are returned as an array. If the last result is a non-nil error,
+// it is thrown as a JavaScript Error instead. Panics are thrown the same way.
+//
+// If the first parameter of the function is a context.Context, the function is called asynchronously
+// on a separate goroutine, and JavaScript receives a Promise that is resolved with the results or rejected
+// with the error, similar to NewPromiseContext.
+//
+// Exporting a different function with the same name releases the previous one.
+// Unexport must be called to free up resources when the function will not be used any more.
+func Export(name string, fnc interface{}) error {
+	v, f, err := exportFunc(fnc)
+	if err != nil {
+		return err
+	}
+	exportMu.Lock()
+	old, ok := exports[name]
+	exports[name] = f
+	Set(name, v)
+	exportMu.Unlock()
+	if ok {
+		old.Release()
+	}
+	return nil
+}
+
+// Unexport removes a global function exported by Export and releases its resources.
+func Unexport(name string) {
+	exportMu.Lock()
+	f, ok := exports[name]
+	if ok {
+		delete(exports, name)
+		Set(name, undefined)
+	}
+	exportMu.Unlock()
+	if ok {
+		f.Release()
+	}
+}
+
+// exportFunc wraps a Go function according to the rules of Export.
+// It returns a JS function that should be exposed and a Go callback that should be released.
+func exportFunc(fnc interface{}) (Value, Func, error) {
+	rv := reflect.ValueOf(fnc)
+	if rv.Kind() != reflect.Func || rv.IsNil() {
+		return Value{}, Func{}, fmt.Errorf("js: expected a function, got %T", fnc)
+	}
+	rt := rv.Type()
+	async := rt.NumIn() != 0 && rt.In(0) == contextType
+	if !async {
+		f := FuncOf(func(_ Value, args []Value) interface{} {
+			res, err := callExported(context.Background(), rv, args, false)
+			if err != nil {
+				return Obj{"error": errorValue(err)}
+			}
+			return Obj{"value": res}
+		})
+		throwShimOnce.Do(initThrowShim)
+		return throwShim.Invoke(f), f, nil
+	}
+	f := FuncOf(func(_ Value, args []Value) interface{} {
+		return NewPromiseContext(context.Background(), func(ctx context.Context) ([]interface{}, error) {
+			res, err := callExported(ctx, rv, args, true)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{res}, nil
+		})
+	})
+	return Value{f.Value}, f, nil
+}
+
+// callExported decodes arguments, calls an exported function and converts its results.
+func callExported(ctx context.Context, fnc reflect.Value, args []Value, withCtx bool) (_ Value, gerr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				gerr = e
+			} else {
+				gerr = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+	rt := fnc.Type()
+	in := make([]reflect.Value, 0, rt.NumIn())
+	if withCtx {
+		in = append(in, reflect.ValueOf(ctx))
+	}
+	// index of the first JS argument in the list of Go arguments
+	off := len(in)
+	n := rt.NumIn()
+	if rt.IsVariadic() {
+		n--
+	}
+	for i := len(in); i < n; i++ {
+		p := reflect.New(rt.In(i))
+		if len(args) != 0 {
+			if err := Unmarshal(args[0], p.Interface()); err != nil {
+				return Value{}, fmt.Errorf("argument %d: %v", i-off, err)
+			}
+			args = args[1:]
+		}
+		in = append(in, p.Elem())
+	}
+	if rt.IsVariadic() {
+		et := rt.In(n).Elem()
+		for i, a := range args {
+			p := reflect.New(et)
+			if err := Unmarshal(a, p.Interface()); err != nil {
+				return Value{}, fmt.Errorf("argument %d: %v", n-off+i, err)
+			}
+			in = append(in, p.Elem())
+		}
+	}
+	out := fnc.Call(in)
+	if k := len(out); k != 0 && rt.Out(k-1) == errorType {
+		if err, _ := out[k-1].Interface().(error); err != nil {
+			return Value{}, err
+		}
+		out = out[:k-1]
+	}
+	switch len(out) {
+	case 0:
+		return Value{undefined}, nil
+	case 1:
+		return marshalValue(out[0])
+	}
+	arr := Array().New(len(out))
+	for i, o := range out {
+		v, err := marshalValue(o)
+		if err != nil {
+			return Value{}, err
+		}
+		arr.SetIndex(i, v)
+	}
+	return arr, nil
+}
+
+// errorValue converts a Go error to a JS value that can be thrown or used to reject a promise.
+func errorValue(err error) Value {
+	if w, ok := err.(Wrapper); ok {
+		return Value{w.JSValue()}
+	}
+	for _, d := range domErrors {
+		if isError(err, d.err) {
+			return NewDOMException(err.Error(), d.name)
+		}
+	}
+	return New("Error", err.Error())
+}
@@ -0,0 +1,157 @@
+package js
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	type point struct {
+		X int `js:"x"`
+		Y int `js:"y"`
+	}
+	err := Export("goAdd", func(a point, b point) point {
+		return point{X: a.X + b.X, Y: a.Y + b.Y}
+	})
+	require.NoError(t, err)
+	defer Unexport("goAdd")
+
+	v := Get("goAdd").Invoke(Obj{"x": 1, "y": 2}, Obj{"x": 3, "y": 4})
+	require.Equal(t, 4, v.Get("x").Int())
+	require.Equal(t, 6, v.Get("y").Int())
+}
+
+func TestExportVariadic(t *testing.T) {
+	err := Export("goSum", func(pref string, vals ...int) (string, int) {
+		s := 0
+		for _, v := range vals {
+			s += v
+		}
+		return pref, s
+	})
+	require.NoError(t, err)
+	defer Unexport("goSum")
+
+	v := Get("goSum").Invoke("sum", 1, 2, 3)
+	require.Equal(t, "sum", v.Index(0).String())
+	require.Equal(t, 6, v.Index(1).Int())
+}
+
+func TestExportError(t *testing.T) {
+	err := Export("goFail", func(fail bool) (int, error) {
+		if fail {
+			return 0, errors.New("failed")
+		}
+		return 1, nil
+	})
+	require.NoError(t, err)
+	defer Unexport("goFail")
+
+	f := Get("goFail")
+	message := func(arg interface{}) string {
+		_, err := f.TryInvoke(arg)
+		require.NotNil(t, err)
+		return err.(Error).Message()
+	}
+	require.Equal(t, 1, f.Invoke(false).Int())
+	require.Equal(t, "failed", message(true))
+	require.Equal(t, "argument 0: js: cannot unmarshal string into Go value of type bool", message("x"))
+}
+
+func TestExportDOMError(t *testing.T) {
+	err := Export("goAbort", func() error {
+		return wrappedError{msg: "request: " + ErrAbort.Error(), err: ErrAbort}
+	})
+	require.NoError(t, err)
+	defer Unexport("goAbort")
+
+	_, err = Get("goAbort").TryInvoke()
+	require.NotNil(t, err)
+	require.Equal(t, "AbortError", err.(Error).Name())
+	require.True(t, isError(err, ErrAbort))
+
+	// the first matching sentinel in domErrors wins, regardless of the order of wrapped errors
+	err = Export("goTimeout", func() error {
+		return multiError{ErrTimeout, ErrAbort}
+	})
+	require.NoError(t, err)
+	defer Unexport("goTimeout")
+
+	for i := 0; i < 10; i++ {
+		_, err = Get("goTimeout").TryInvoke()
+		require.Equal(t, "AbortError", err.(Error).Name())
+	}
+}
+
+// multiError matches any of the errors in the list.
+type multiError []error
+
+func (e multiError) Error() string {
+	return "multiple errors"
+}
+
+func (e multiError) Is(target error) bool {
+	for _, err := range e {
+		if err == target {
+			return true
+		}
+	}
+	return false
+}
+
+// wrappedError is an error with a cause, as created by fmt.Errorf with %w in Go 1.13.
+type wrappedError struct {
+	msg string
+	err error
+}
+
+func (e wrappedError) Error() string {
+	return e.msg
+}
+
+func (e wrappedError) Unwrap() error {
+	return e.err
+}
+
+func TestExportAsync(t *testing.T) {
+	err := Export("goAsync", func(ctx context.Context, fail bool) (string, error) {
+		time.Sleep(time.Millisecond)
+		if fail {
+			return "", errors.New("failed")
+		}
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	defer Unexport("goAsync")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	f := Get("goAsync")
+	res, err := f.Invoke(false).Promised().AwaitContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "ok", res[0].String())
+
+	_, err = f.Invoke(true).Promised().AwaitContext(ctx)
+	require.NotNil(t, err)
+	require.Equal(t, "JavaScript error: failed", err.Error())
+
+	_, err = f.Invoke("x").Promised().AwaitContext(ctx)
+	require.NotNil(t, err)
+	require.Equal(t, "argument 0: js: cannot unmarshal string into Go value of type bool", err.(Error).Message())
+}
+
+func TestUnexport(t *testing.T) {
+	err := Export("goNop", func() {})
+	require.NoError(t, err)
+	require.Equal(t, TypeFunction, Get("goNop").Type())
+	Unexport("goNop")
+	require.True(t, Get("goNop").IsUndefined())
+
+	err = Export("goNop", 1)
+	require.NotNil(t, err)
+}
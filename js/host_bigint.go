@@ -0,0 +1,93 @@
+//+build !wasm
+
+package js
+
+import (
+	"math"
+	"math/big"
+	"strings"
+)
+
+// jsBigInt is an emulated JS BigInt value. It stores a decimal representation of the number,
+// so equal values can be compared with "==".
+type jsBigInt string
+
+var bigintProto *jsObject
+
+// newBigInt normalizes a big integer to jsBigInt.
+func newBigInt(x *big.Int) jsBigInt {
+	return jsBigInt(x.String())
+}
+
+func (b jsBigInt) int() *big.Int {
+	x, _ := new(big.Int).SetString(string(b), 10)
+	return x
+}
+
+func (b jsBigInt) float() float64 {
+	f, _ := new(big.Float).SetInt(b.int()).Float64()
+	return f
+}
+
+// toBigInt implements JS ToBigInt conversion used by BigInt function.
+func toBigInt(v Ref) jsBigInt {
+	switch x := v.v.(type) {
+	case jsBigInt:
+		return x
+	case bool:
+		if x {
+			return "1"
+		}
+		return "0"
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) || x != math.Trunc(x) {
+			throwError(rangeErrorProto, "The number "+formatNumber(x)+" cannot be converted to a BigInt because it is not an integer")
+		}
+		i, _ := big.NewFloat(x).Int(nil)
+		return newBigInt(i)
+	case string:
+		s := strings.TrimSpace(x)
+		if s == "" {
+			return "0"
+		}
+		if i, ok := new(big.Int).SetString(s, 0); ok {
+			return newBigInt(i)
+		}
+		throwError(syntaxErrorProto, "Cannot convert "+x+" to a BigInt")
+	case *jsObject:
+		return toBigInt(Ref{toString(v)})
+	}
+	throwTypeError("Cannot convert " + toString(v) + " to a BigInt")
+	return ""
+}
+
+func setupBigInt(g *jsObject) {
+	bigintProto = newObject(objectProto)
+	p := bigintProto
+	thisBigInt := func(v Ref) jsBigInt {
+		b, ok := v.v.(jsBigInt)
+		if !ok {
+			throwTypeError("BigInt.prototype method called on incompatible receiver " + toString(v))
+		}
+		return b
+	}
+	p.defineMethod("toString", 0, func(v Ref, args []Ref) Ref {
+		b := thisBigInt(v)
+		if r := arg(args, 0); r.v != nil {
+			return Ref{b.int().Text(toInt(r, 10))}
+		}
+		return Ref{string(b)}
+	})
+	p.defineMethod("valueOf", 0, func(v Ref, args []Ref) Ref {
+		return Ref{thisBigInt(v)}
+	})
+	p.define(symbolToStringTag.key, Ref{"BigInt"}, false)
+
+	c := newClass("BigInt", 1, p, func(this Ref, args []Ref) Ref {
+		return Ref{toBigInt(arg(args, 0))}
+	}, func(args []Ref) *jsObject {
+		throwTypeError("BigInt is not a constructor")
+		return nil
+	})
+	g.define("BigInt", Ref{c}, false)
+}
@@ -0,0 +1,458 @@
+//+build !wasm
+
+package js
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// arrayBuffer is an internal state of an ArrayBuffer object.
+type arrayBuffer struct {
+	data     []byte
+	detached bool
+}
+
+// detach implements detaching of transferred buffers. Views of the buffer become empty on the next access.
+func (b *arrayBuffer) detach() {
+	b.data = nil
+	b.detached = true
+}
+
+// typedArray is an internal state of a typed array object.
+type typedArray struct {
+	buf  *arrayBuffer
+	kind *typedArrayKind
+	off  int // offset in bytes
+	n    int // length in elements
+}
+
+// sync resets the view if its buffer was detached.
+func (a *typedArray) sync() {
+	if a.buf.detached {
+		a.off, a.n = 0, 0
+	}
+}
+
+func (a *typedArray) bytes() []byte {
+	sz := a.kind.size
+	return a.buf.data[a.off : a.off+a.n*sz]
+}
+
+func (a *typedArray) get(i int) Ref {
+	sz := a.kind.size
+	return Ref{a.kind.get(a.buf.data[a.off+i*sz:])}
+}
+
+func (a *typedArray) set(i int, v float64) {
+	sz := a.kind.size
+	a.kind.set(a.buf.data[a.off+i*sz:], v)
+}
+
+// typedArrayKind describes an element type of a typed array.
+type typedArrayKind struct {
+	name string
+	size int
+	get  func(p []byte) float64
+	set  func(p []byte, v float64)
+}
+
+// toUint32 implements the JS ToUint32 conversion (modulo 2^32).
+func toUint32(v float64) uint32 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	v = math.Mod(math.Trunc(v), 1<<32)
+	if v < 0 {
+		v += 1 << 32
+	}
+	return uint32(v)
+}
+
+var le = binary.LittleEndian
+
+var typedArrayKinds = []*typedArrayKind{
+	{name: "Int8Array", size: 1,
+		get: func(p []byte) float64 { return float64(int8(p[0])) },
+		set: func(p []byte, v float64) { p[0] = byte(toUint32(v)) },
+	},
+	{name: "Uint8Array", size: 1,
+		get: func(p []byte) float64 { return float64(p[0]) },
+		set: func(p []byte, v float64) { p[0] = byte(toUint32(v)) },
+	},
+	{name: "Uint8ClampedArray", size: 1,
+		get: func(p []byte) float64 { return float64(p[0]) },
+		set: func(p []byte, v float64) {
+			switch {
+			case !(v > 0):
+				p[0] = 0
+			case v > 255:
+				p[0] = 255
+			default:
+				p[0] = byte(math.RoundToEven(v))
+			}
+		},
+	},
+	{name: "Int16Array", size: 2,
+		get: func(p []byte) float64 { return float64(int16(le.Uint16(p))) },
+		set: func(p []byte, v float64) { le.PutUint16(p, uint16(toUint32(v))) },
+	},
+	{name: "Uint16Array", size: 2,
+		get: func(p []byte) float64 { return float64(le.Uint16(p)) },
+		set: func(p []byte, v float64) { le.PutUint16(p, uint16(toUint32(v))) },
+	},
+	{name: "Int32Array", size: 4,
+		get: func(p []byte) float64 { return float64(int32(le.Uint32(p))) },
+		set: func(p []byte, v float64) { le.PutUint32(p, toUint32(v)) },
+	},
+	{name: "Uint32Array", size: 4,
+		get: func(p []byte) float64 { return float64(le.Uint32(p)) },
+		set: func(p []byte, v float64) { le.PutUint32(p, toUint32(v)) },
+	},
+	{name: "Float32Array", size: 4,
+		get: func(p []byte) float64 { return float64(math.Float32frombits(le.Uint32(p))) },
+		set: func(p []byte, v float64) { le.PutUint32(p, math.Float32bits(float32(v))) },
+	},
+	{name: "Float64Array", size: 8,
+		get: func(p []byte) float64 { return math.Float64frombits(le.Uint64(p)) },
+		set: func(p []byte, v float64) { le.PutUint64(p, math.Float64bits(v)) },
+	},
+}
+
+var (
+	arrayBufferProto  *jsObject
+	typedArrayClasses = make(map[string]*jsObject)
+)
+
+// newArrayBuffer creates an ArrayBuffer object that uses a given slice as a storage.
+func newArrayBuffer(data []byte) *jsObject {
+	b := newObject(arrayBufferProto)
+	b.class = "ArrayBuffer"
+	b.data = &arrayBuffer{data: data}
+	return b
+}
+
+// newTypedArray creates a typed array view of a buffer.
+func newTypedArray(kind *typedArrayKind, buf *jsObject, off, n int) *jsObject {
+	class := typedArrayClasses[kind.name]
+	a := newObject(getProp(Ref{class}, "prototype").v.(*jsObject))
+	a.class = kind.name
+	a.data = &typedArray{buf: buf.data.(*arrayBuffer), kind: kind, off: off, n: n}
+	a.define("buffer", Ref{buf}, false)
+	return a
+}
+
+func bufferThis(v Ref) *arrayBuffer {
+	if o, ok := v.v.(*jsObject); ok {
+		if b, ok := o.data.(*arrayBuffer); ok {
+			return b
+		}
+	}
+	throwTypeError("Method ArrayBuffer.prototype method called on incompatible receiver " + toString(v))
+	return nil
+}
+
+func typedArrayThis(v Ref) *typedArray {
+	if o, ok := v.v.(*jsObject); ok {
+		if a, ok := o.data.(*typedArray); ok {
+			a.sync()
+			return a
+		}
+	}
+	throwTypeError("this is not a typed array.")
+	return nil
+}
+
+func setupBuffers(g *jsObject) {
+	arrayBufferProto = newObject(objectProto)
+	bp := arrayBufferProto
+	bp.define("@@toStringTag", Ref{"ArrayBuffer"}, false)
+	bp.defineGetter("byteLength", func(this Ref, args []Ref) Ref {
+		return Ref{float64(len(bufferThis(this).data))}
+	}, nil)
+	bp.defineMethod("slice", 2, func(this Ref, args []Ref) Ref {
+		b := bufferThis(this)
+		s := relIndex(arg(args, 0), len(b.data), 0)
+		e := relIndex(arg(args, 1), len(b.data), len(b.data))
+		if e < s {
+			e = s
+		}
+		return Ref{newArrayBuffer(append([]byte{}, b.data[s:e]...))}
+	})
+	bc := newClass("ArrayBuffer", 1, bp, nil, func(args []Ref) *jsObject {
+		n := toNumber(arg(args, 0))
+		if math.IsNaN(n) {
+			n = 0
+		}
+		if n < 0 || n > math.MaxInt32 {
+			throwError(rangeErrorProto, "Array buffer allocation failed")
+		}
+		return newArrayBuffer(make([]byte, int(n)))
+	})
+	bc.defineMethod("isView", 1, func(this Ref, args []Ref) Ref {
+		o, ok := arg(args, 0).v.(*jsObject)
+		if !ok {
+			return Ref{false}
+		}
+		switch o.data.(type) {
+		case *typedArray, *dataView:
+			return Ref{true}
+		}
+		return Ref{false}
+	})
+	g.define("ArrayBuffer", Ref{bc}, false)
+
+	// %TypedArray%.prototype
+	tp := newObject(objectProto)
+	tp.defineGetter("length", func(this Ref, args []Ref) Ref {
+		return Ref{float64(typedArrayThis(this).n)}
+	}, nil)
+	tp.defineGetter("byteLength", func(this Ref, args []Ref) Ref {
+		a := typedArrayThis(this)
+		return Ref{float64(a.n * a.kind.size)}
+	}, nil)
+	tp.defineGetter("byteOffset", func(this Ref, args []Ref) Ref {
+		return Ref{float64(typedArrayThis(this).off)}
+	}, nil)
+	tp.defineGetter("@@toStringTag", func(this Ref, args []Ref) Ref {
+		if o, ok := this.v.(*jsObject); ok {
+			if a, ok := o.data.(*typedArray); ok {
+				return Ref{a.kind.name}
+			}
+		}
+		return undefined
+	}, nil)
+	tp.defineMethod("set", 2, func(this Ref, args []Ref) Ref {
+		a := typedArrayThis(this)
+		off := toInt(arg(args, 1), 0)
+		if off < 0 {
+			throwError(rangeErrorProto, "offset is out of bounds")
+		}
+		if src, ok := arg(args, 0).v.(*jsObject); ok {
+			if sa, ok := src.data.(*typedArray); ok && sa.kind == a.kind {
+				sa.sync()
+				if off+sa.n > a.n {
+					throwError(rangeErrorProto, "offset is out of bounds")
+				}
+				copy(a.bytes()[off*a.kind.size:], sa.bytes())
+				return undefined
+			}
+		}
+		vals := listFromArrayLike(arg(args, 0))
+		if off+len(vals) > a.n {
+			throwError(rangeErrorProto, "offset is out of bounds")
+		}
+		for i, v := range vals {
+			a.set(off+i, toNumber(v))
+		}
+		return undefined
+	})
+	tp.defineMethod("subarray", 2, func(this Ref, args []Ref) Ref {
+		a := typedArrayThis(this)
+		s := relIndex(arg(args, 0), a.n, 0)
+		e := relIndex(arg(args, 1), a.n, a.n)
+		if e < s {
+			e = s
+		}
+		buf := getProp(this, "buffer").v.(*jsObject)
+		return Ref{newTypedArray(a.kind, buf, a.off+s*a.kind.size, e-s)}
+	})
+	tp.defineMethod("slice", 2, func(this Ref, args []Ref) Ref {
+		a := typedArrayThis(this)
+		s := relIndex(arg(args, 0), a.n, 0)
+		e := relIndex(arg(args, 1), a.n, a.n)
+		if e < s {
+			e = s
+		}
+		sz := a.kind.size
+		data := append([]byte{}, a.bytes()[s*sz:e*sz]...)
+		return Ref{newTypedArray(a.kind, newArrayBuffer(data), 0, e-s)}
+	})
+	tp.defineMethod("fill", 3, func(this Ref, args []Ref) Ref {
+		a := typedArrayThis(this)
+		v := toNumber(arg(args, 0))
+		s := relIndex(arg(args, 1), a.n, 0)
+		e := relIndex(arg(args, 2), a.n, a.n)
+		for i := s; i < e; i++ {
+			a.set(i, v)
+		}
+		return this
+	})
+	tp.defineMethod("indexOf", 1, func(this Ref, args []Ref) Ref {
+		a := typedArrayThis(this)
+		for i := relIndex(arg(args, 1), a.n, 0); i < a.n; i++ {
+			if a.get(i) == arg(args, 0) {
+				return Ref{float64(i)}
+			}
+		}
+		return Ref{float64(-1)}
+	})
+	tp.defineMethod("join", 1, func(this Ref, args []Ref) Ref {
+		return callFunc(getProp(Ref{arrayProto}, "join"), Ref{newArray(listFromArrayLike(this))}, args)
+	})
+	tp.defineMethod("toString", 0, func(this Ref, args []Ref) Ref {
+		return callMethod(this, "join")
+	})
+	tp.defineMethod("forEach", 1, func(this Ref, args []Ref) Ref {
+		fnc := arg(args, 0)
+		for i, v := range listFromArrayLike(this) {
+			callFunc(fnc, arg(args, 1), []Ref{v, {float64(i)}, this})
+		}
+		return undefined
+	})
+
+	for _, kind := range typedArrayKinds {
+		kind := kind
+		p := newObject(tp)
+		p.define("BYTES_PER_ELEMENT", Ref{float64(kind.size)}, false)
+		c := newClass(kind.name, 3, p, nil, func(args []Ref) *jsObject {
+			switch x := arg(args, 0).v.(type) {
+			case nil, jsNull, bool, float64, string:
+				n := toNumber(arg(args, 0))
+				if math.IsNaN(n) {
+					n = 0
+				}
+				if n < 0 || n != math.Trunc(n) || n*float64(kind.size) > math.MaxInt32 {
+					throwError(rangeErrorProto, "Invalid typed array length: "+toString(arg(args, 0)))
+				}
+				return newTypedArray(kind, newArrayBuffer(make([]byte, int(n)*kind.size)), 0, int(n))
+			case *jsObject:
+				if b, ok := x.data.(*arrayBuffer); ok {
+					off := toInt(arg(args, 1), 0)
+					if off < 0 || off > len(b.data) || off%kind.size != 0 {
+						throwError(rangeErrorProto, "start offset of "+kind.name+" should be a multiple of "+toString(Ref{float64(kind.size)}))
+					}
+					var n int
+					if l := arg(args, 2); l.v != nil {
+						n = toInt(l, 0)
+						if n < 0 || off+n*kind.size > len(b.data) {
+							throwError(rangeErrorProto, "Invalid typed array length: "+toString(l))
+						}
+					} else {
+						if (len(b.data)-off)%kind.size != 0 {
+							throwError(rangeErrorProto, "byte length of "+kind.name+" should be a multiple of "+toString(Ref{float64(kind.size)}))
+						}
+						n = (len(b.data) - off) / kind.size
+					}
+					return newTypedArray(kind, x, off, n)
+				}
+				vals := listFromArrayLike(arg(args, 0))
+				a := newTypedArray(kind, newArrayBuffer(make([]byte, len(vals)*kind.size)), 0, len(vals))
+				ta := a.data.(*typedArray)
+				for i, v := range vals {
+					ta.set(i, toNumber(v))
+				}
+				return a
+			}
+			throwTypeError("invalid argument")
+			return nil
+		})
+		c.define("BYTES_PER_ELEMENT", Ref{float64(kind.size)}, false)
+		c.defineMethod("from", 1, func(this Ref, args []Ref) Ref {
+			return construct(Ref{typedArrayClasses[kind.name]}, []Ref{{newArray(listFromArrayLike(arg(args, 0)))}})
+		})
+		c.defineMethod("of", 0, func(this Ref, args []Ref) Ref {
+			return construct(Ref{typedArrayClasses[kind.name]}, []Ref{{newArray(append([]Ref{}, args...))}})
+		})
+		typedArrayClasses[kind.name] = c
+		g.define(kind.name, Ref{c}, false)
+	}
+
+	setupDataView(g)
+}
+
+// dataView is an internal state of a DataView object.
+type dataView struct {
+	buf *arrayBuffer
+	off int
+	n   int
+}
+
+func setupDataView(g *jsObject) {
+	p := newObject(objectProto)
+	p.define("@@toStringTag", Ref{"DataView"}, false)
+	this := func(v Ref) *dataView {
+		if o, ok := v.v.(*jsObject); ok {
+			if d, ok := o.data.(*dataView); ok {
+				if d.buf.detached {
+					d.off, d.n = 0, 0
+				}
+				return d
+			}
+		}
+		throwTypeError("Receiver is not a DataView")
+		return nil
+	}
+	p.defineGetter("byteLength", func(v Ref, args []Ref) Ref {
+		return Ref{float64(this(v).n)}
+	}, nil)
+	p.defineGetter("byteOffset", func(v Ref, args []Ref) Ref {
+		return Ref{float64(this(v).off)}
+	}, nil)
+	// bytesAt returns a byte slice of the view for an access of a given size
+	bytesAt := func(d *dataView, off Ref, size int) []byte {
+		i := toInt(off, 0)
+		if i < 0 || i+size > d.n {
+			throwError(rangeErrorProto, "Offset is outside the bounds of the DataView")
+		}
+		return d.buf.data[d.off+i : d.off+i+size]
+	}
+	for _, kind := range typedArrayKinds {
+		if kind.name == "Uint8ClampedArray" {
+			continue
+		}
+		kind := kind
+		name := kind.name[:len(kind.name)-len("Array")]
+		p.defineMethod("get"+name, 1, func(v Ref, args []Ref) Ref {
+			buf := append([]byte{}, bytesAt(this(v), arg(args, 0), kind.size)...)
+			if !arg(args, 1).Truthy() {
+				// big endian is the default
+				reverse(buf)
+			}
+			return Ref{kind.get(buf)}
+		})
+		p.defineMethod("set"+name, 2, func(v Ref, args []Ref) Ref {
+			dst := bytesAt(this(v), arg(args, 0), kind.size)
+			buf := make([]byte, kind.size)
+			kind.set(buf, toNumber(arg(args, 1)))
+			if !arg(args, 2).Truthy() {
+				reverse(buf)
+			}
+			copy(dst, buf)
+			return undefined
+		})
+	}
+	c := newClass("DataView", 1, p, nil, func(args []Ref) *jsObject {
+		bo, ok := arg(args, 0).v.(*jsObject)
+		var b *arrayBuffer
+		if ok {
+			b, ok = bo.data.(*arrayBuffer)
+		}
+		if !ok {
+			throwTypeError("First argument to DataView constructor must be an ArrayBuffer")
+		}
+		off := toInt(arg(args, 1), 0)
+		if off < 0 || off > len(b.data) {
+			throwError(rangeErrorProto, "Start offset "+toString(arg(args, 1))+" is outside the bounds of the buffer")
+		}
+		n := len(b.data) - off
+		if l := arg(args, 2); l.v != nil {
+			n = toInt(l, 0)
+			if n < 0 || off+n > len(b.data) {
+				throwError(rangeErrorProto, "Invalid DataView length "+toString(l))
+			}
+		}
+		o := newObject(p)
+		o.class = "DataView"
+		o.data = &dataView{buf: b, off: off, n: n}
+		o.define("buffer", Ref{bo}, false)
+		return o
+	})
+	g.define("DataView", Ref{c}, false)
+}
+
+func reverse(p []byte) {
+	for i, j := 0, len(p)-1; i < j; i, j = i+1, j-1 {
+		p[i], p[j] = p[j], p[i]
+	}
+}
@@ -0,0 +1,146 @@
+//+build !wasm
+
+package js
+
+// throwDataCloneError raises a DataCloneError DOMException.
+func throwDataCloneError(msg string) {
+	throw(Ref{newDOMException(msg, "DataCloneError")})
+}
+
+// cloner implements the structured clone algorithm.
+type cloner struct {
+	memo map[*jsObject]Ref
+}
+
+// structuredClone implements JS structuredClone function. Transferred buffers are moved to the clone
+// and detached. Other transferable objects are passed by reference, since the emulator has a single realm.
+func structuredClone(v Ref, transfer []Ref) Ref {
+	c := &cloner{memo: make(map[*jsObject]Ref)}
+	var detach []*arrayBuffer
+	for _, t := range transfer {
+		o, ok := t.v.(*jsObject)
+		if !ok {
+			throwTypeError("Failed to execute 'structuredClone': Value at index 0 does not have a transferable type.")
+		}
+		if _, dup := c.memo[o]; dup {
+			throwDataCloneError("Failed to execute 'structuredClone': Object at index 1 is a duplicate of an earlier object.")
+		}
+		if b, ok := o.data.(*arrayBuffer); ok {
+			if b.detached {
+				throwDataCloneError("Failed to execute 'structuredClone': ArrayBuffer is detached and could not be cloned.")
+			}
+			c.memo[o] = Ref{newArrayBuffer(b.data)}
+			detach = append(detach, b)
+			continue
+		}
+		// instances of emulated classes, like MessagePort, are considered transferable
+		if o.call != nil || o.data != nil || o.proto == objectProto || o.class == "Array" {
+			throwDataCloneError("Failed to execute 'structuredClone': Value at index 0 does not have a transferable type.")
+		}
+		c.memo[o] = t
+	}
+	out := c.clone(v)
+	for _, b := range detach {
+		b.detach()
+	}
+	return out
+}
+
+func (c *cloner) clone(v Ref) Ref {
+	switch x := v.v.(type) {
+	case *jsSymbol:
+		throwDataCloneError(symbolString(x) + " could not be cloned.")
+	case *jsObject:
+		return c.cloneObject(x)
+	}
+	return v
+}
+
+func (c *cloner) cloneObject(o *jsObject) Ref {
+	if r, ok := c.memo[o]; ok {
+		return r
+	}
+	if o.call != nil {
+		throwDataCloneError(toString(Ref{o}) + " could not be cloned.")
+	}
+	switch d := o.data.(type) {
+	case *arrayBuffer:
+		if d.detached {
+			throwDataCloneError("An ArrayBuffer is detached and could not be cloned.")
+		}
+		r := Ref{newArrayBuffer(append([]byte{}, d.data...))}
+		c.memo[o] = r
+		return r
+	case *typedArray:
+		d.sync()
+		buf := c.clone(getProp(Ref{o}, "buffer")).v.(*jsObject)
+		r := Ref{newTypedArray(d.kind, buf, d.off, d.n)}
+		c.memo[o] = r
+		return r
+	case *dataView:
+		buf := c.clone(getProp(Ref{o}, "buffer"))
+		r := construct(getProp(Ref{o}, "constructor"), []Ref{buf, {float64(d.off)}, {float64(d.n)}})
+		c.memo[o] = r
+		return r
+	case *dateData:
+		r := Ref{newDate(d.ms)}
+		c.memo[o] = r
+		return r
+	case *mapData:
+		n := newObject(o.proto)
+		n.class = o.class
+		nd := &mapData{}
+		n.data = nd
+		r := Ref{n}
+		c.memo[o] = r
+		next := d.iter()
+		for e := next(); e != nil; e = next() {
+			nd.set(c.clone(e.key), c.clone(e.val))
+		}
+		return r
+	case nil:
+	default:
+		throwDataCloneError(toString(Ref{o}) + " could not be cloned.")
+	}
+	switch o.class {
+	case "Array":
+		n := newArray(nil)
+		r := Ref{n}
+		c.memo[o] = r
+		for _, v := range listFromArrayLike(Ref{o}) {
+			n.arr = append(n.arr, c.clone(v))
+		}
+		return r
+	case "Error", "DOMException":
+		// errors are cloned with their prototype, since the emulator has a single realm
+		n := newObject(o.proto)
+		n.class = o.class
+		r := Ref{n}
+		c.memo[o] = r
+		for _, k := range []string{"name", "message", "stack", "code", "cause"} {
+			if p, ok := o.getOwn(k); ok && !p.isAccessor() {
+				n.define(k, c.clone(p.value), false)
+			}
+		}
+		return r
+	}
+	n := newObject(objectProto)
+	r := Ref{n}
+	c.memo[o] = r
+	for _, k := range o.ownKeys(false) {
+		n.define(k, c.clone(getProp(Ref{o}, k)), true)
+	}
+	return r
+}
+
+func setupStructuredClone(g *jsObject) {
+	g.defineMethod("structuredClone", 1, func(this Ref, args []Ref) Ref {
+		var transfer []Ref
+		if opts, ok := arg(args, 1).v.(*jsObject); ok {
+			if t := getProp(Ref{opts}, "transfer"); t.v != nil {
+				transfer = listFromIterable(t)
+			}
+		}
+		return structuredClone(arg(args, 0), transfer)
+	})
+}
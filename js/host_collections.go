@@ -0,0 +1,445 @@
+//+build !wasm
+
+package js
+
+import (
+	"math"
+	"sync"
+)
+
+var (
+	mapProto  *jsObject
+	setProto  *jsObject
+	weakProto *jsObject
+	finProto  *jsObject
+)
+
+// mapEntry is a single entry of a Map or a Set.
+type mapEntry struct {
+	key, val Ref
+	deleted  bool
+}
+
+// mapData is an internal state of Map and Set objects. Entries are kept in insertion order.
+// Deleted entries are only marked, thus iterators remain valid while the collection is modified.
+type mapData struct {
+	mu      sync.Mutex
+	index   map[Ref]*mapEntry
+	entries []*mapEntry
+}
+
+// normKey normalizes a key, so it can be used in a Go map with SameValueZero semantic.
+func normKey(k Ref) Ref {
+	if f, ok := k.v.(float64); ok {
+		if math.IsNaN(f) {
+			return Ref{math.NaN()}
+		} else if f == 0 {
+			return Ref{float64(0)}
+		}
+	}
+	return k
+}
+
+// nanKey is used instead of NaN, since NaN cannot be used as a Go map key.
+type nanKey struct{}
+
+func indexKey(k Ref) Ref {
+	if f, ok := k.v.(float64); ok && math.IsNaN(f) {
+		return Ref{nanKey{}}
+	}
+	return k
+}
+
+func (d *mapData) get(k Ref) (Ref, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e := d.index[indexKey(k)]
+	if e == nil {
+		return undefined, false
+	}
+	return e.val, true
+}
+
+func (d *mapData) set(k, v Ref) {
+	k = normKey(k)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.index == nil {
+		d.index = make(map[Ref]*mapEntry)
+	}
+	if e := d.index[indexKey(k)]; e != nil {
+		e.val = v
+		return
+	}
+	e := &mapEntry{key: k, val: v}
+	d.index[indexKey(k)] = e
+	d.entries = append(d.entries, e)
+}
+
+func (d *mapData) delete(k Ref) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e := d.index[indexKey(k)]
+	if e == nil {
+		return false
+	}
+	e.deleted = true
+	delete(d.index, indexKey(k))
+	return true
+}
+
+func (d *mapData) clear() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, e := range d.entries {
+		e.deleted = true
+	}
+	d.index = nil
+	d.entries = nil
+}
+
+func (d *mapData) size() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.index)
+}
+
+// iter returns a function that returns the next live entry of the collection.
+func (d *mapData) iter() func() *mapEntry {
+	i := 0
+	return func() *mapEntry {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		for i < len(d.entries) {
+			e := d.entries[i]
+			i++
+			if !e.deleted {
+				return e
+			}
+		}
+		return nil
+	}
+}
+
+// iterator creates a JS iterator over the collection. Function fnc converts entries to iterator values.
+func (d *mapData) iterator(tag string, fnc func(e *mapEntry) Ref) Ref {
+	next := d.iter()
+	return Ref{newIterator(tag, func() (Ref, bool) {
+		e := next()
+		if e == nil {
+			return undefined, false
+		}
+		return fnc(e), true
+	})}
+}
+
+func collectionThis(v Ref, class string) *mapData {
+	if o, ok := v.v.(*jsObject); ok && o.class == class {
+		if d, ok := o.data.(*mapData); ok {
+			return d
+		}
+	}
+	throwTypeError("Method " + class + ".prototype method called on incompatible receiver " + toString(v))
+	return nil
+}
+
+func setupCollections(g *jsObject) {
+	setupMap(g)
+	setupSet(g)
+	setupWeakRef(g)
+	setupFinalizationRegistry(g)
+}
+
+func setupMap(g *jsObject) {
+	mapProto = newObject(objectProto)
+	p := mapProto
+	this := func(v Ref) *mapData {
+		return collectionThis(v, "Map")
+	}
+	p.define(symbolToStringTag.key, Ref{"Map"}, false)
+	p.defineMethod("get", 1, func(v Ref, args []Ref) Ref {
+		val, _ := this(v).get(arg(args, 0))
+		return val
+	})
+	p.defineMethod("set", 2, func(v Ref, args []Ref) Ref {
+		this(v).set(arg(args, 0), arg(args, 1))
+		return v
+	})
+	p.defineMethod("has", 1, func(v Ref, args []Ref) Ref {
+		_, ok := this(v).get(arg(args, 0))
+		return Ref{ok}
+	})
+	p.defineMethod("delete", 1, func(v Ref, args []Ref) Ref {
+		return Ref{this(v).delete(arg(args, 0))}
+	})
+	p.defineMethod("clear", 0, func(v Ref, args []Ref) Ref {
+		this(v).clear()
+		return undefined
+	})
+	p.defineGetter("size", func(v Ref, args []Ref) Ref {
+		return Ref{float64(this(v).size())}
+	}, nil)
+	p.defineMethod("forEach", 1, func(v Ref, args []Ref) Ref {
+		fnc := arg(args, 0)
+		if !isCallable(fnc) {
+			throwTypeError(toString(fnc) + " is not a function")
+		}
+		next := this(v).iter()
+		for e := next(); e != nil; e = next() {
+			callFunc(fnc, arg(args, 1), []Ref{e.val, e.key, v})
+		}
+		return undefined
+	})
+	p.defineMethod("keys", 0, func(v Ref, args []Ref) Ref {
+		return this(v).iterator("Map Iterator", func(e *mapEntry) Ref {
+			return e.key
+		})
+	})
+	p.defineMethod("values", 0, func(v Ref, args []Ref) Ref {
+		return this(v).iterator("Map Iterator", func(e *mapEntry) Ref {
+			return e.val
+		})
+	})
+	entries := newFunc("entries", 0, func(v Ref, args []Ref) Ref {
+		return this(v).iterator("Map Iterator", func(e *mapEntry) Ref {
+			return Ref{newArray([]Ref{e.key, e.val})}
+		})
+	})
+	p.define("entries", Ref{entries}, false)
+	p.define(symbolIterator.key, Ref{entries}, false)
+
+	c := newClass("Map", 0, p, nil, func(args []Ref) *jsObject {
+		d := &mapData{}
+		if it := arg(args, 0); it.v != nil && it.v != (jsNull{}) {
+			for _, e := range listFromIterable(it) {
+				if _, ok := e.v.(*jsObject); !ok {
+					throwTypeError("Iterator value " + toString(e) + " is not an entry object")
+				}
+				d.set(getProp(e, "0"), getProp(e, "1"))
+			}
+		}
+		o := newObject(mapProto)
+		o.class = "Map"
+		o.data = d
+		return o
+	})
+	g.define("Map", Ref{c}, false)
+}
+
+func setupSet(g *jsObject) {
+	setProto = newObject(objectProto)
+	p := setProto
+	this := func(v Ref) *mapData {
+		return collectionThis(v, "Set")
+	}
+	p.define(symbolToStringTag.key, Ref{"Set"}, false)
+	p.defineMethod("add", 1, func(v Ref, args []Ref) Ref {
+		k := arg(args, 0)
+		this(v).set(k, k)
+		return v
+	})
+	p.defineMethod("has", 1, func(v Ref, args []Ref) Ref {
+		_, ok := this(v).get(arg(args, 0))
+		return Ref{ok}
+	})
+	p.defineMethod("delete", 1, func(v Ref, args []Ref) Ref {
+		return Ref{this(v).delete(arg(args, 0))}
+	})
+	p.defineMethod("clear", 0, func(v Ref, args []Ref) Ref {
+		this(v).clear()
+		return undefined
+	})
+	p.defineGetter("size", func(v Ref, args []Ref) Ref {
+		return Ref{float64(this(v).size())}
+	}, nil)
+	p.defineMethod("forEach", 1, func(v Ref, args []Ref) Ref {
+		fnc := arg(args, 0)
+		if !isCallable(fnc) {
+			throwTypeError(toString(fnc) + " is not a function")
+		}
+		next := this(v).iter()
+		for e := next(); e != nil; e = next() {
+			callFunc(fnc, arg(args, 1), []Ref{e.key, e.key, v})
+		}
+		return undefined
+	})
+	values := newFunc("values", 0, func(v Ref, args []Ref) Ref {
+		return this(v).iterator("Set Iterator", func(e *mapEntry) Ref {
+			return e.key
+		})
+	})
+	p.define("values", Ref{values}, false)
+	p.define("keys", Ref{values}, false)
+	p.define(symbolIterator.key, Ref{values}, false)
+	p.defineMethod("entries", 0, func(v Ref, args []Ref) Ref {
+		return this(v).iterator("Set Iterator", func(e *mapEntry) Ref {
+			return Ref{newArray([]Ref{e.key, e.key})}
+		})
+	})
+
+	c := newClass("Set", 0, p, nil, func(args []Ref) *jsObject {
+		d := &mapData{}
+		if it := arg(args, 0); it.v != nil && it.v != (jsNull{}) {
+			for _, v := range listFromIterable(it) {
+				d.set(v, v)
+			}
+		}
+		o := newObject(setProto)
+		o.class = "Set"
+		o.data = d
+		return o
+	})
+	g.define("Set", Ref{c}, false)
+}
+
+// weakRefData is an internal state of a WeakRef object.
+//
+// The emulator has no garbage collection of JS objects, thus the target is held strongly.
+type weakRefData struct {
+	target Ref
+}
+
+func setupWeakRef(g *jsObject) {
+	weakProto = newObject(objectProto)
+	p := weakProto
+	p.define(symbolToStringTag.key, Ref{"WeakRef"}, false)
+	p.defineMethod("deref", 0, func(v Ref, args []Ref) Ref {
+		if o, ok := v.v.(*jsObject); ok {
+			if d, ok := o.data.(*weakRefData); ok {
+				return d.target
+			}
+		}
+		throwTypeError("WeakRef.prototype.deref called on incompatible receiver " + toString(v))
+		return undefined
+	})
+	c := newClass("WeakRef", 1, p, nil, func(args []Ref) *jsObject {
+		t := arg(args, 0)
+		if _, ok := t.v.(*jsObject); !ok {
+			throwTypeError("WeakRef: target must be an object")
+		}
+		o := newObject(weakProto)
+		o.class = "WeakRef"
+		o.data = &weakRefData{target: t}
+		return o
+	})
+	g.define("WeakRef", Ref{c}, false)
+}
+
+// finCell is a single registration in FinalizationRegistry.
+type finCell struct {
+	target *jsObject
+	held   Ref
+	token  Ref
+}
+
+// finRegistry is an internal state of a FinalizationRegistry object.
+type finRegistry struct {
+	mu       sync.Mutex
+	callback Ref
+	cells    []*finCell
+}
+
+// finalizers tracks all finalization registries, so collection of objects can be simulated.
+var finalizers struct {
+	sync.Mutex
+	list []*finRegistry
+}
+
+// collectObject simulates garbage collection of the object by calling cleanup callbacks
+// of all finalization registries that have this object registered.
+//
+// Since the emulator never collects objects, this is only useful for testing.
+func collectObject(v Ref) {
+	o, ok := v.v.(*jsObject)
+	if !ok {
+		return
+	}
+	finalizers.Lock()
+	list := append([]*finRegistry{}, finalizers.list...)
+	finalizers.Unlock()
+	for _, r := range list {
+		var held []Ref
+		r.mu.Lock()
+		cells := r.cells[:0]
+		for _, c := range r.cells {
+			if c.target == o {
+				held = append(held, c.held)
+			} else {
+				cells = append(cells, c)
+			}
+		}
+		r.cells = cells
+		r.mu.Unlock()
+		for _, h := range held {
+			callFunc(r.callback, undefined, []Ref{h})
+		}
+	}
+}
+
+func setupFinalizationRegistry(g *jsObject) {
+	finProto = newObject(objectProto)
+	p := finProto
+	this := func(v Ref) *finRegistry {
+		if o, ok := v.v.(*jsObject); ok {
+			if d, ok := o.data.(*finRegistry); ok {
+				return d
+			}
+		}
+		throwTypeError("FinalizationRegistry method called on incompatible receiver " + toString(v))
+		return nil
+	}
+	p.define(symbolToStringTag.key, Ref{"FinalizationRegistry"}, false)
+	p.defineMethod("register", 2, func(v Ref, args []Ref) Ref {
+		r := this(v)
+		t, ok := arg(args, 0).v.(*jsObject)
+		if !ok {
+			throwTypeError("FinalizationRegistry.prototype.register: target must be an object")
+		}
+		if held := arg(args, 1); held.v == t {
+			throwTypeError("FinalizationRegistry.prototype.register: target and holdings must not be same")
+		}
+		token := arg(args, 2)
+		if _, ok := token.v.(*jsObject); !ok && token.v != nil {
+			throwTypeError("FinalizationRegistry.prototype.register: invalid unregister token")
+		}
+		r.mu.Lock()
+		r.cells = append(r.cells, &finCell{target: t, held: arg(args, 1), token: token})
+		r.mu.Unlock()
+		return undefined
+	})
+	p.defineMethod("unregister", 1, func(v Ref, args []Ref) Ref {
+		r := this(v)
+		token, ok := arg(args, 0).v.(*jsObject)
+		if !ok {
+			throwTypeError("Invalid unregisterToken ('" + toString(arg(args, 0)) + "')")
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		removed := false
+		cells := r.cells[:0]
+		for _, c := range r.cells {
+			if c.token.v == token {
+				removed = true
+			} else {
+				cells = append(cells, c)
+			}
+		}
+		r.cells = cells
+		return Ref{removed}
+	})
+	c := newClass("FinalizationRegistry", 1, p, nil, func(args []Ref) *jsObject {
+		fnc := arg(args, 0)
+		if !isCallable(fnc) {
+			throwTypeError("FinalizationRegistry: cleanup must be callable")
+		}
+		r := &finRegistry{callback: fnc}
+		finalizers.Lock()
+		finalizers.list = append(finalizers.list, r)
+		finalizers.Unlock()
+		o := newObject(finProto)
+		o.class = "FinalizationRegistry"
+		o.data = r
+		return o
+	})
+	g.define("FinalizationRegistry", Ref{c}, false)
+}
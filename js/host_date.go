@@ -0,0 +1,214 @@
+//+build !wasm
+
+package js
+
+import (
+	"math"
+	"time"
+)
+
+var dateProto *jsObject
+
+// dateData is an internal state of a Date object. It stores the number of milliseconds since the Unix epoch,
+// or NaN for an invalid date.
+type dateData struct {
+	ms float64
+}
+
+func (d *dateData) valid() bool {
+	return !math.IsNaN(d.ms)
+}
+
+func (d *dateData) time() time.Time {
+	sec := math.Floor(d.ms / 1000)
+	return time.Unix(int64(sec), int64((d.ms-sec*1000)*1e6))
+}
+
+func (d *dateData) String() string {
+	if !d.valid() {
+		return "Invalid Date"
+	}
+	t := d.time().Local()
+	name, _ := t.Zone()
+	return t.Format("Mon Jan 02 2006 15:04:05 GMT-0700") + " (" + name + ")"
+}
+
+// timeClip limits the time value to the range supported by JS dates.
+func timeClip(ms float64) float64 {
+	if math.IsNaN(ms) || math.Abs(ms) > 8.64e15 {
+		return math.NaN()
+	}
+	return math.Trunc(ms)
+}
+
+func timeMillis(t time.Time) float64 {
+	return float64(t.Unix())*1000 + float64(t.Nanosecond()/1e6)
+}
+
+// parseDate parses date strings in the formats produced by toISOString and toString.
+func parseDate(s string) float64 {
+	for _, layout := range []string{
+		"2006-01-02T15:04:05.999Z07:00",
+		"2006-01-02T15:04:05Z07:00",
+		"2006-01-02T15:04Z07:00",
+		"Mon Jan 02 2006 15:04:05 GMT-0700",
+	} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return timeMillis(t)
+		}
+	}
+	// local time without a zone; date-only forms are UTC
+	for _, layout := range []string{
+		"2006-01-02T15:04:05.999",
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04",
+	} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return timeMillis(t)
+		}
+	}
+	for _, layout := range []string{
+		"2006-01-02",
+		"2006-01",
+		"2006",
+	} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return timeMillis(t)
+		}
+	}
+	if len(s) > 33 {
+		// strip the zone name: "... GMT+0000 (Coordinated Universal Time)"
+		if t, err := time.Parse("Mon Jan 02 2006 15:04:05 GMT-0700", s[:33]); err == nil {
+			return timeMillis(t)
+		}
+	}
+	return math.NaN()
+}
+
+// dateFromFields creates a time value from date fields, as in Date constructor and Date.UTC.
+// Fields may overflow, for example month 12 is January of the next year.
+func dateFromFields(args []Ref, loc *time.Location) float64 {
+	f := make([]float64, 7)
+	f[2] = 1 // day
+	for i := range f {
+		if i < len(args) {
+			f[i] = toNumber(args[i])
+			if math.IsNaN(f[i]) || math.IsInf(f[i], 0) {
+				return math.NaN()
+			}
+			f[i] = math.Trunc(f[i])
+		}
+	}
+	if f[0] >= 0 && f[0] <= 99 {
+		f[0] += 1900
+	}
+	t := time.Date(int(f[0]), time.Month(f[1]+1), int(f[2]), int(f[3]), int(f[4]), int(f[5]), int(f[6])*1e6, loc)
+	return timeClip(timeMillis(t))
+}
+
+func dateThis(v Ref) *dateData {
+	if o, ok := v.v.(*jsObject); ok {
+		if d, ok := o.data.(*dateData); ok {
+			return d
+		}
+	}
+	throwTypeError("this is not a Date object.")
+	return nil
+}
+
+func newDate(ms float64) *jsObject {
+	d := newObject(dateProto)
+	d.class = "Date"
+	d.data = &dateData{ms: timeClip(ms)}
+	return d
+}
+
+func setupDate(g *jsObject) {
+	dateProto = newObject(objectProto)
+	p := dateProto
+	num := func(name string, fnc func(d *dateData) float64) {
+		p.defineMethod(name, 0, func(this Ref, args []Ref) Ref {
+			d := dateThis(this)
+			if !d.valid() {
+				return Ref{math.NaN()}
+			}
+			return Ref{fnc(d)}
+		})
+	}
+	num("getTime", func(d *dateData) float64 { return d.ms })
+	num("valueOf", func(d *dateData) float64 { return d.ms })
+	field := func(name string, fnc func(t time.Time) int) {
+		num("get"+name, func(d *dateData) float64 { return float64(fnc(d.time().Local())) })
+		num("getUTC"+name, func(d *dateData) float64 { return float64(fnc(d.time().UTC())) })
+	}
+	field("FullYear", time.Time.Year)
+	field("Month", func(t time.Time) int { return int(t.Month()) - 1 })
+	field("Date", time.Time.Day)
+	field("Day", func(t time.Time) int { return int(t.Weekday()) })
+	field("Hours", time.Time.Hour)
+	field("Minutes", time.Time.Minute)
+	field("Seconds", time.Time.Second)
+	field("Milliseconds", func(t time.Time) int { return t.Nanosecond() / 1e6 })
+	num("getTimezoneOffset", func(d *dateData) float64 {
+		_, off := d.time().Local().Zone()
+		return float64(-off / 60)
+	})
+	p.defineMethod("setTime", 1, func(this Ref, args []Ref) Ref {
+		d := dateThis(this)
+		d.ms = timeClip(toNumber(arg(args, 0)))
+		return Ref{d.ms}
+	})
+	p.defineMethod("toISOString", 0, func(this Ref, args []Ref) Ref {
+		d := dateThis(this)
+		if !d.valid() {
+			throwError(rangeErrorProto, "Invalid time value")
+		}
+		return Ref{d.time().UTC().Format("2006-01-02T15:04:05.000Z")}
+	})
+	p.defineMethod("toJSON", 1, func(this Ref, args []Ref) Ref {
+		if !dateThis(this).valid() {
+			return null
+		}
+		return callMethod(this, "toISOString")
+	})
+	p.defineMethod("toString", 0, func(this Ref, args []Ref) Ref {
+		return Ref{dateThis(this).String()}
+	})
+	p.defineMethod("toUTCString", 0, func(this Ref, args []Ref) Ref {
+		d := dateThis(this)
+		if !d.valid() {
+			return Ref{"Invalid Date"}
+		}
+		return Ref{d.time().UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT")}
+	})
+
+	c := newClass("Date", 7, p, func(this Ref, args []Ref) Ref {
+		return Ref{(&dateData{ms: timeMillis(time.Now())}).String()}
+	}, func(args []Ref) *jsObject {
+		switch len(args) {
+		case 0:
+			return newDate(timeMillis(time.Now()))
+		case 1:
+			switch x := args[0].v.(type) {
+			case string:
+				return newDate(parseDate(x))
+			case *jsObject:
+				if d, ok := x.data.(*dateData); ok {
+					return newDate(d.ms)
+				}
+			}
+			return newDate(toNumber(args[0]))
+		}
+		return newDate(dateFromFields(args, time.Local))
+	})
+	c.defineMethod("now", 0, func(this Ref, args []Ref) Ref {
+		return Ref{timeMillis(time.Now())}
+	})
+	c.defineMethod("parse", 1, func(this Ref, args []Ref) Ref {
+		return Ref{parseDate(toString(arg(args, 0)))}
+	})
+	c.defineMethod("UTC", 7, func(this Ref, args []Ref) Ref {
+		return Ref{dateFromFields(args, time.UTC)}
+	})
+	g.define("Date", Ref{c}, false)
+}
@@ -0,0 +1,1205 @@
+//+build !wasm
+
+package js
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Prototypes of builtin classes of the JS emulation.
+var (
+	objectProto      *jsObject
+	funcProto        *jsObject
+	arrayProto       *jsObject
+	stringProto      *jsObject
+	numberProto      *jsObject
+	booleanProto     *jsObject
+	errorProto       *jsObject
+	typeErrorProto   *jsObject
+	rangeErrorProto  *jsObject
+	syntaxErrorProto *jsObject
+
+	domExceptionProto *jsObject
+
+	aggregateErrorClass *jsObject
+)
+
+// newGlobal creates a global object with all the builtin classes of the JS emulation.
+func newGlobal() *jsObject {
+	objectProto = &jsObject{class: "Object"}
+	funcProto = &jsObject{proto: objectProto, class: "Function", call: func(this Ref, args []Ref) Ref {
+		return undefined
+	}}
+	g := newObject(objectProto)
+	g.class = "global"
+	g.define("globalThis", Ref{g}, false)
+	g.define("undefined", undefined, false)
+	g.define("NaN", Ref{math.NaN()}, false)
+	g.define("Infinity", Ref{math.Inf(1)}, false)
+
+	setupObject(g)
+	setupFunction(g)
+	setupArray(g)
+	setupPrimitives(g)
+	setupSymbol(g)
+	setupBigInt(g)
+	setupErrors(g)
+	setupMath(g)
+	setupReflect(g)
+	setupConsole(g)
+	setupJSON(g)
+	setupPromise(g)
+	setupTimers(g)
+	setupDate(g)
+	setupCollections(g)
+	setupStructuredClone(g)
+	setupBuffers(g)
+	return g
+}
+
+func setupObject(g *jsObject) {
+	p := objectProto
+	p.defineMethod("hasOwnProperty", 1, func(this Ref, args []Ref) Ref {
+		_, ok := toObject(this).getOwn(propertyKey(arg(args, 0)))
+		return Ref{ok}
+	})
+	p.defineMethod("isPrototypeOf", 1, func(this Ref, args []Ref) Ref {
+		o, ok := arg(args, 0).v.(*jsObject)
+		if !ok {
+			return Ref{false}
+		}
+		self := toObject(this)
+		for cur := o.proto; cur != nil; cur = cur.proto {
+			if cur == self {
+				return Ref{true}
+			}
+		}
+		return Ref{false}
+	})
+	p.defineMethod("toString", 0, func(this Ref, args []Ref) Ref {
+		switch this.v.(type) {
+		case nil:
+			return Ref{"[object Undefined]"}
+		case jsNull:
+			return Ref{"[object Null]"}
+		}
+		o := toObject(this)
+		if tag, ok := getProp(this, "@@toStringTag").v.(string); ok {
+			return Ref{"[object " + tag + "]"}
+		}
+		return Ref{"[object " + o.class + "]"}
+	})
+	p.defineMethod("valueOf", 0, func(this Ref, args []Ref) Ref {
+		return this
+	})
+
+	c := newClass("Object", 1, p, func(this Ref, args []Ref) Ref {
+		v := arg(args, 0)
+		if _, ok := v.v.(*jsObject); ok {
+			return v
+		}
+		return Ref{newObject(objectProto)}
+	}, nil)
+	c.construct = func(args []Ref) *jsObject {
+		if o, ok := arg(args, 0).v.(*jsObject); ok {
+			return o
+		}
+		return newObject(objectProto)
+	}
+	keysOf := func(v Ref) []string {
+		return toObject(v).ownKeys(false)
+	}
+	c.defineMethod("keys", 1, func(this Ref, args []Ref) Ref {
+		keys := keysOf(arg(args, 0))
+		arr := make([]Ref, 0, len(keys))
+		for _, k := range keys {
+			arr = append(arr, Ref{k})
+		}
+		return Ref{newArray(arr)}
+	})
+	c.defineMethod("values", 1, func(this Ref, args []Ref) Ref {
+		v := arg(args, 0)
+		keys := keysOf(v)
+		arr := make([]Ref, 0, len(keys))
+		for _, k := range keys {
+			arr = append(arr, getProp(v, k))
+		}
+		return Ref{newArray(arr)}
+	})
+	c.defineMethod("entries", 1, func(this Ref, args []Ref) Ref {
+		v := arg(args, 0)
+		keys := keysOf(v)
+		arr := make([]Ref, 0, len(keys))
+		for _, k := range keys {
+			arr = append(arr, Ref{newArray([]Ref{{k}, getProp(v, k)})})
+		}
+		return Ref{newArray(arr)}
+	})
+	c.defineMethod("getOwnPropertyNames", 1, func(this Ref, args []Ref) Ref {
+		keys := toObject(arg(args, 0)).ownKeys(true)
+		arr := make([]Ref, 0, len(keys))
+		for _, k := range keys {
+			arr = append(arr, Ref{k})
+		}
+		return Ref{newArray(arr)}
+	})
+	c.defineMethod("assign", 2, func(this Ref, args []Ref) Ref {
+		dst := arg(args, 0)
+		toObject(dst)
+		for _, src := range args[1:] {
+			if src.v == nil || src.v == (jsNull{}) {
+				continue
+			}
+			for _, k := range keysOf(src) {
+				setProp(dst, k, getProp(src, k))
+			}
+		}
+		return dst
+	})
+	c.defineMethod("create", 2, func(this Ref, args []Ref) Ref {
+		var proto *jsObject
+		switch p := arg(args, 0).v.(type) {
+		case *jsObject:
+			proto = p
+		case jsNull:
+		default:
+			throwTypeError("Object prototype may only be an Object or null")
+		}
+		return Ref{newObject(proto)}
+	})
+	c.defineMethod("getPrototypeOf", 1, func(this Ref, args []Ref) Ref {
+		if p := toObject(arg(args, 0)).proto; p != nil {
+			return Ref{p}
+		}
+		return null
+	})
+	c.defineMethod("setPrototypeOf", 2, func(this Ref, args []Ref) Ref {
+		o, ok := arg(args, 0).v.(*jsObject)
+		if !ok {
+			return arg(args, 0)
+		}
+		switch p := arg(args, 1).v.(type) {
+		case *jsObject:
+			o.proto = p
+		case jsNull:
+			o.proto = nil
+		default:
+			throwTypeError("Object prototype may only be an Object or null")
+		}
+		return Ref{o}
+	})
+	c.defineMethod("defineProperty", 3, func(this Ref, args []Ref) Ref {
+		o, ok := arg(args, 0).v.(*jsObject)
+		if !ok {
+			throwTypeError("Object.defineProperty called on non-object")
+		}
+		definePropertyFromDescriptor(o, propertyKey(arg(args, 1)), arg(args, 2))
+		return Ref{o}
+	})
+	c.defineMethod("freeze", 1, func(this Ref, args []Ref) Ref {
+		// immutability is not emulated
+		return arg(args, 0)
+	})
+	c.defineMethod("is", 2, func(this Ref, args []Ref) Ref {
+		a, b := arg(args, 0), arg(args, 1)
+		if x, ok := a.v.(float64); ok {
+			if y, ok := b.v.(float64); ok {
+				if math.IsNaN(x) && math.IsNaN(y) {
+					return Ref{true}
+				}
+				return Ref{x == y && math.Signbit(x) == math.Signbit(y)}
+			}
+		}
+		return Ref{a == b}
+	})
+	g.define("Object", Ref{c}, false)
+}
+
+func definePropertyFromDescriptor(o *jsObject, key string, desc Ref) {
+	d, ok := desc.v.(*jsObject)
+	if !ok {
+		throwTypeError("Property description must be an object")
+	}
+	p := &property{enum: getProp(desc, "enumerable").Truthy()}
+	if get := getProp(desc, "get"); isCallable(get) {
+		p.get = get.v.(*jsObject)
+	}
+	if set := getProp(desc, "set"); isCallable(set) {
+		p.set = set.v.(*jsObject)
+	}
+	if !p.isAccessor() {
+		p.value = getProp(Ref{d}, "value")
+	}
+	o.defineProp(key, p)
+}
+
+func setupFunction(g *jsObject) {
+	p := funcProto
+	p.defineMethod("call", 1, func(this Ref, args []Ref) Ref {
+		var rest []Ref
+		if len(args) > 1 {
+			rest = args[1:]
+		}
+		return callFunc(this, arg(args, 0), rest)
+	})
+	p.defineMethod("apply", 2, func(this Ref, args []Ref) Ref {
+		var list []Ref
+		if a := arg(args, 1); a.v != nil && a.v != (jsNull{}) {
+			list = listFromArrayLike(a)
+		}
+		return callFunc(this, arg(args, 0), list)
+	})
+	p.defineMethod("bind", 1, func(this Ref, args []Ref) Ref {
+		fnc := this
+		if !isCallable(fnc) {
+			throwTypeError("Bind must be called on a function")
+		}
+		self := arg(args, 0)
+		var bound []Ref
+		if len(args) > 1 {
+			bound = append(bound, args[1:]...)
+		}
+		name := "bound " + toString(getProp(fnc, "name"))
+		return Ref{newFunc(name, 0, func(_ Ref, args []Ref) Ref {
+			return callFunc(fnc, self, append(append([]Ref{}, bound...), args...))
+		})}
+	})
+	p.defineMethod("toString", 0, func(this Ref, args []Ref) Ref {
+		return Ref{"function " + toString(getProp(this, "name")) + "() { [native code] }"}
+	})
+	c := newClass("Function", 1, p, func(this Ref, args []Ref) Ref {
+		throwError(errorProto, "Function constructor is not supported by the host JS emulation; build with GOARCH=wasm")
+		return undefined
+	}, nil)
+	c.construct = func(args []Ref) *jsObject {
+		c.call(undefined, args)
+		return nil
+	}
+	g.define("Function", Ref{c}, false)
+}
+
+func arrayThis(this Ref) *jsObject {
+	o, ok := this.v.(*jsObject)
+	if !ok || o.class != "Array" {
+		throwTypeError("Array.prototype method called on incompatible receiver")
+	}
+	return o
+}
+
+// arrayElems returns a copy of array elements.
+func arrayElems(this Ref) []Ref {
+	o, ok := this.v.(*jsObject)
+	if ok && o.class == "Array" {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		return append([]Ref{}, o.arr...)
+	}
+	return listFromArrayLike(this)
+}
+
+func setupArray(g *jsObject) {
+	arrayProto = &jsObject{proto: objectProto, class: "Array"}
+	p := arrayProto
+	p.defineMethod("push", 1, func(this Ref, args []Ref) Ref {
+		o := arrayThis(this)
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		o.arr = append(o.arr, args...)
+		return Ref{float64(len(o.arr))}
+	})
+	p.defineMethod("pop", 0, func(this Ref, args []Ref) Ref {
+		o := arrayThis(this)
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		if len(o.arr) == 0 {
+			return undefined
+		}
+		v := o.arr[len(o.arr)-1]
+		o.arr = o.arr[:len(o.arr)-1]
+		return v
+	})
+	p.defineMethod("shift", 0, func(this Ref, args []Ref) Ref {
+		o := arrayThis(this)
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		if len(o.arr) == 0 {
+			return undefined
+		}
+		v := o.arr[0]
+		o.arr = append([]Ref{}, o.arr[1:]...)
+		return v
+	})
+	p.defineMethod("unshift", 1, func(this Ref, args []Ref) Ref {
+		o := arrayThis(this)
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		o.arr = append(append([]Ref{}, args...), o.arr...)
+		return Ref{float64(len(o.arr))}
+	})
+	p.defineMethod("slice", 2, func(this Ref, args []Ref) Ref {
+		arr := arrayElems(this)
+		b := relIndex(arg(args, 0), len(arr), 0)
+		e := relIndex(arg(args, 1), len(arr), len(arr))
+		if e < b {
+			e = b
+		}
+		return Ref{newArray(append([]Ref{}, arr[b:e]...))}
+	})
+	p.defineMethod("splice", 2, func(this Ref, args []Ref) Ref {
+		o := arrayThis(this)
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		n := len(o.arr)
+		b := relIndex(arg(args, 0), n, 0)
+		cnt := n - b
+		if len(args) > 1 {
+			cnt = toInt(args[1], 0)
+			if cnt < 0 {
+				cnt = 0
+			} else if cnt > n-b {
+				cnt = n - b
+			}
+		}
+		var items []Ref
+		if len(args) > 2 {
+			items = args[2:]
+		}
+		removed := append([]Ref{}, o.arr[b:b+cnt]...)
+		arr := append([]Ref{}, o.arr[:b]...)
+		arr = append(arr, items...)
+		o.arr = append(arr, o.arr[b+cnt:]...)
+		return Ref{newArray(removed)}
+	})
+	p.defineMethod("concat", 1, func(this Ref, args []Ref) Ref {
+		arr := arrayElems(this)
+		for _, a := range args {
+			if o, ok := a.v.(*jsObject); ok && o.class == "Array" {
+				arr = append(arr, arrayElems(a)...)
+			} else {
+				arr = append(arr, a)
+			}
+		}
+		return Ref{newArray(arr)}
+	})
+	p.defineMethod("join", 1, func(this Ref, args []Ref) Ref {
+		sep := ","
+		if s := arg(args, 0); s.v != nil {
+			sep = toString(s)
+		}
+		arr := arrayElems(this)
+		strs := make([]string, 0, len(arr))
+		for _, v := range arr {
+			switch v.v.(type) {
+			case nil, jsNull:
+				strs = append(strs, "")
+			default:
+				strs = append(strs, toString(v))
+			}
+		}
+		return Ref{strings.Join(strs, sep)}
+	})
+	p.defineMethod("toString", 0, func(this Ref, args []Ref) Ref {
+		return callMethod(this, "join")
+	})
+	p.defineMethod("reverse", 0, func(this Ref, args []Ref) Ref {
+		o := arrayThis(this)
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		for i, j := 0, len(o.arr)-1; i < j; i, j = i+1, j-1 {
+			o.arr[i], o.arr[j] = o.arr[j], o.arr[i]
+		}
+		return this
+	})
+	p.defineMethod("indexOf", 1, func(this Ref, args []Ref) Ref {
+		arr := arrayElems(this)
+		for i := relIndex(arg(args, 1), len(arr), 0); i < len(arr); i++ {
+			if arr[i] == arg(args, 0) {
+				return Ref{float64(i)}
+			}
+		}
+		return Ref{float64(-1)}
+	})
+	p.defineMethod("lastIndexOf", 1, func(this Ref, args []Ref) Ref {
+		arr := arrayElems(this)
+		for i := len(arr) - 1; i >= 0; i-- {
+			if arr[i] == arg(args, 0) {
+				return Ref{float64(i)}
+			}
+		}
+		return Ref{float64(-1)}
+	})
+	p.defineMethod("includes", 1, func(this Ref, args []Ref) Ref {
+		for _, v := range arrayElems(this) {
+			if sameValueZero(v, arg(args, 0)) {
+				return Ref{true}
+			}
+		}
+		return Ref{false}
+	})
+	// iterate calls the callback for each element and stops if it returns false
+	iterate := func(this Ref, args []Ref, fnc func(i int, v, r Ref) bool) {
+		cb := arg(args, 0)
+		if !isCallable(cb) {
+			throwTypeError(toString(cb) + " is not a function")
+		}
+		self := arg(args, 1)
+		for i, v := range arrayElems(this) {
+			r := callFunc(cb, self, []Ref{v, {float64(i)}, this})
+			if !fnc(i, v, r) {
+				return
+			}
+		}
+	}
+	p.defineMethod("forEach", 1, func(this Ref, args []Ref) Ref {
+		iterate(this, args, func(i int, v, r Ref) bool { return true })
+		return undefined
+	})
+	p.defineMethod("map", 1, func(this Ref, args []Ref) Ref {
+		var out []Ref
+		iterate(this, args, func(i int, v, r Ref) bool {
+			out = append(out, r)
+			return true
+		})
+		return Ref{newArray(out)}
+	})
+	p.defineMethod("filter", 1, func(this Ref, args []Ref) Ref {
+		out := []Ref{}
+		iterate(this, args, func(i int, v, r Ref) bool {
+			if r.Truthy() {
+				out = append(out, v)
+			}
+			return true
+		})
+		return Ref{newArray(out)}
+	})
+	p.defineMethod("some", 1, func(this Ref, args []Ref) Ref {
+		found := false
+		iterate(this, args, func(i int, v, r Ref) bool {
+			found = r.Truthy()
+			return !found
+		})
+		return Ref{found}
+	})
+	p.defineMethod("every", 1, func(this Ref, args []Ref) Ref {
+		all := true
+		iterate(this, args, func(i int, v, r Ref) bool {
+			all = r.Truthy()
+			return all
+		})
+		return Ref{all}
+	})
+	p.defineMethod("find", 1, func(this Ref, args []Ref) Ref {
+		found := undefined
+		iterate(this, args, func(i int, v, r Ref) bool {
+			if r.Truthy() {
+				found = v
+				return false
+			}
+			return true
+		})
+		return found
+	})
+	p.defineMethod("findIndex", 1, func(this Ref, args []Ref) Ref {
+		found := -1
+		iterate(this, args, func(i int, v, r Ref) bool {
+			if r.Truthy() {
+				found = i
+				return false
+			}
+			return true
+		})
+		return Ref{float64(found)}
+	})
+	p.defineMethod("reduce", 1, func(this Ref, args []Ref) Ref {
+		cb := arg(args, 0)
+		arr := arrayElems(this)
+		var acc Ref
+		if len(args) > 1 {
+			acc = args[1]
+		} else {
+			if len(arr) == 0 {
+				throwTypeError("Reduce of empty array with no initial value")
+			}
+			acc, arr = arr[0], arr[1:]
+		}
+		for i, v := range arr {
+			acc = callFunc(cb, undefined, []Ref{acc, v, {float64(i)}, this})
+		}
+		return acc
+	})
+	p.defineMethod("fill", 1, func(this Ref, args []Ref) Ref {
+		o := arrayThis(this)
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		b := relIndex(arg(args, 1), len(o.arr), 0)
+		e := relIndex(arg(args, 2), len(o.arr), len(o.arr))
+		for i := b; i < e; i++ {
+			o.arr[i] = arg(args, 0)
+		}
+		return this
+	})
+	p.defineMethod("sort", 1, func(this Ref, args []Ref) Ref {
+		o := arrayThis(this)
+		cmp := arg(args, 0)
+		arr := arrayElems(this)
+		sort.SliceStable(arr, func(i, j int) bool {
+			a, b := arr[i], arr[j]
+			// undefined values are always sorted to the end
+			if a.v == nil || b.v == nil {
+				return b.v == nil && a.v != nil
+			}
+			if isCallable(cmp) {
+				return toNumber(callFunc(cmp, undefined, []Ref{a, b})) < 0
+			}
+			return toString(a) < toString(b)
+		})
+		o.mu.Lock()
+		o.arr = arr
+		o.mu.Unlock()
+		return this
+	})
+
+	c := newClass("Array", 1, p, nil, func(args []Ref) *jsObject {
+		if len(args) == 1 {
+			if n, ok := args[0].v.(float64); ok {
+				if n < 0 || n != math.Trunc(n) || n > math.MaxInt32 {
+					throwError(rangeErrorProto, "Invalid array length")
+				}
+				return newArray(make([]Ref, int(n)))
+			}
+		}
+		return newArray(append([]Ref{}, args...))
+	})
+	c.call = func(this Ref, args []Ref) Ref {
+		return Ref{c.construct(args)}
+	}
+	c.defineMethod("isArray", 1, func(this Ref, args []Ref) Ref {
+		o, ok := arg(args, 0).v.(*jsObject)
+		return Ref{ok && o.class == "Array"}
+	})
+	c.defineMethod("of", 0, func(this Ref, args []Ref) Ref {
+		return Ref{newArray(append([]Ref{}, args...))}
+	})
+	c.defineMethod("from", 1, func(this Ref, args []Ref) Ref {
+		arr := listFromIterable(arg(args, 0))
+		if fnc := arg(args, 1); isCallable(fnc) {
+			for i, v := range arr {
+				arr[i] = callFunc(fnc, undefined, []Ref{v, {float64(i)}})
+			}
+		}
+		return Ref{newArray(arr)}
+	})
+	g.define("Array", Ref{c}, false)
+}
+
+// utf16String is a helper to work with JS strings using UTF-16 indexes.
+type utf16String []uint16
+
+func toUTF16(s string) utf16String {
+	return utf16.Encode([]rune(s))
+}
+
+func (s utf16String) String() string {
+	return string(utf16.Decode(s))
+}
+
+func (s utf16String) indexOf(sub utf16String, from int) int {
+	for i := from; i+len(sub) <= len(s); i++ {
+		match := true
+		for j := range sub {
+			if s[i+j] != sub[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+func setupPrimitives(g *jsObject) {
+	// String
+	stringProto = &jsObject{proto: objectProto, class: "String"}
+	sp := stringProto
+	str := func(this Ref) string {
+		if s, ok := this.v.(string); ok {
+			return s
+		}
+		return toString(this)
+	}
+	sp.defineMethod("toString", 0, func(this Ref, args []Ref) Ref {
+		return Ref{str(this)}
+	})
+	sp.defineMethod("valueOf", 0, func(this Ref, args []Ref) Ref {
+		return Ref{str(this)}
+	})
+	sp.defineMethod("charAt", 1, func(this Ref, args []Ref) Ref {
+		s := toUTF16(str(this))
+		i := toInt(arg(args, 0), 0)
+		if i < 0 || i >= len(s) {
+			return Ref{""}
+		}
+		return Ref{s[i : i+1].String()}
+	})
+	sp.defineMethod("charCodeAt", 1, func(this Ref, args []Ref) Ref {
+		s := toUTF16(str(this))
+		i := toInt(arg(args, 0), 0)
+		if i < 0 || i >= len(s) {
+			return Ref{math.NaN()}
+		}
+		return Ref{float64(s[i])}
+	})
+	sp.defineMethod("indexOf", 1, func(this Ref, args []Ref) Ref {
+		s := toUTF16(str(this))
+		return Ref{float64(s.indexOf(toUTF16(toString(arg(args, 0))), relIndex(arg(args, 1), len(s), 0)))}
+	})
+	sp.defineMethod("lastIndexOf", 1, func(this Ref, args []Ref) Ref {
+		s, sub := toUTF16(str(this)), toUTF16(toString(arg(args, 0)))
+		last := -1
+		for i := s.indexOf(sub, 0); i >= 0; i = s.indexOf(sub, i+1) {
+			last = i
+		}
+		return Ref{float64(last)}
+	})
+	sp.defineMethod("includes", 1, func(this Ref, args []Ref) Ref {
+		return Ref{strings.Contains(str(this), toString(arg(args, 0)))}
+	})
+	sp.defineMethod("startsWith", 1, func(this Ref, args []Ref) Ref {
+		return Ref{strings.HasPrefix(str(this), toString(arg(args, 0)))}
+	})
+	sp.defineMethod("endsWith", 1, func(this Ref, args []Ref) Ref {
+		return Ref{strings.HasSuffix(str(this), toString(arg(args, 0)))}
+	})
+	sp.defineMethod("slice", 2, func(this Ref, args []Ref) Ref {
+		s := toUTF16(str(this))
+		b := relIndex(arg(args, 0), len(s), 0)
+		e := relIndex(arg(args, 1), len(s), len(s))
+		if e < b {
+			return Ref{""}
+		}
+		return Ref{s[b:e].String()}
+	})
+	sp.defineMethod("substring", 2, func(this Ref, args []Ref) Ref {
+		s := toUTF16(str(this))
+		clamp := func(i int) int {
+			if i < 0 {
+				return 0
+			} else if i > len(s) {
+				return len(s)
+			}
+			return i
+		}
+		b := clamp(toInt(arg(args, 0), 0))
+		e := clamp(toInt(arg(args, 1), len(s)))
+		if e < b {
+			b, e = e, b
+		}
+		return Ref{s[b:e].String()}
+	})
+	sp.defineMethod("toUpperCase", 0, func(this Ref, args []Ref) Ref {
+		return Ref{strings.ToUpper(str(this))}
+	})
+	sp.defineMethod("toLowerCase", 0, func(this Ref, args []Ref) Ref {
+		return Ref{strings.ToLower(str(this))}
+	})
+	sp.defineMethod("trim", 0, func(this Ref, args []Ref) Ref {
+		return Ref{strings.TrimSpace(str(this))}
+	})
+	sp.defineMethod("split", 2, func(this Ref, args []Ref) Ref {
+		s := str(this)
+		if arg(args, 0).v == nil {
+			return Ref{newArray([]Ref{{s}})}
+		}
+		var parts []string
+		if sep := toString(arg(args, 0)); sep == "" {
+			for _, c := range toUTF16(s) {
+				parts = append(parts, utf16String{c}.String())
+			}
+		} else {
+			parts = strings.Split(s, sep)
+		}
+		if lim := arg(args, 1); lim.v != nil {
+			if n := toInt(lim, 0); n < len(parts) {
+				parts = parts[:n]
+			}
+		}
+		arr := make([]Ref, 0, len(parts))
+		for _, p := range parts {
+			arr = append(arr, Ref{p})
+		}
+		return Ref{newArray(arr)}
+	})
+	sp.defineMethod("repeat", 1, func(this Ref, args []Ref) Ref {
+		n := toInt(arg(args, 0), 0)
+		if n < 0 {
+			throwError(rangeErrorProto, "Invalid count value: "+strconv.Itoa(n))
+		}
+		return Ref{strings.Repeat(str(this), n)}
+	})
+	sp.defineMethod("concat", 1, func(this Ref, args []Ref) Ref {
+		s := str(this)
+		for _, a := range args {
+			s += toString(a)
+		}
+		return Ref{s}
+	})
+	sp.defineMethod("replace", 2, func(this Ref, args []Ref) Ref {
+		// only string patterns are supported
+		s, pat := str(this), toString(arg(args, 0))
+		i := strings.Index(s, pat)
+		if i < 0 {
+			return Ref{s}
+		}
+		rep := arg(args, 1)
+		var r string
+		if isCallable(rep) {
+			r = toString(callFunc(rep, undefined, []Ref{{pat}, {float64(len(toUTF16(s[:i])))}, {s}}))
+		} else {
+			r = toString(rep)
+		}
+		return Ref{s[:i] + r + s[i+len(pat):]}
+	})
+	sc := newClass("String", 1, sp, func(this Ref, args []Ref) Ref {
+		if len(args) == 0 {
+			return Ref{""}
+		}
+		if s, ok := args[0].v.(*jsSymbol); ok {
+			return Ref{symbolString(s)}
+		}
+		return Ref{toString(args[0])}
+	}, nil)
+	sc.construct = func(args []Ref) *jsObject {
+		throwTypeError("String objects are not supported by the host JS emulation")
+		return nil
+	}
+	g.define("String", Ref{sc}, false)
+
+	// Number
+	numberProto = &jsObject{proto: objectProto, class: "Number"}
+	np := numberProto
+	num := func(this Ref) float64 {
+		f, ok := this.v.(float64)
+		if !ok {
+			throwTypeError("Number.prototype method called on incompatible receiver")
+		}
+		return f
+	}
+	np.defineMethod("toString", 1, func(this Ref, args []Ref) Ref {
+		f := num(this)
+		if r := arg(args, 0); r.v != nil && toInt(r, 10) != 10 {
+			if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+				return Ref{strconv.FormatInt(int64(f), toInt(r, 10))}
+			}
+		}
+		return Ref{formatNumber(f)}
+	})
+	np.defineMethod("toFixed", 1, func(this Ref, args []Ref) Ref {
+		return Ref{strconv.FormatFloat(num(this), 'f', toInt(arg(args, 0), 0), 64)}
+	})
+	np.defineMethod("valueOf", 0, func(this Ref, args []Ref) Ref {
+		return Ref{num(this)}
+	})
+	nc := newClass("Number", 1, np, func(this Ref, args []Ref) Ref {
+		if len(args) == 0 {
+			return Ref{0.0}
+		}
+		return Ref{toNumber(args[0])}
+	}, nil)
+	nc.construct = func(args []Ref) *jsObject {
+		throwTypeError("Number objects are not supported by the host JS emulation")
+		return nil
+	}
+	nc.define("MAX_SAFE_INTEGER", Ref{float64(1<<53 - 1)}, false)
+	nc.define("MIN_SAFE_INTEGER", Ref{-float64(1<<53 - 1)}, false)
+	nc.define("EPSILON", Ref{math.Nextafter(1, 2) - 1}, false)
+	nc.define("MAX_VALUE", Ref{math.MaxFloat64}, false)
+	nc.define("POSITIVE_INFINITY", Ref{math.Inf(1)}, false)
+	nc.define("NEGATIVE_INFINITY", Ref{math.Inf(-1)}, false)
+	nc.define("NaN", Ref{math.NaN()}, false)
+	nc.defineMethod("isInteger", 1, func(this Ref, args []Ref) Ref {
+		f, ok := arg(args, 0).v.(float64)
+		return Ref{ok && f == math.Trunc(f) && !math.IsInf(f, 0)}
+	})
+	nc.defineMethod("isNaN", 1, func(this Ref, args []Ref) Ref {
+		f, ok := arg(args, 0).v.(float64)
+		return Ref{ok && math.IsNaN(f)}
+	})
+	nc.defineMethod("isFinite", 1, func(this Ref, args []Ref) Ref {
+		f, ok := arg(args, 0).v.(float64)
+		return Ref{ok && !math.IsNaN(f) && !math.IsInf(f, 0)}
+	})
+	g.define("Number", Ref{nc}, false)
+	g.defineMethod("isNaN", 1, func(this Ref, args []Ref) Ref {
+		return Ref{math.IsNaN(toNumber(arg(args, 0)))}
+	})
+	g.defineMethod("parseFloat", 1, func(this Ref, args []Ref) Ref {
+		return Ref{parseNumber(toString(arg(args, 0)))}
+	})
+	g.defineMethod("parseInt", 2, func(this Ref, args []Ref) Ref {
+		s := strings.TrimSpace(toString(arg(args, 0)))
+		base := toInt(arg(args, 1), 10)
+		if base == 0 {
+			base = 10
+		}
+		end := 0
+		for i, c := range s {
+			if (c == '-' || c == '+') && i == 0 {
+				end = i + 1
+				continue
+			}
+			if _, err := strconv.ParseInt(string(c), base, 64); err != nil {
+				break
+			}
+			end = i + 1
+		}
+		n, err := strconv.ParseInt(s[:end], base, 64)
+		if err != nil {
+			return Ref{math.NaN()}
+		}
+		return Ref{float64(n)}
+	})
+
+	// Boolean
+	booleanProto = &jsObject{proto: objectProto, class: "Boolean"}
+	bp := booleanProto
+	bp.defineMethod("toString", 0, func(this Ref, args []Ref) Ref {
+		return Ref{toString(this)}
+	})
+	bp.defineMethod("valueOf", 0, func(this Ref, args []Ref) Ref {
+		return this
+	})
+	bc := newClass("Boolean", 1, bp, func(this Ref, args []Ref) Ref {
+		return Ref{arg(args, 0).Truthy()}
+	}, nil)
+	bc.construct = func(args []Ref) *jsObject {
+		throwTypeError("Boolean objects are not supported by the host JS emulation")
+		return nil
+	}
+	g.define("Boolean", Ref{bc}, false)
+}
+
+// newDOMException creates a DOMException object with a given message and name.
+func newDOMException(msg, name string) *jsObject {
+	e := newObject(domExceptionProto)
+	e.class = "DOMException"
+	e.define("name", Ref{name}, false)
+	e.define("message", Ref{msg}, false)
+	e.define("code", Ref{float64(domExceptionCodes[name])}, false)
+	e.define("stack", Ref{name + ": " + msg + "\n    at <go>"}, false)
+	return e
+}
+
+// domExceptionCodes maps DOMException names to legacy error codes.
+var domExceptionCodes = map[string]int{
+	"IndexSizeError":             1,
+	"HierarchyRequestError":      3,
+	"WrongDocumentError":         4,
+	"InvalidCharacterError":      5,
+	"NoModificationAllowedError": 7,
+	"NotFoundError":              8,
+	"NotSupportedError":          9,
+	"InvalidStateError":          11,
+	"SyntaxError":                12,
+	"InvalidModificationError":   13,
+	"NamespaceError":             14,
+	"InvalidAccessError":         15,
+	"TypeMismatchError":          17,
+	"SecurityError":              18,
+	"NetworkError":               19,
+	"AbortError":                 20,
+	"URLMismatchError":           21,
+	"QuotaExceededError":         22,
+	"TimeoutError":               23,
+	"InvalidNodeTypeError":       24,
+	"DataCloneError":             25,
+}
+
+func setupErrors(g *jsObject) {
+	errorProto = &jsObject{proto: objectProto, class: "Object"}
+	errorProto.define("name", Ref{"Error"}, false)
+	errorProto.define("message", Ref{""}, false)
+	errorProto.defineMethod("toString", 0, func(this Ref, args []Ref) Ref {
+		name := toString(getProp(this, "name"))
+		msg := toString(getProp(this, "message"))
+		switch {
+		case msg == "":
+			return Ref{name}
+		case name == "":
+			return Ref{msg}
+		}
+		return Ref{name + ": " + msg}
+	})
+	newErrorClass := func(name string, proto *jsObject) *jsObject {
+		cons := func(args []Ref) *jsObject {
+			msg := ""
+			if m := arg(args, 0); m.v != nil {
+				msg = toString(m)
+			}
+			e := newError(proto, msg)
+			if m := arg(args, 0); m.v == nil {
+				e.deleteOwn("message")
+			}
+			if opts, ok := arg(args, 1).v.(*jsObject); ok && hasProp(opts, "cause") {
+				e.define("cause", getProp(Ref{opts}, "cause"), false)
+			}
+			return e
+		}
+		c := newClass(name, 1, proto, func(this Ref, args []Ref) Ref {
+			return Ref{cons(args)}
+		}, cons)
+		g.define(name, Ref{c}, false)
+		return c
+	}
+	errorClass := newErrorClass("Error", errorProto)
+	subclass := func(name string) *jsObject {
+		p := newObject(errorProto)
+		p.define("name", Ref{name}, false)
+		p.define("message", Ref{""}, false)
+		c := newErrorClass(name, p)
+		c.proto = errorClass
+		return p
+	}
+	typeErrorProto = subclass("TypeError")
+	rangeErrorProto = subclass("RangeError")
+	syntaxErrorProto = subclass("SyntaxError")
+	subclass("ReferenceError")
+	subclass("EvalError")
+	subclass("URIError")
+
+	// AggregateError(errors, message)
+	aggProto := newObject(errorProto)
+	aggProto.define("name", Ref{"AggregateError"}, false)
+	aggProto.define("message", Ref{""}, false)
+	aggCons := func(args []Ref) *jsObject {
+		e := newError(aggProto, toString(arg(args, 1)))
+		e.define("errors", Ref{newArray(listFromArrayLike(arg(args, 0)))}, false)
+		return e
+	}
+	agg := newClass("AggregateError", 2, aggProto, func(this Ref, args []Ref) Ref {
+		return Ref{aggCons(args)}
+	}, aggCons)
+	agg.proto = errorClass
+	aggregateErrorClass = agg
+	g.define("AggregateError", Ref{agg}, false)
+
+	// DOMException(message, name)
+	domExceptionProto = newObject(errorProto)
+	domProto := domExceptionProto
+	domProto.define("name", Ref{"Error"}, false)
+	domProto.define("message", Ref{""}, false)
+	domProto.define("code", Ref{0.0}, false)
+	dom := newClass("DOMException", 2, domProto, nil, func(args []Ref) *jsObject {
+		name := "Error"
+		if n := arg(args, 1); n.v != nil {
+			name = toString(n)
+		}
+		msg := ""
+		if m := arg(args, 0); m.v != nil {
+			msg = toString(m)
+		}
+		return newDOMException(msg, name)
+	})
+	for name, code := range domExceptionCodes {
+		// legacy constants, like DOMException.ABORT_ERR
+		cname := strings.ToUpper(strings.TrimSuffix(name, "Error"))
+		switch name {
+		case "InvalidCharacterError", "InvalidModificationError", "InvalidAccessError", "InvalidNodeTypeError", "InvalidStateError":
+			cname = "INVALID_" + strings.ToUpper(strings.TrimSuffix(strings.TrimPrefix(name, "Invalid"), "Error"))
+		}
+		dom.define(cname+"_ERR", Ref{float64(code)}, false)
+	}
+	g.define("DOMException", Ref{dom}, false)
+}
+
+func setupMath(g *jsObject) {
+	m := newObject(objectProto)
+	m.define("@@toStringTag", Ref{"Math"}, false)
+	m.define("PI", Ref{math.Pi}, false)
+	m.define("E", Ref{math.E}, false)
+	m.define("LN2", Ref{math.Ln2}, false)
+	m.define("LN10", Ref{math.Ln10}, false)
+	m.define("SQRT2", Ref{math.Sqrt2}, false)
+	fn1 := func(name string, f func(float64) float64) {
+		m.defineMethod(name, 1, func(this Ref, args []Ref) Ref {
+			return Ref{f(toNumber(arg(args, 0)))}
+		})
+	}
+	fn1("abs", math.Abs)
+	fn1("ceil", math.Ceil)
+	fn1("floor", math.Floor)
+	fn1("trunc", math.Trunc)
+	fn1("sqrt", math.Sqrt)
+	fn1("cbrt", math.Cbrt)
+	fn1("exp", math.Exp)
+	fn1("log", math.Log)
+	fn1("log2", math.Log2)
+	fn1("log10", math.Log10)
+	fn1("sin", math.Sin)
+	fn1("cos", math.Cos)
+	fn1("tan", math.Tan)
+	fn1("asin", math.Asin)
+	fn1("acos", math.Acos)
+	fn1("atan", math.Atan)
+	fn1("round", func(f float64) float64 {
+		return math.Floor(f + 0.5)
+	})
+	fn1("sign", func(f float64) float64 {
+		switch {
+		case f > 0:
+			return 1
+		case f < 0:
+			return -1
+		}
+		return f
+	})
+	m.defineMethod("atan2", 2, func(this Ref, args []Ref) Ref {
+		return Ref{math.Atan2(toNumber(arg(args, 0)), toNumber(arg(args, 1)))}
+	})
+	m.defineMethod("pow", 2, func(this Ref, args []Ref) Ref {
+		return Ref{math.Pow(toNumber(arg(args, 0)), toNumber(arg(args, 1)))}
+	})
+	m.defineMethod("random", 0, func(this Ref, args []Ref) Ref {
+		return Ref{rand.Float64()}
+	})
+	m.defineMethod("min", 2, func(this Ref, args []Ref) Ref {
+		r := math.Inf(1)
+		for _, a := range args {
+			f := toNumber(a)
+			if math.IsNaN(f) {
+				return Ref{f}
+			}
+			r = math.Min(r, f)
+		}
+		return Ref{r}
+	})
+	m.defineMethod("max", 2, func(this Ref, args []Ref) Ref {
+		r := math.Inf(-1)
+		for _, a := range args {
+			f := toNumber(a)
+			if math.IsNaN(f) {
+				return Ref{f}
+			}
+			r = math.Max(r, f)
+		}
+		return Ref{r}
+	})
+	g.define("Math", Ref{m}, false)
+}
+
+func setupReflect(g *jsObject) {
+	r := newObject(objectProto)
+	r.define("@@toStringTag", Ref{"Reflect"}, false)
+	target := func(args []Ref) *jsObject {
+		o, ok := arg(args, 0).v.(*jsObject)
+		if !ok {
+			throwTypeError("Reflect method called on non-object")
+		}
+		return o
+	}
+	r.defineMethod("get", 2, func(this Ref, args []Ref) Ref {
+		return getProp(Ref{target(args)}, propertyKey(arg(args, 1)))
+	})
+	r.defineMethod("set", 3, func(this Ref, args []Ref) Ref {
+		setProp(Ref{target(args)}, propertyKey(arg(args, 1)), arg(args, 2))
+		return Ref{true}
+	})
+	r.defineMethod("has", 2, func(this Ref, args []Ref) Ref {
+		return Ref{hasProp(target(args), propertyKey(arg(args, 1)))}
+	})
+	r.defineMethod("deleteProperty", 2, func(this Ref, args []Ref) Ref {
+		return Ref{target(args).deleteOwn(propertyKey(arg(args, 1)))}
+	})
+	r.defineMethod("ownKeys", 1, func(this Ref, args []Ref) Ref {
+		keys := target(args).ownKeys(true)
+		arr := make([]Ref, 0, len(keys))
+		for _, k := range keys {
+			arr = append(arr, Ref{k})
+		}
+		return Ref{newArray(arr)}
+	})
+	r.defineMethod("getPrototypeOf", 1, func(this Ref, args []Ref) Ref {
+		if p := target(args).proto; p != nil {
+			return Ref{p}
+		}
+		return null
+	})
+	r.defineMethod("setPrototypeOf", 2, func(this Ref, args []Ref) Ref {
+		o := target(args)
+		switch p := arg(args, 1).v.(type) {
+		case *jsObject:
+			o.proto = p
+		case jsNull:
+			o.proto = nil
+		default:
+			throwTypeError("Object prototype may only be an Object or null")
+		}
+		return Ref{true}
+	})
+	r.defineMethod("defineProperty", 3, func(this Ref, args []Ref) Ref {
+		definePropertyFromDescriptor(target(args), propertyKey(arg(args, 1)), arg(args, 2))
+		return Ref{true}
+	})
+	r.defineMethod("apply", 3, func(this Ref, args []Ref) Ref {
+		return callFunc(arg(args, 0), arg(args, 1), listFromArrayLike(arg(args, 2)))
+	})
+	r.defineMethod("construct", 2, func(this Ref, args []Ref) Ref {
+		return construct(arg(args, 0), listFromArrayLike(arg(args, 1)))
+	})
+	g.define("Reflect", Ref{r}, false)
+}
+
+// inspect formats a value for console output.
+func inspect(v Ref) string {
+	switch x := v.v.(type) {
+	case string:
+		return x
+	case *jsObject:
+		if x.class == "Error" || x.class == "DOMException" {
+			if s, ok := getProp(v, "stack").v.(string); ok {
+				return s
+			}
+		}
+		if x.class == "Object" || x.class == "Array" {
+			if s, ok := tryStringify(v); ok {
+				return s
+			}
+		}
+	}
+	return toString(v)
+}
+
+func tryStringify(v Ref) (s string, ok bool) {
+	tryCall(func() {
+		s, ok = stringifyJSON(v, "")
+	})
+	return
+}
+
+func setupConsole(g *jsObject) {
+	c := newObject(objectProto)
+	logTo := func(prefix string) nativeFunc {
+		return func(this Ref, args []Ref) Ref {
+			strs := make([]string, 0, len(args))
+			for _, a := range args {
+				strs = append(strs, inspect(a))
+			}
+			fmt.Fprintln(os.Stderr, prefix+strings.Join(strs, " "))
+			return undefined
+		}
+	}
+	c.defineMethod("log", 0, logTo(""))
+	c.defineMethod("info", 0, logTo(""))
+	c.defineMethod("debug", 0, logTo(""))
+	c.defineMethod("warn", 0, logTo("warning: "))
+	c.defineMethod("error", 0, logTo("error: "))
+	g.define("console", Ref{c}, false)
+}
@@ -0,0 +1,268 @@
+//+build !wasm
+
+package js
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+func setupJSON(g *jsObject) {
+	j := newObject(objectProto)
+	j.define("@@toStringTag", Ref{"JSON"}, false)
+	j.defineMethod("parse", 2, func(this Ref, args []Ref) Ref {
+		v := parseJSON(toString(arg(args, 0)))
+		if rev := arg(args, 1); isCallable(rev) {
+			holder := newObject(objectProto)
+			holder.define("", v, true)
+			return reviveJSON(rev, Ref{holder}, "")
+		}
+		return v
+	})
+	j.defineMethod("stringify", 3, func(this Ref, args []Ref) Ref {
+		indent := ""
+		switch sp := arg(args, 2).v.(type) {
+		case float64:
+			n := int(math.Min(10, sp))
+			if n > 0 {
+				indent = strings.Repeat(" ", n)
+			}
+		case string:
+			indent = sp
+			if len(indent) > 10 {
+				indent = indent[:10]
+			}
+		}
+		w := &jsonWriter{indent: indent, seen: make(map[*jsObject]bool)}
+		switch rep := arg(args, 1); {
+		case isCallable(rep):
+			w.replacer = rep
+		case rep.v != nil && rep.v != (jsNull{}):
+			if o, ok := rep.v.(*jsObject); ok && o.class == "Array" {
+				w.keys = make(map[string]bool)
+				for _, k := range arrayElems(rep) {
+					w.keys[toString(k)] = true
+				}
+			}
+		}
+		holder := newObject(objectProto)
+		holder.define("", arg(args, 0), true)
+		if !w.write(Ref{holder}, "", arg(args, 0), "") {
+			return undefined
+		}
+		return Ref{w.buf.String()}
+	})
+	g.define("JSON", Ref{j}, false)
+}
+
+// stringifyJSON is a shorthand for JSON.stringify(v, null, indent).
+// It returns false if the value cannot be represented in JSON.
+func stringifyJSON(v Ref, indent string) (string, bool) {
+	w := &jsonWriter{indent: indent, seen: make(map[*jsObject]bool)}
+	if !w.write(undefined, "", v, "") {
+		return "", false
+	}
+	return w.buf.String(), true
+}
+
+type jsonWriter struct {
+	buf      bytes.Buffer
+	indent   string
+	replacer Ref
+	keys     map[string]bool // property allow-list, if set
+	seen     map[*jsObject]bool
+}
+
+func (w *jsonWriter) writeString(s string) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	w.buf.Write(bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+}
+
+// write serializes the value that is stored in the holder under a given key. It returns false if the value was skipped.
+func (w *jsonWriter) write(holder Ref, key string, v Ref, prefix string) bool {
+	if _, ok := v.v.(*jsObject); ok {
+		if f := getProp(v, "toJSON"); isCallable(f) {
+			v = callFunc(f, v, []Ref{{key}})
+		}
+	}
+	if isCallable(w.replacer) {
+		v = callFunc(w.replacer, holder, []Ref{{key}, v})
+	}
+	switch x := v.v.(type) {
+	case nil, *jsSymbol:
+		return false
+	case jsBigInt:
+		throwTypeError("Do not know how to serialize a BigInt")
+	case jsNull:
+		w.buf.WriteString("null")
+	case bool:
+		w.buf.WriteString(toString(v))
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			w.buf.WriteString("null")
+		} else {
+			w.buf.WriteString(formatNumber(x))
+		}
+	case string:
+		w.writeString(x)
+	case *jsObject:
+		if x.call != nil {
+			return false
+		}
+		if w.seen[x] {
+			throwTypeError("Converting circular structure to JSON")
+		}
+		w.seen[x] = true
+		defer delete(w.seen, x)
+		inner := prefix + w.indent
+		newline := func() {
+			if w.indent != "" {
+				w.buf.WriteByte('\n')
+				w.buf.WriteString(inner)
+			}
+		}
+		if x.class == "Array" {
+			arr := arrayElems(v)
+			w.buf.WriteByte('[')
+			for i, e := range arr {
+				if i != 0 {
+					w.buf.WriteByte(',')
+				}
+				newline()
+				if !w.write(v, strconv.Itoa(i), e, inner) {
+					w.buf.WriteString("null")
+				}
+			}
+			if len(arr) != 0 && w.indent != "" {
+				w.buf.WriteByte('\n')
+				w.buf.WriteString(prefix)
+			}
+			w.buf.WriteByte(']')
+			return true
+		}
+		w.buf.WriteByte('{')
+		n := 0
+		for _, k := range x.ownKeys(false) {
+			if w.keys != nil && !w.keys[k] {
+				continue
+			}
+			mark := w.buf.Len()
+			if n != 0 {
+				w.buf.WriteByte(',')
+			}
+			newline()
+			w.writeString(k)
+			w.buf.WriteByte(':')
+			if w.indent != "" {
+				w.buf.WriteByte(' ')
+			}
+			if !w.write(v, k, getProp(v, k), inner) {
+				w.buf.Truncate(mark)
+				continue
+			}
+			n++
+		}
+		if n != 0 && w.indent != "" {
+			w.buf.WriteByte('\n')
+			w.buf.WriteString(prefix)
+		}
+		w.buf.WriteByte('}')
+	}
+	return true
+}
+
+// parseJSON implements JSON.parse. It throws a SyntaxError if the text is not a valid JSON.
+func parseJSON(s string) Ref {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	v, err := parseJSONValue(dec)
+	if err == nil {
+		if _, err = dec.Token(); err == io.EOF {
+			return v
+		} else if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+	}
+	throwError(syntaxErrorProto, "Unexpected token in JSON: "+err.Error())
+	return undefined
+}
+
+func parseJSONValue(dec *json.Decoder) (Ref, error) {
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return undefined, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return undefined, err
+	}
+	switch t := tok.(type) {
+	case nil:
+		return null, nil
+	case bool:
+		return Ref{t}, nil
+	case string:
+		return Ref{t}, nil
+	case json.Number:
+		f, err := strconv.ParseFloat(string(t), 64)
+		if err != nil && !strings.Contains(err.Error(), "range") {
+			return undefined, err
+		}
+		return Ref{f}, nil
+	case json.Delim:
+		switch t {
+		case '[':
+			arr := []Ref{}
+			for dec.More() {
+				v, err := parseJSONValue(dec)
+				if err != nil {
+					return undefined, err
+				}
+				arr = append(arr, v)
+			}
+			if _, err := dec.Token(); err != nil {
+				return undefined, err
+			}
+			return Ref{newArray(arr)}, nil
+		case '{':
+			obj := newObject(objectProto)
+			for dec.More() {
+				k, err := dec.Token()
+				if err != nil {
+					return undefined, err
+				}
+				v, err := parseJSONValue(dec)
+				if err != nil {
+					return undefined, err
+				}
+				obj.define(k.(string), v, true)
+			}
+			if _, err := dec.Token(); err != nil {
+				return undefined, err
+			}
+			return Ref{obj}, nil
+		}
+	}
+	return undefined, io.ErrUnexpectedEOF
+}
+
+// reviveJSON applies a reviver function of JSON.parse to the parsed value.
+func reviveJSON(rev Ref, holder Ref, key string) Ref {
+	v := getProp(holder, key)
+	if o, ok := v.v.(*jsObject); ok {
+		for _, k := range o.ownKeys(false) {
+			nv := reviveJSON(rev, v, k)
+			if nv.v == nil {
+				o.deleteOwn(k)
+			} else {
+				setProp(v, k, nv)
+			}
+		}
+	}
+	return callFunc(rev, holder, []Ref{{key}, v})
+}
@@ -0,0 +1,746 @@
+//+build !wasm
+
+package js
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// This file implements an object model of the in-memory JavaScript emulation used on non-wasm builds.
+//
+// Values are stored directly in Ref: nil is undefined, jsNull is null, and bool, float64, string and *jsObject
+// represent corresponding JS types. This allows to compare Refs with == according to JS === semantics.
+
+type jsNull struct{}
+
+// nativeFunc is a Go implementation of a JS function.
+type nativeFunc func(this Ref, args []Ref) Ref
+
+// property is a data or accessor property of an object.
+type property struct {
+	value    Ref
+	get, set *jsObject // accessor functions, if any
+	enum     bool      // enumerable
+}
+
+func (p *property) isAccessor() bool {
+	return p.get != nil || p.set != nil
+}
+
+// object is an emulated JS object. Arrays, functions and other builtin classes are
+// objects with a specific class name and additional internal state.
+type jsObject struct {
+	mu    sync.Mutex
+	proto *jsObject
+	class string // internal class name, as reported by Object.prototype.toString
+	props map[string]*property
+	keys  []string // insertion order of props
+	arr   []Ref    // elements, if the object is an Array
+
+	call      nativeFunc                 // function implementation, if the object is callable
+	construct func(args []Ref) *jsObject // builtin constructor implementation; generic constructor is used if nil
+
+	data interface{} // internal slots of builtin classes
+}
+
+func newObject(proto *jsObject) *jsObject {
+	return &jsObject{proto: proto, class: "Object"}
+}
+
+func newArray(vals []Ref) *jsObject {
+	return &jsObject{proto: arrayProto, class: "Array", arr: vals}
+}
+
+// newFunc creates a new native function object.
+func newFunc(name string, n int, fnc nativeFunc) *jsObject {
+	f := &jsObject{proto: funcProto, class: "Function", call: fnc}
+	f.define("name", Ref{name}, false)
+	f.define("length", Ref{float64(n)}, false)
+	return f
+}
+
+// newClass creates a new native constructor function with a given prototype object.
+// If construct is nil, the constructor will call the function with a new object as this.
+func newClass(name string, n int, proto *jsObject, fnc nativeFunc, construct func(args []Ref) *jsObject) *jsObject {
+	if fnc == nil {
+		fnc = func(this Ref, args []Ref) Ref {
+			throwTypeError("Class constructor " + name + " cannot be invoked without 'new'")
+			return undefined
+		}
+	}
+	c := newFunc(name, n, fnc)
+	c.construct = construct
+	c.define("prototype", Ref{proto}, false)
+	proto.define("constructor", Ref{c}, false)
+	return c
+}
+
+// define sets an own data property.
+func (o *jsObject) define(key string, v Ref, enum bool) {
+	o.defineProp(key, &property{value: v, enum: enum})
+}
+
+// defineMethod sets an own non-enumerable function property.
+func (o *jsObject) defineMethod(name string, n int, fnc nativeFunc) {
+	o.define(name, Ref{newFunc(name, n, fnc)}, false)
+}
+
+// defineGetter sets an own accessor property with a given getter and an optional setter.
+func (o *jsObject) defineGetter(name string, get nativeFunc, set nativeFunc) {
+	p := &property{get: newFunc("get "+name, 0, get)}
+	if set != nil {
+		p.set = newFunc("set "+name, 1, set)
+	}
+	o.defineProp(name, p)
+}
+
+func (o *jsObject) defineProp(key string, p *property) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.class == "Array" {
+		if i, ok := arrayIndex(key); ok && !p.isAccessor() {
+			o.setIndexLocked(i, p.value)
+			return
+		}
+	}
+	if o.props == nil {
+		o.props = make(map[string]*property)
+	}
+	if _, ok := o.props[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.props[key] = p
+}
+
+// arrayIndex checks if the key is a canonical array index.
+func arrayIndex(key string) (int, bool) {
+	if key == "" || (len(key) > 1 && key[0] == '0') {
+		return 0, false
+	}
+	for _, c := range key {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	i, err := strconv.Atoi(key)
+	if err != nil || i >= math.MaxInt32 {
+		return 0, false
+	}
+	return i, true
+}
+
+func (o *jsObject) setIndexLocked(i int, v Ref) {
+	if i >= len(o.arr) {
+		arr := make([]Ref, i+1)
+		copy(arr, o.arr)
+		o.arr = arr
+	}
+	o.arr[i] = v
+}
+
+// getOwn returns an own property of the object. Array elements and typed array elements are returned as data properties.
+func (o *jsObject) getOwn(key string) (*property, bool) {
+	if ta, ok := o.data.(*typedArray); ok {
+		ta.sync()
+		if i, ok := arrayIndex(key); ok {
+			if i >= ta.n {
+				return nil, false
+			}
+			return &property{value: ta.get(i), enum: true}, true
+		}
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.class == "Array" {
+		if key == "length" {
+			return &property{value: Ref{float64(len(o.arr))}}, true
+		}
+		if i, ok := arrayIndex(key); ok {
+			if i >= len(o.arr) {
+				return nil, false
+			}
+			return &property{value: o.arr[i], enum: true}, true
+		}
+	}
+	p, ok := o.props[key]
+	return p, ok
+}
+
+// lookup searches for a property in the object and its prototype chain.
+func (o *jsObject) lookup(key string) (*property, bool) {
+	for cur := o; cur != nil; cur = cur.proto {
+		if p, ok := cur.getOwn(key); ok {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// ownKeys returns own property names, including array indexes. If all is false, only enumerable keys are returned.
+func (o *jsObject) ownKeys(all bool) []string {
+	var keys []string
+	if ta, ok := o.data.(*typedArray); ok {
+		ta.sync()
+		for i := 0; i < ta.n; i++ {
+			keys = append(keys, strconv.Itoa(i))
+		}
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.class == "Array" {
+		// holes are not emulated, thus all the indexes are reported as present
+		for i := range o.arr {
+			keys = append(keys, strconv.Itoa(i))
+		}
+		if all {
+			keys = append(keys, "length")
+		}
+	}
+	var ints []int
+	var strs []string
+	for _, k := range o.keys {
+		if strings.HasPrefix(k, symbolPrefix) {
+			continue
+		}
+		if !all && !o.props[k].enum {
+			continue
+		}
+		if i, ok := arrayIndex(k); ok {
+			ints = append(ints, i)
+		} else {
+			strs = append(strs, k)
+		}
+	}
+	// integer keys go first in ascending order, as in JS
+	sort.Ints(ints)
+	for _, i := range ints {
+		keys = append(keys, strconv.Itoa(i))
+	}
+	return append(keys, strs...)
+}
+
+// deleteOwn removes an own property of the object.
+func (o *jsObject) deleteOwn(key string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.class == "Array" {
+		if key == "length" {
+			return false
+		}
+		if i, ok := arrayIndex(key); ok {
+			if i < len(o.arr) {
+				o.arr[i] = undefined
+			}
+			return true
+		}
+	}
+	if _, ok := o.props[key]; !ok {
+		return true
+	}
+	delete(o.props, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i:i], o.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// isCallable checks if the value is a function.
+func isCallable(v Ref) bool {
+	o, ok := v.v.(*jsObject)
+	return ok && o.call != nil
+}
+
+// protoOf returns the prototype used for property lookups on the value.
+func protoOf(v Ref) *jsObject {
+	switch x := v.v.(type) {
+	case *jsObject:
+		return x
+	case string:
+		return stringProto
+	case float64:
+		return numberProto
+	case bool:
+		return booleanProto
+	case *jsSymbol:
+		return symbolProto
+	case jsBigInt:
+		return bigintProto
+	}
+	return nil
+}
+
+// getProp implements JS property access (v[key]).
+func getProp(v Ref, key string) Ref {
+	switch x := v.v.(type) {
+	case nil, jsNull:
+		throwTypeError("Cannot read properties of " + toString(v) + " (reading '" + key + "')")
+	case string:
+		if key == "length" {
+			return Ref{float64(len(utf16.Encode([]rune(x))))}
+		}
+		if i, ok := arrayIndex(key); ok {
+			s := utf16.Encode([]rune(x))
+			if i < len(s) {
+				return Ref{string(utf16.Decode(s[i : i+1]))}
+			}
+			return undefined
+		}
+	}
+	o := protoOf(v)
+	if o == nil {
+		return undefined
+	}
+	p, ok := o.lookup(key)
+	if !ok {
+		return undefined
+	}
+	if p.isAccessor() {
+		if p.get == nil {
+			return undefined
+		}
+		return p.get.call(v, nil)
+	}
+	return p.value
+}
+
+// setProp implements JS property assignment (v[key] = x).
+func setProp(v Ref, key string, x Ref) {
+	o, ok := v.v.(*jsObject)
+	if !ok {
+		switch v.v.(type) {
+		case nil, jsNull:
+			throwTypeError("Cannot set properties of " + toString(v) + " (setting '" + key + "')")
+		}
+		// assignments to primitives are ignored
+		return
+	}
+	if ta, ok := o.data.(*typedArray); ok {
+		ta.sync()
+		if i, ok := arrayIndex(key); ok {
+			if i < ta.n {
+				ta.set(i, toNumber(x))
+			}
+			return
+		}
+	}
+	if o.class == "Array" {
+		if key == "length" {
+			n := toNumber(x)
+			if n < 0 || n != math.Trunc(n) || n > math.MaxInt32 {
+				throwError(rangeErrorProto, "Invalid array length")
+			}
+			o.mu.Lock()
+			if l := int(n); l <= len(o.arr) {
+				o.arr = o.arr[:l]
+			} else {
+				arr := make([]Ref, l)
+				copy(arr, o.arr)
+				o.arr = arr
+			}
+			o.mu.Unlock()
+			return
+		}
+		if i, ok := arrayIndex(key); ok {
+			o.mu.Lock()
+			o.setIndexLocked(i, x)
+			o.mu.Unlock()
+			return
+		}
+	}
+	if p, ok := o.lookup(key); ok && p.isAccessor() {
+		if p.set != nil {
+			p.set.call(v, []Ref{x})
+		}
+		return
+	}
+	o.mu.Lock()
+	if p, ok := o.props[key]; ok {
+		p.value = x
+		o.mu.Unlock()
+		return
+	}
+	o.mu.Unlock()
+	o.define(key, x, true)
+}
+
+// hasProp implements JS "in" operator.
+func hasProp(o *jsObject, key string) bool {
+	_, ok := o.lookup(key)
+	return ok
+}
+
+// callFunc calls a JS function with a given this value.
+func callFunc(fnc Ref, this Ref, args []Ref) Ref {
+	o, ok := fnc.v.(*jsObject)
+	if !ok || o.call == nil {
+		throwTypeError(typeOf(fnc) + " is not a function")
+	}
+	return o.call(this, args)
+}
+
+// callMethod calls a method of the value by name.
+func callMethod(v Ref, name string, args ...Ref) Ref {
+	fnc := getProp(v, name)
+	if !isCallable(fnc) {
+		throwTypeError(name + " is not a function")
+	}
+	return callFunc(fnc, v, args)
+}
+
+// construct implements JS "new" operator.
+func construct(fnc Ref, args []Ref) Ref {
+	c, ok := fnc.v.(*jsObject)
+	if !ok || c.call == nil {
+		throwTypeError(typeOf(fnc) + " is not a constructor")
+	}
+	if c.construct != nil {
+		return Ref{c.construct(args)}
+	}
+	proto, ok := getProp(fnc, "prototype").v.(*jsObject)
+	if !ok {
+		proto = objectProto
+	}
+	obj := Ref{newObject(proto)}
+	res := c.call(obj, args)
+	if _, ok := res.v.(*jsObject); ok {
+		return res
+	}
+	return obj
+}
+
+// instanceOf implements JS "instanceof" operator.
+func instanceOf(v Ref, class Ref) bool {
+	if !isCallable(class) {
+		throwTypeError("Right-hand side of 'instanceof' is not callable")
+	}
+	o, ok := v.v.(*jsObject)
+	if !ok {
+		return false
+	}
+	proto, ok := getProp(class, "prototype").v.(*jsObject)
+	if !ok {
+		return false
+	}
+	for cur := o.proto; cur != nil; cur = cur.proto {
+		if cur == proto {
+			return true
+		}
+	}
+	return false
+}
+
+// throw raises a JS exception.
+func throw(v Ref) {
+	panic(Error{Value: v})
+}
+
+// newError creates a new error object with a given prototype.
+func newError(proto *jsObject, msg string) *jsObject {
+	e := newObject(proto)
+	e.class = "Error"
+	e.define("message", Ref{msg}, false)
+	name := toString(getProp(Ref{e}, "name"))
+	e.define("stack", Ref{name + ": " + msg + "\n    at <go>"}, false)
+	return e
+}
+
+// throwError raises a JS exception with a new error object.
+func throwError(proto *jsObject, msg string) {
+	throw(Ref{newError(proto, msg)})
+}
+
+func throwTypeError(msg string) {
+	throwError(typeErrorProto, msg)
+}
+
+// tryCall runs the function and returns a JS exception that was thrown by it, if any.
+func tryCall(fnc func()) (thrown Ref, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, isErr := r.(Error)
+			if !isErr {
+				panic(r)
+			}
+			thrown, ok = e.Value, false
+		}
+	}()
+	fnc()
+	return undefined, true
+}
+
+// typeOf implements JS "typeof" operator.
+func typeOf(v Ref) string {
+	switch x := v.v.(type) {
+	case nil:
+		return "undefined"
+	case jsNull:
+		return "object"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case *jsSymbol:
+		return "symbol"
+	case jsBigInt:
+		return "bigint"
+	case *jsObject:
+		if x.call != nil {
+			return "function"
+		}
+		return "object"
+	}
+	panic("unexpected value type")
+}
+
+// toString implements JS ToString conversion.
+func toString(v Ref) string {
+	switch x := v.v.(type) {
+	case nil:
+		return "undefined"
+	case jsNull:
+		return "null"
+	case bool:
+		if x {
+			return "true"
+		}
+		return "false"
+	case float64:
+		return formatNumber(x)
+	case string:
+		return x
+	case *jsSymbol:
+		throwTypeError("Cannot convert a Symbol value to a string")
+	case jsBigInt:
+		return string(x)
+	case *jsObject:
+		if d, ok := x.data.(*dateData); ok && x.class == "Date" {
+			return d.String()
+		}
+		for _, name := range []string{"toString", "valueOf"} {
+			if f := getProp(v, name); isCallable(f) {
+				r := callFunc(f, v, nil)
+				if _, isObj := r.v.(*jsObject); !isObj {
+					return toString(r)
+				}
+			}
+		}
+		throwTypeError("Cannot convert object to primitive value")
+	}
+	panic("unexpected value type")
+}
+
+// toNumber implements JS ToNumber conversion.
+func toNumber(v Ref) float64 {
+	switch x := v.v.(type) {
+	case nil:
+		return math.NaN()
+	case jsNull:
+		return 0
+	case bool:
+		if x {
+			return 1
+		}
+		return 0
+	case float64:
+		return x
+	case string:
+		return parseNumber(x)
+	case *jsSymbol:
+		throwTypeError("Cannot convert a Symbol value to a number")
+	case jsBigInt:
+		return x.float()
+	case *jsObject:
+		if f := getProp(v, "valueOf"); isCallable(f) {
+			r := callFunc(f, v, nil)
+			if _, isObj := r.v.(*jsObject); !isObj {
+				return toNumber(r)
+			}
+		}
+		return parseNumber(toString(v))
+	}
+	panic("unexpected value type")
+}
+
+func parseNumber(s string) float64 {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "":
+		return 0
+	case "Infinity", "+Infinity":
+		return math.Inf(1)
+	case "-Infinity":
+		return math.Inf(-1)
+	}
+	if len(s) > 2 && s[0] == '0' {
+		base := 0
+		switch s[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 0 {
+			n, err := strconv.ParseUint(s[2:], base, 64)
+			if err != nil {
+				return math.NaN()
+			}
+			return float64(n)
+		}
+	}
+	for _, c := range s {
+		// reject Go-specific syntax like "inf", "nan" and "0x1p-2"
+		if !(c >= '0' && c <= '9') && !strings.ContainsRune(".eE+-", c) {
+			return math.NaN()
+		}
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
+}
+
+// formatNumber formats the number the same way as JS Number.prototype.toString.
+func formatNumber(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == 0:
+		return "0"
+	}
+	sign := ""
+	if f < 0 {
+		sign, f = "-", -f
+	}
+	// shortest representation: d.ddde±x
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	i := strings.IndexByte(s, 'e')
+	digits := strings.Replace(s[:i], ".", "", 1)
+	exp, _ := strconv.Atoi(s[i+1:])
+	k, n := len(digits), exp+1
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits
+	}
+	e := "e+"
+	if n-1 < 0 {
+		e = "e-"
+	}
+	m := digits[:1]
+	if k > 1 {
+		m += "." + digits[1:]
+	}
+	return sign + m + e + strconv.Itoa(abs(n-1))
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// toObject converts the value to an object, or throws if it is null or undefined.
+func toObject(v Ref) *jsObject {
+	switch x := v.v.(type) {
+	case *jsObject:
+		return x
+	case nil, jsNull:
+		throwTypeError("Cannot convert undefined or null to object")
+	}
+	// primitive wrappers are not emulated; return an empty object with a correct prototype
+	return newObject(protoOf(v))
+}
+
+// toInt converts an argument to an integer, using a default value for undefined.
+func toInt(v Ref, def int) int {
+	if v.v == nil {
+		return def
+	}
+	f := toNumber(v)
+	if math.IsNaN(f) {
+		return 0
+	}
+	if f > math.MaxInt32 {
+		return math.MaxInt32
+	} else if f < math.MinInt32 {
+		return math.MinInt32
+	}
+	return int(f)
+}
+
+// relIndex resolves a relative index argument (as used by slice, subarray, etc) for a given length.
+func relIndex(v Ref, n, def int) int {
+	i := toInt(v, def)
+	if i < 0 {
+		i += n
+		if i < 0 {
+			i = 0
+		}
+	} else if i > n {
+		i = n
+	}
+	return i
+}
+
+// arg returns i-th argument or undefined.
+func arg(args []Ref, i int) Ref {
+	if i < len(args) {
+		return args[i]
+	}
+	return undefined
+}
+
+// sameValueZero implements the comparison used by Array.prototype.includes.
+func sameValueZero(a, b Ref) bool {
+	if x, ok := a.v.(float64); ok {
+		if y, ok := b.v.(float64); ok && math.IsNaN(x) && math.IsNaN(y) {
+			return true
+		}
+	}
+	return a == b
+}
+
+// listFromArrayLike converts an array-like object to a slice of values.
+func listFromArrayLike(v Ref) []Ref {
+	o, ok := v.v.(*jsObject)
+	if !ok {
+		if v.v == nil || v.v == (jsNull{}) {
+			throwTypeError("CreateListFromArrayLike called on non-object")
+		}
+		return nil
+	}
+	if o.class == "Array" {
+		o.mu.Lock()
+		out := make([]Ref, len(o.arr))
+		copy(out, o.arr)
+		o.mu.Unlock()
+		return out
+	}
+	n := toInt(getProp(v, "length"), 0)
+	out := make([]Ref, 0, n)
+	for i := 0; i < n; i++ {
+		out = append(out, getProp(v, strconv.Itoa(i)))
+	}
+	return out
+}
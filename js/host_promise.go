@@ -0,0 +1,470 @@
+//+build !wasm
+
+package js
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// loop is an event loop of the JS emulation. All the callbacks scheduled by promises and timers are
+// executed sequentially on a single goroutine, while calls from Go are executed on the caller's goroutine.
+var loop = newEventLoop()
+
+type eventLoop struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	micro []func()
+	macro []func()
+
+	timerID int
+	timers  map[int]*time.Timer
+}
+
+func newEventLoop() *eventLoop {
+	l := &eventLoop{timers: make(map[int]*time.Timer)}
+	l.cond = sync.NewCond(&l.mu)
+	go l.run()
+	return l
+}
+
+// queueMicrotask schedules a function to run before any pending macrotask.
+func (l *eventLoop) queueMicrotask(fnc func()) {
+	l.mu.Lock()
+	l.micro = append(l.micro, fnc)
+	l.mu.Unlock()
+	l.cond.Signal()
+}
+
+// queueTask schedules a function to run after all the pending tasks.
+func (l *eventLoop) queueTask(fnc func()) {
+	l.mu.Lock()
+	l.macro = append(l.macro, fnc)
+	l.mu.Unlock()
+	l.cond.Signal()
+}
+
+func (l *eventLoop) next() func() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for len(l.micro) == 0 && len(l.macro) == 0 {
+		l.cond.Wait()
+	}
+	var fnc func()
+	if len(l.micro) != 0 {
+		fnc = l.micro[0]
+		l.micro[0] = nil
+		l.micro = l.micro[1:]
+	} else {
+		fnc = l.macro[0]
+		l.macro[0] = nil
+		l.macro = l.macro[1:]
+	}
+	return fnc
+}
+
+func (l *eventLoop) run() {
+	for {
+		fnc := l.next()
+		if e, ok := tryCall(fnc); !ok {
+			fmt.Fprintln(os.Stderr, "Uncaught "+inspect(e))
+		}
+	}
+}
+
+// setTimer schedules a function to run after a given delay. If repeat is set, the function is called periodically.
+func (l *eventLoop) setTimer(fnc func(), d time.Duration, repeat bool) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.timerID++
+	id := l.timerID
+	var t *time.Timer
+	t = time.AfterFunc(d, func() {
+		l.queueTask(func() {
+			l.mu.Lock()
+			_, ok := l.timers[id]
+			if ok && !repeat {
+				delete(l.timers, id)
+			}
+			l.mu.Unlock()
+			if !ok {
+				// the timer was cleared after it fired
+				return
+			}
+			fnc()
+			if repeat {
+				l.mu.Lock()
+				if _, ok := l.timers[id]; ok {
+					t.Reset(d)
+				}
+				l.mu.Unlock()
+			}
+		})
+	})
+	l.timers[id] = t
+	return id
+}
+
+func (l *eventLoop) clearTimer(id int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if t, ok := l.timers[id]; ok {
+		t.Stop()
+		delete(l.timers, id)
+	}
+}
+
+func setupTimers(g *jsObject) {
+	set := func(repeat bool) nativeFunc {
+		return func(this Ref, args []Ref) Ref {
+			fnc := arg(args, 0)
+			if !isCallable(fnc) {
+				throwTypeError("The \"callback\" argument must be of type function")
+			}
+			d := toNumber(arg(args, 1))
+			if !(d > 0) {
+				d = 0
+			}
+			var rest []Ref
+			if len(args) > 2 {
+				rest = append(rest, args[2:]...)
+			}
+			id := loop.setTimer(func() {
+				callFunc(fnc, undefined, rest)
+			}, time.Duration(d*float64(time.Millisecond)), repeat)
+			return Ref{float64(id)}
+		}
+	}
+	clear := func(this Ref, args []Ref) Ref {
+		if id, ok := arg(args, 0).v.(float64); ok {
+			loop.clearTimer(int(id))
+		}
+		return undefined
+	}
+	g.defineMethod("setTimeout", 2, set(false))
+	g.defineMethod("setInterval", 2, set(true))
+	g.defineMethod("clearTimeout", 1, clear)
+	g.defineMethod("clearInterval", 1, clear)
+	g.defineMethod("queueMicrotask", 1, func(this Ref, args []Ref) Ref {
+		fnc := arg(args, 0)
+		if !isCallable(fnc) {
+			throwTypeError("The \"callback\" argument must be of type function")
+		}
+		loop.queueMicrotask(func() {
+			callFunc(fnc, undefined, nil)
+		})
+		return undefined
+	})
+}
+
+var promiseProto *jsObject
+
+const (
+	promisePending = iota
+	promiseFulfilled
+	promiseRejected
+)
+
+// promiseData is an internal state of a Promise object.
+type promiseData struct {
+	mu        sync.Mutex
+	state     int
+	value     Ref
+	reactions []func()
+}
+
+// newPromise creates a pending promise and returns functions that settle it.
+// Only the first call of either function has an effect.
+func newPromise() (p *jsObject, resolve, reject func(v Ref)) {
+	d := &promiseData{}
+	p = newObject(promiseProto)
+	p.class = "Promise"
+	p.data = d
+	var once sync.Once
+	resolve = func(v Ref) {
+		once.Do(func() { resolvePromise(p, v) })
+	}
+	reject = func(v Ref) {
+		once.Do(func() { settlePromiseData(d, promiseRejected, v) })
+	}
+	return p, resolve, reject
+}
+
+// resolvePromise resolves the promise with a value, following thenables.
+func resolvePromise(p *jsObject, v Ref) {
+	d := p.data.(*promiseData)
+	if o, ok := v.v.(*jsObject); ok {
+		if o == p {
+			settlePromiseData(d, promiseRejected, Ref{newError(typeErrorProto, "Chaining cycle detected for promise")})
+			return
+		}
+		var then Ref
+		if e, ok := tryCall(func() { then = getProp(v, "then") }); !ok {
+			settlePromiseData(d, promiseRejected, e)
+			return
+		}
+		if isCallable(then) {
+			loop.queueMicrotask(func() {
+				var once sync.Once
+				res := newFunc("", 1, func(_ Ref, args []Ref) Ref {
+					once.Do(func() { resolvePromise(p, arg(args, 0)) })
+					return undefined
+				})
+				rej := newFunc("", 1, func(_ Ref, args []Ref) Ref {
+					once.Do(func() { settlePromiseData(d, promiseRejected, arg(args, 0)) })
+					return undefined
+				})
+				if e, ok := tryCall(func() { callFunc(then, v, []Ref{{res}, {rej}}) }); !ok {
+					once.Do(func() { settlePromiseData(d, promiseRejected, e) })
+				}
+			})
+			return
+		}
+	}
+	settlePromiseData(d, promiseFulfilled, v)
+}
+
+func settlePromiseData(d *promiseData, state int, v Ref) {
+	d.mu.Lock()
+	if d.state != promisePending {
+		d.mu.Unlock()
+		return
+	}
+	d.state, d.value = state, v
+	reactions := d.reactions
+	d.reactions = nil
+	d.mu.Unlock()
+	for _, r := range reactions {
+		loop.queueMicrotask(r)
+	}
+}
+
+func promiseThis(v Ref) *promiseData {
+	if o, ok := v.v.(*jsObject); ok {
+		if d, ok := o.data.(*promiseData); ok {
+			return d
+		}
+	}
+	throwTypeError("Method Promise.prototype.then called on incompatible receiver " + toString(v))
+	return nil
+}
+
+// promiseThen registers handlers on the promise and returns a derived promise.
+// Handlers that are not callable pass the value or the reason to the derived promise.
+func promiseThen(d *promiseData, onFulfilled, onRejected Ref) *jsObject {
+	p, resolve, reject := newPromise()
+	reaction := func() {
+		d.mu.Lock()
+		state, v := d.state, d.value
+		d.mu.Unlock()
+		h := onFulfilled
+		if state == promiseRejected {
+			h = onRejected
+		}
+		if !isCallable(h) {
+			if state == promiseRejected {
+				reject(v)
+			} else {
+				resolve(v)
+			}
+			return
+		}
+		var r Ref
+		if e, ok := tryCall(func() { r = callFunc(h, undefined, []Ref{v}) }); !ok {
+			reject(e)
+			return
+		}
+		resolve(r)
+	}
+	d.mu.Lock()
+	if d.state == promisePending {
+		d.reactions = append(d.reactions, reaction)
+		d.mu.Unlock()
+	} else {
+		d.mu.Unlock()
+		loop.queueMicrotask(reaction)
+	}
+	return p
+}
+
+// promiseResolve implements Promise.resolve.
+func promiseResolve(v Ref) *jsObject {
+	if o, ok := v.v.(*jsObject); ok {
+		if _, ok := o.data.(*promiseData); ok {
+			return o
+		}
+	}
+	p, resolve, _ := newPromise()
+	resolve(v)
+	return p
+}
+
+func setupPromise(g *jsObject) {
+	promiseProto = newObject(objectProto)
+	pp := promiseProto
+	pp.define("@@toStringTag", Ref{"Promise"}, false)
+	pp.defineMethod("then", 2, func(this Ref, args []Ref) Ref {
+		return Ref{promiseThen(promiseThis(this), arg(args, 0), arg(args, 1))}
+	})
+	pp.defineMethod("catch", 1, func(this Ref, args []Ref) Ref {
+		return Ref{promiseThen(promiseThis(this), undefined, arg(args, 0))}
+	})
+	pp.defineMethod("finally", 1, func(this Ref, args []Ref) Ref {
+		d := promiseThis(this)
+		fnc := arg(args, 0)
+		if !isCallable(fnc) {
+			return Ref{promiseThen(d, fnc, fnc)}
+		}
+		onFulfilled := newFunc("", 1, func(_ Ref, args []Ref) Ref {
+			v := arg(args, 0)
+			r := promiseResolve(callFunc(fnc, undefined, nil))
+			return Ref{promiseThen(r.data.(*promiseData), Ref{newFunc("", 0, func(_ Ref, _ []Ref) Ref {
+				return v
+			})}, undefined)}
+		})
+		onRejected := newFunc("", 1, func(_ Ref, args []Ref) Ref {
+			e := arg(args, 0)
+			r := promiseResolve(callFunc(fnc, undefined, nil))
+			return Ref{promiseThen(r.data.(*promiseData), Ref{newFunc("", 0, func(_ Ref, _ []Ref) Ref {
+				throw(e)
+				return undefined
+			})}, undefined)}
+		})
+		return Ref{promiseThen(d, Ref{onFulfilled}, Ref{onRejected})}
+	})
+
+	c := newClass("Promise", 1, pp, nil, func(args []Ref) *jsObject {
+		exec := arg(args, 0)
+		if !isCallable(exec) {
+			throwTypeError("Promise resolver " + toString(exec) + " is not a function")
+		}
+		p, resolve, reject := newPromise()
+		res := newFunc("", 1, func(_ Ref, args []Ref) Ref {
+			resolve(arg(args, 0))
+			return undefined
+		})
+		rej := newFunc("", 1, func(_ Ref, args []Ref) Ref {
+			reject(arg(args, 0))
+			return undefined
+		})
+		if e, ok := tryCall(func() { callFunc(exec, undefined, []Ref{{res}, {rej}}) }); !ok {
+			reject(e)
+		}
+		return p
+	})
+	c.defineMethod("resolve", 1, func(this Ref, args []Ref) Ref {
+		return Ref{promiseResolve(arg(args, 0))}
+	})
+	c.defineMethod("reject", 1, func(this Ref, args []Ref) Ref {
+		p, _, reject := newPromise()
+		reject(arg(args, 0))
+		return Ref{p}
+	})
+
+	// combine implements the common part of Promise combinators. The onSettled function is called
+	// for each settled input promise in order of settlement; it returns true to stop the iteration.
+	// The done function is called if all the promises were settled without stopping the iteration.
+	type combinator func(n int, resolve, reject func(Ref)) (onSettled func(i int, ok bool, v Ref) bool, done func())
+	combine := func(name string, comb combinator) {
+		c.defineMethod(name, 1, func(this Ref, args []Ref) Ref {
+			list := listFromArrayLike(arg(args, 0))
+			p, resolve, reject := newPromise()
+			onSettled, done := comb(len(list), resolve, reject)
+			var (
+				mu      sync.Mutex
+				left    = len(list)
+				stopped bool
+			)
+			settled := func(i int, ok bool, v Ref) {
+				mu.Lock()
+				defer mu.Unlock()
+				if stopped {
+					return
+				}
+				if onSettled(i, ok, v) {
+					stopped = true
+					return
+				}
+				left--
+				if left == 0 {
+					done()
+				}
+			}
+			if len(list) == 0 {
+				done()
+				return Ref{p}
+			}
+			for i, v := range list {
+				i := i
+				d := promiseResolve(v).data.(*promiseData)
+				promiseThen(d, Ref{newFunc("", 1, func(_ Ref, args []Ref) Ref {
+					settled(i, true, arg(args, 0))
+					return undefined
+				})}, Ref{newFunc("", 1, func(_ Ref, args []Ref) Ref {
+					settled(i, false, arg(args, 0))
+					return undefined
+				})})
+			}
+			return Ref{p}
+		})
+	}
+	combine("all", func(n int, resolve, reject func(Ref)) (func(int, bool, Ref) bool, func()) {
+		vals := make([]Ref, n)
+		return func(i int, ok bool, v Ref) bool {
+				if !ok {
+					reject(v)
+					return true
+				}
+				vals[i] = v
+				return false
+			}, func() {
+				resolve(Ref{newArray(vals)})
+			}
+	})
+	combine("allSettled", func(n int, resolve, reject func(Ref)) (func(int, bool, Ref) bool, func()) {
+		vals := make([]Ref, n)
+		return func(i int, ok bool, v Ref) bool {
+				r := newObject(objectProto)
+				if ok {
+					r.define("status", Ref{"fulfilled"}, true)
+					r.define("value", v, true)
+				} else {
+					r.define("status", Ref{"rejected"}, true)
+					r.define("reason", v, true)
+				}
+				vals[i] = Ref{r}
+				return false
+			}, func() {
+				resolve(Ref{newArray(vals)})
+			}
+	})
+	combine("race", func(n int, resolve, reject func(Ref)) (func(int, bool, Ref) bool, func()) {
+		return func(i int, ok bool, v Ref) bool {
+				if ok {
+					resolve(v)
+				} else {
+					reject(v)
+				}
+				return true
+			}, func() {
+				// never settles if there are no promises
+			}
+	})
+	combine("any", func(n int, resolve, reject func(Ref)) (func(int, bool, Ref) bool, func()) {
+		errs := make([]Ref, n)
+		return func(i int, ok bool, v Ref) bool {
+				if ok {
+					resolve(v)
+					return true
+				}
+				errs[i] = v
+				return false
+			}, func() {
+				agg := construct(Ref{aggregateErrorClass}, []Ref{{newArray(errs)}, {"All promises were rejected"}})
+				reject(agg)
+			}
+	})
+	g.define("Promise", Ref{c}, false)
+}
@@ -0,0 +1,221 @@
+//+build !wasm
+
+package js
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// jsSymbol is an emulated JS symbol.
+//
+// Properties keyed by symbols are stored as regular properties with a name that starts with "@@", thus they are
+// not visible to the code that enumerates string keys.
+type jsSymbol struct {
+	desc string
+	key  string
+}
+
+// symbolPrefix is a prefix of property names used for symbol keys.
+const symbolPrefix = "@@"
+
+var (
+	symbolProto   *jsObject
+	iteratorProto *jsObject
+
+	symbolIterator      = &jsSymbol{desc: "Symbol.iterator", key: symbolPrefix + "iterator"}
+	symbolAsyncIterator = &jsSymbol{desc: "Symbol.asyncIterator", key: symbolPrefix + "asyncIterator"}
+	symbolToStringTag   = &jsSymbol{desc: "Symbol.toStringTag", key: symbolPrefix + "toStringTag"}
+
+	symbols struct {
+		sync.Mutex
+		last     int
+		registry map[string]*jsSymbol
+	}
+)
+
+// newSymbol creates a new unique symbol with a given description.
+func newSymbol(desc string) *jsSymbol {
+	symbols.Lock()
+	symbols.last++
+	n := symbols.last
+	symbols.Unlock()
+	return &jsSymbol{desc: desc, key: symbolPrefix + strconv.Itoa(n) + ":" + desc}
+}
+
+// propertyKey implements JS ToPropertyKey conversion.
+func propertyKey(v Ref) string {
+	if s, ok := v.v.(*jsSymbol); ok {
+		return s.key
+	}
+	return toString(v)
+}
+
+// symbolString returns a string representation of a symbol, as returned by String(sym).
+func symbolString(s *jsSymbol) string {
+	return "Symbol(" + s.desc + ")"
+}
+
+func setupSymbol(g *jsObject) {
+	symbolProto = &jsObject{proto: objectProto, class: "Symbol"}
+	p := symbolProto
+	thisSymbol := func(v Ref) *jsSymbol {
+		s, ok := v.v.(*jsSymbol)
+		if !ok {
+			throwTypeError("Symbol.prototype method called on incompatible receiver " + toString(v))
+		}
+		return s
+	}
+	p.defineMethod("toString", 0, func(v Ref, args []Ref) Ref {
+		return Ref{symbolString(thisSymbol(v))}
+	})
+	p.defineMethod("valueOf", 0, func(v Ref, args []Ref) Ref {
+		return Ref{thisSymbol(v)}
+	})
+	p.defineGetter("description", func(v Ref, args []Ref) Ref {
+		return Ref{thisSymbol(v).desc}
+	}, nil)
+	p.define(symbolToStringTag.key, Ref{"Symbol"}, false)
+
+	c := newClass("Symbol", 0, p, func(this Ref, args []Ref) Ref {
+		desc := ""
+		if d := arg(args, 0); d.v != nil {
+			desc = toString(d)
+		}
+		return Ref{newSymbol(desc)}
+	}, func(args []Ref) *jsObject {
+		throwTypeError("Symbol is not a constructor")
+		return nil
+	})
+	for _, s := range []*jsSymbol{symbolIterator, symbolAsyncIterator, symbolToStringTag} {
+		c.define(strings.TrimPrefix(s.desc, "Symbol."), Ref{s}, false)
+	}
+	c.defineMethod("for", 1, func(this Ref, args []Ref) Ref {
+		key := toString(arg(args, 0))
+		symbols.Lock()
+		defer symbols.Unlock()
+		if symbols.registry == nil {
+			symbols.registry = make(map[string]*jsSymbol)
+		}
+		s := symbols.registry[key]
+		if s == nil {
+			s = &jsSymbol{desc: key, key: symbolPrefix + "for:" + key}
+			symbols.registry[key] = s
+		}
+		return Ref{s}
+	})
+	g.define("Symbol", Ref{c}, false)
+
+	iteratorProto = newObject(objectProto)
+	iteratorProto.defineMethod(symbolIterator.key, 0, func(this Ref, args []Ref) Ref {
+		return this
+	})
+	setupIterators()
+}
+
+// newIterator creates a JS iterator object that calls next to get the next value.
+// The iterator completes when next returns false.
+func newIterator(tag string, next func() (Ref, bool)) *jsObject {
+	it := newObject(iteratorProto)
+	it.define(symbolToStringTag.key, Ref{tag}, false)
+	done := false
+	it.defineMethod("next", 0, func(this Ref, args []Ref) Ref {
+		var v Ref
+		if !done {
+			var ok bool
+			v, ok = next()
+			done = !ok
+		}
+		res := newObject(objectProto)
+		res.define("value", v, true)
+		res.define("done", Ref{done}, true)
+		return Ref{res}
+	})
+	return it
+}
+
+// newListIterator creates a JS iterator that returns values from a list. The list is requested on each step,
+// so modifications of the underlying collection are visible to the iterator.
+func newListIterator(tag string, list func() []Ref) *jsObject {
+	i := 0
+	return newIterator(tag, func() (Ref, bool) {
+		l := list()
+		if i >= len(l) {
+			return undefined, false
+		}
+		v := l[i]
+		i++
+		return v, true
+	})
+}
+
+func setupIterators() {
+	p := arrayProto
+	values := newFunc("values", 0, func(this Ref, args []Ref) Ref {
+		return Ref{newListIterator("Array Iterator", func() []Ref {
+			return listFromArrayLike(this)
+		})}
+	})
+	p.define("values", Ref{values}, false)
+	p.define(symbolIterator.key, Ref{values}, false)
+	p.defineMethod("keys", 0, func(this Ref, args []Ref) Ref {
+		return Ref{newListIterator("Array Iterator", func() []Ref {
+			n := len(listFromArrayLike(this))
+			keys := make([]Ref, n)
+			for i := range keys {
+				keys[i] = Ref{float64(i)}
+			}
+			return keys
+		})}
+	})
+	p.defineMethod("entries", 0, func(this Ref, args []Ref) Ref {
+		return Ref{newListIterator("Array Iterator", func() []Ref {
+			vals := listFromArrayLike(this)
+			out := make([]Ref, len(vals))
+			for i, v := range vals {
+				out[i] = Ref{newArray([]Ref{{float64(i)}, v})}
+			}
+			return out
+		})}
+	})
+	stringProto.defineMethod(symbolIterator.key, 0, func(this Ref, args []Ref) Ref {
+		s := utf16.Encode([]rune(toString(this)))
+		i := 0
+		return Ref{newIterator("String Iterator", func() (Ref, bool) {
+			if i >= len(s) {
+				return undefined, false
+			}
+			n := 1
+			if utf16.IsSurrogate(rune(s[i])) && i+1 < len(s) {
+				n = 2
+			}
+			v := string(utf16.Decode(s[i : i+n]))
+			i += n
+			return Ref{v}, true
+		})}
+	})
+}
+
+// listFromIterable collects values of JS iterable into a list. Array-like objects without an iterator are supported as well.
+func listFromIterable(v Ref) []Ref {
+	if o, ok := v.v.(*jsObject); ok && o.class != "Array" {
+		if f := getProp(v, symbolIterator.key); isCallable(f) {
+			it := callFunc(f, v, nil)
+			next := getProp(it, "next")
+			var out []Ref
+			for {
+				res := callFunc(next, it, nil)
+				if _, ok := res.v.(*jsObject); !ok {
+					throwTypeError("Iterator result " + toString(res) + " is not an object")
+				}
+				if getProp(res, "done").Truthy() {
+					return out
+				}
+				out = append(out, getProp(res, "value"))
+			}
+		}
+	}
+	return listFromArrayLike(v)
+}
@@ -0,0 +1,682 @@
+//+build !wasm
+
+package js
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostTypes(t *testing.T) {
+	require.Equal(t, TypeUndefined, Value{undefined}.Type())
+	require.Equal(t, TypeNull, Value{null}.Type())
+	require.Equal(t, TypeNumber, ValueOf(1).Type())
+	require.Equal(t, TypeString, ValueOf("a").Type())
+	require.Equal(t, TypeBoolean, ValueOf(true).Type())
+	require.Equal(t, TypeObject, NewObject().Type())
+	require.Equal(t, TypeFunction, Class("Object").Type())
+
+	require.True(t, NewObject().InstanceOfClass("Object"))
+	require.True(t, New("TypeError", "x").InstanceOfClass("Error"))
+	require.False(t, NewObject().InstanceOfClass("Array"))
+
+	require.Equal(t, "function", TypeFunction.String())
+	require.Equal(t, "Type(100)", Type(100).String())
+}
+
+func TestHostObjects(t *testing.T) {
+	o := NewObject()
+	o.Set("b", 1)
+	o.Set("a", "x")
+	o.Set("1", true)
+	keys := Get("Object").Call("keys", o).Slice()
+	require.Len(t, keys, 3)
+	require.Equal(t, "1", keys[0].String())
+	require.Equal(t, "b", keys[1].String())
+	require.Equal(t, "a", keys[2].String())
+	require.Equal(t, "[object Object]", o.String())
+	require.Equal(t, 1, o.Get("b").Int())
+	require.True(t, o.Get("c").IsUndefined())
+
+	arr := ValueOf([]interface{}{1, "2", nil})
+	require.Equal(t, 3, arr.Length())
+	arr.Call("push", 4)
+	require.Equal(t, "1,2,,4", arr.String())
+	require.Equal(t, "2", arr.Index(1).String())
+	require.Equal(t, 1, arr.Call("indexOf", "2").Int())
+
+	require.Equal(t, "ab", ValueOf("abc").Call("slice", 0, -1).String())
+	require.Equal(t, "0.1", ValueOf(0.1).String())
+	require.Equal(t, "1e+21", ValueOf(1e21).String())
+}
+
+func TestHostObjectProps(t *testing.T) {
+	o := ValueOf(Obj{"a": 1})
+	o.Set("b", "x")
+	require.True(t, o.Has("a"))
+	require.True(t, o.Has("toString"))
+	require.True(t, o.HasOwn("b"))
+	require.False(t, o.HasOwn("toString"))
+	require.Equal(t, []string{"a", "b"}, o.Keys())
+
+	ents := o.Entries()
+	require.Len(t, ents, 2)
+	require.Equal(t, "b", ents[1].Key)
+	require.Equal(t, "x", ents[1].Value.String())
+
+	var keys []string
+	o.Range(func(k string, v Value) bool {
+		keys = append(keys, k)
+		return false
+	})
+	require.Equal(t, []string{"a"}, keys)
+
+	require.True(t, o.Delete("a"))
+	require.False(t, o.Has("a"))
+	require.Equal(t, []string{"b"}, o.Keys())
+
+	require.False(t, ValueOf(1).Has("a"))
+	require.False(t, ValueOf(nil).Delete("a"))
+	require.Nil(t, Value{}.Keys())
+}
+
+func TestHostFunc(t *testing.T) {
+	f := FuncOf(func(this Value, args []Value) interface{} {
+		return args[0].Int() + args[1].Int()
+	})
+	defer f.Release()
+	require.Equal(t, 3, Value{f.Value}.Invoke(1, 2).Int())
+	require.Equal(t, 5, Value{f.Value}.Call("call", nil, 2, 3).Int())
+	require.Equal(t, 7, Value{f.Value}.Call("bind", nil, 3).Invoke(4).Int())
+}
+
+func TestHostFuncScope(t *testing.T) {
+	SetDebug(true)
+	defer SetDebug(false)
+
+	n := LiveFuncCount()
+	s := NewFuncScope()
+	f := s.FuncOf(func(this Value, args []Value) interface{} {
+		return 1
+	})
+	s.CallbackOf(func(v []Value) {})
+	require.Equal(t, 2, s.Len())
+	require.Equal(t, n+2, LiveFuncCount())
+
+	live := LiveFuncs()
+	require.Contains(t, live[len(live)-1].Stack, "TestHostFuncScope")
+	require.NotContains(t, live[len(live)-1].Stack, "CallbackOf")
+
+	deferred := false
+	s.Defer(func() {
+		deferred = true
+		require.Equal(t, 1, Value{f.Value}.Invoke().Int())
+	})
+	s.Release()
+	require.True(t, deferred)
+	require.Equal(t, n, LiveFuncCount())
+	require.Panics(t, func() {
+		Value{f.Value}.Invoke()
+	})
+
+	s.FuncOf(func(this Value, args []Value) interface{} {
+		return nil
+	})
+	require.Equal(t, 0, s.Len())
+	require.Equal(t, n, LiveFuncCount())
+}
+
+func TestHostFuncScopeContext(t *testing.T) {
+	n := LiveFuncCount()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewFuncScopeContext(ctx)
+	s.CallbackOf(func(v []Value) {})
+	require.Equal(t, n+1, LiveFuncCount())
+	cancel()
+	for i := 0; i < 100 && LiveFuncCount() != n; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+	require.Equal(t, n, LiveFuncCount())
+}
+
+func TestHostException(t *testing.T) {
+	err := func() (err error) {
+		defer func() {
+			err = recover().(error)
+		}()
+		Value{null}.Get("x")
+		return nil
+	}()
+	e, ok := err.(Error)
+	require.True(t, ok)
+	require.True(t, Value{e.Value}.InstanceOfClass("TypeError"))
+	require.Equal(t, "JavaScript error: Cannot read properties of null (reading 'x')", e.Error())
+}
+
+func TestHostTry(t *testing.T) {
+	_, err := Value{null}.TryGet("x")
+	e, ok := err.(Error)
+	require.True(t, ok)
+	require.True(t, Value{e.Value}.InstanceOfClass("TypeError"))
+
+	_, err = Object().TryCall("defineProperty", nil, "x", nil)
+	require.IsType(t, Error{}, err)
+
+	_, err = Class("DOMException").TryNew("msg", "SyntaxError")
+	require.NoError(t, err)
+
+	thrower := FuncOf(func(this Value, args []Value) interface{} {
+		panic(Error{Value: New("RangeError", "bad").Ref})
+	})
+	defer thrower.Release()
+	_, err = Value{thrower.Value}.TryInvoke()
+	require.Equal(t, "JavaScript error: bad", err.Error())
+
+	v, err := Get("Math").TryCall("max", 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, v.Int())
+
+	// Go panics are not converted to errors
+	require.PanicsWithValue(t, "bug", func() {
+		Try(func() { panic("bug") })
+	})
+	require.Panics(t, func() {
+		var arr []int
+		Try(func() { _ = arr[1] })
+	})
+}
+
+func TestHostError(t *testing.T) {
+	cause := New("TypeError", "inner")
+	e := Error{Value: New("Error", "outer", Obj{"cause": cause}).Ref}
+	require.Equal(t, "Error", e.Name())
+	require.Equal(t, "outer", e.Message())
+	require.Equal(t, "JavaScript error: outer", e.Error())
+	require.Contains(t, e.Stack(), "outer")
+	require.Equal(t, 0, e.Code())
+	require.Equal(t, Error{Value: cause.Ref}, e.Cause())
+	require.Equal(t, "TypeError", e.Unwrap().(Error).Name())
+	require.False(t, e.Is(ErrAbort))
+
+	e = Error{Value: NewAbortError("stop").Ref}
+	require.Equal(t, "AbortError", e.Name())
+	require.Equal(t, 20, e.Code())
+	require.True(t, e.Is(ErrAbort))
+	require.False(t, e.Is(ErrTimeout))
+	require.Nil(t, e.Cause())
+
+	e = Error{Value: errorValue(ErrNotAllowed).Ref}
+	require.Equal(t, "NotAllowedError", e.Name())
+	require.True(t, e.Is(ErrNotAllowed))
+
+	e = Error{Value: ValueOf("boom").Ref}
+	require.Equal(t, "", e.Name())
+	require.Equal(t, "boom", e.Message())
+	require.Equal(t, "JavaScript error: boom", e.Error())
+
+	require.Equal(t, "JavaScript error: undefined", Error{Value: NewObject().Ref}.Error())
+}
+
+func TestHostJSON(t *testing.T) {
+	v := Get("JSON").Call("parse", `{"b":[1,true,null],"a":"s"}`)
+	require.Equal(t, 1, v.Get("b").Index(0).Int())
+	s := Get("JSON").Call("stringify", v).String()
+	require.Equal(t, `{"b":[1,true,null],"a":"s"}`, s)
+	d := New("Date", 1e12)
+	require.Equal(t, `"2001-09-09T01:46:40.000Z"`, Get("JSON").Call("stringify", d).String())
+}
+
+func TestHostTypedArray(t *testing.T) {
+	buf := []byte{1, 2, 3, 4}
+	ta := TypedArrayOf(buf)
+	defer ta.Release()
+	require.Equal(t, 4, ta.Length())
+	ta.SetIndex(0, 5)
+	require.Equal(t, byte(5), buf[0])
+	buf[1] = 7
+	require.Equal(t, 7, ta.Index(1).Int())
+
+	v := New("DataView", ta.Get("buffer"))
+	require.Equal(t, 0x0507, v.Call("getUint16", 0).Int())
+	require.Equal(t, 0x0705, v.Call("getUint16", 0, true).Int())
+}
+
+func TestHostCopyBytes(t *testing.T) {
+	ab := New("ArrayBuffer", 8)
+	n, err := CopyBytesToJS(ab, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9})
+	require.NoError(t, err)
+	require.Equal(t, 8, n)
+
+	dv := New("DataView", ab, 2, 4)
+	p := make([]byte, 8)
+	n, err = CopyBytesToGo(p, dv)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	require.Equal(t, []byte{3, 4, 5, 6, 0, 0, 0, 0}, p)
+
+	i16 := New("Int16Array", ab, 4, 2)
+	p, err = ReadBytes(i16)
+	require.NoError(t, err)
+	require.Equal(t, []byte{5, 6, 7, 8}, p)
+
+	p = make([]byte, 2)
+	n, err = CopyBytesToGo(p, ab)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []byte{1, 2}, p)
+
+	_, err = CopyBytesToGo(p, NewObject())
+	require.Error(t, err)
+	_, err = ReadBytes(ValueOf("x"))
+	require.Error(t, err)
+
+	m := MMap(make([]byte, 4))
+	defer m.Release()
+	require.NoError(t, m.CopyFrom(New("DataView", ab, 1, 4)))
+	require.Equal(t, []byte{2, 3, 4, 5}, m.Bytes())
+	require.Error(t, m.CopyFrom(ab))
+}
+
+func TestHostBuffer(t *testing.T) {
+	b := BufferOf([]byte{1, 2, 3})
+	require.Equal(t, 3, b.Len())
+	v := Value{b.JSValue()}
+	require.True(t, v.InstanceOfClass("Uint8Array"))
+	require.Equal(t, 2, v.Index(1).Int())
+	require.Equal(t, 512, v.Get("buffer", "byteLength").Int())
+	b.Release()
+
+	b = GetBuffer(2 << 20)
+	require.Equal(t, 2<<20, b.Len())
+	b.Release()
+}
+
+func TestHostPromise(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	p := New("Promise", FuncOf(func(this Value, args []Value) interface{} {
+		Get("setTimeout").Invoke(args[0], 10, "ok")
+		return nil
+	}))
+	res, err := p.Call("then", FuncOf(func(this Value, args []Value) interface{} {
+		return args[0].String() + "!"
+	})).Promised().AwaitContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "ok!", res[0].String())
+
+	_, err = Class("Promise").Call("reject", New("Error", "fail")).Promised().AwaitContext(ctx)
+	require.NotNil(t, err)
+	require.Equal(t, "JavaScript error: fail", err.Error())
+}
+
+func TestHostIterate(t *testing.T) {
+	var got []string
+	ValueOf([]interface{}{"a", "b", "c"}).Iterate(func(v Value) bool {
+		got = append(got, v.String())
+		return len(got) < 2
+	})
+	require.Equal(t, []string{"a", "b"}, got)
+
+	got = nil
+	ValueOf("xé").Iterate(func(v Value) bool {
+		got = append(got, v.String())
+		return true
+	})
+	require.Equal(t, []string{"x", "é"}, got)
+
+	err := Try(func() {
+		NewObject().Iterate(func(v Value) bool { return true })
+	})
+	require.Error(t, err)
+
+	// make sure the shared FinalizationRegistry is created before counting functions
+	OnCollect(NewObject(), func() {})()
+
+	n := LiveFuncCount()
+	i := 0
+	it := IteratorOf(func() (interface{}, bool) {
+		i++
+		return i, i <= 3
+	})
+	arr := Get("Array").Call("from", it)
+	require.Equal(t, 3, arr.Length())
+	require.Equal(t, 3, arr.Index(2).Int())
+	// the iterator must keep reporting completion after it's done
+	require.True(t, it.Call("next").Get("done").Bool())
+	require.True(t, it.Call("next").Get("done").Bool())
+	require.Equal(t, n+3, LiveFuncCount())
+	require.True(t, it.Call("return").Get("done").Bool())
+	require.Equal(t, n, LiveFuncCount())
+
+	it = IteratorOf(func() (interface{}, bool) {
+		return nil, false
+	})
+	require.Equal(t, n+3, LiveFuncCount())
+	collectObject(it.Ref)
+	require.Equal(t, n, LiveFuncCount())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	close(ch)
+	var sum int
+	vals, errc := ChanIteratorOf(ch).IterateAsync(ctx)
+	for v := range vals {
+		sum += v.Int()
+	}
+	require.NoError(t, <-errc)
+	require.Equal(t, 3, sum)
+	require.Equal(t, n, LiveFuncCount())
+
+	vals, errc = ValueOf([]interface{}{1, 2}).IterateAsync(ctx)
+	sum = 0
+	for v := range vals {
+		sum += v.Int()
+	}
+	require.NoError(t, <-errc)
+	require.Equal(t, 3, sum)
+
+	ctx2, cancel2 := context.WithCancel(ctx)
+	it = AsyncIteratorOf(func(ctx context.Context) (interface{}, bool, error) {
+		return "x", true, nil
+	})
+	vals, errc = it.IterateAsync(ctx2)
+	<-vals
+	cancel2()
+	for range vals {
+	}
+	require.Equal(t, context.Canceled, <-errc)
+
+	// closing the iterator resolves a pending call
+	it = ChanIteratorOf(make(chan int))
+	pending := it.Call("next").Promised()
+	_, err = it.Call("return").Promised().AwaitContext(ctx)
+	require.NoError(t, err)
+	res, err := pending.AwaitContext(ctx)
+	require.NoError(t, err)
+	require.True(t, res[0].Get("done").Bool())
+}
+
+func TestHostCollections(t *testing.T) {
+	obj := NewObject()
+	m := NewMap()
+	m.Set("a", 1)
+	m.Set(obj, 2)
+	m.Set(math.NaN(), 3)
+	require.Equal(t, 3, m.Len())
+	require.Equal(t, 2, m.Get(obj).Int())
+	require.Equal(t, 3, m.Get(math.NaN()).Int())
+	require.True(t, m.Get("b").IsUndefined())
+	require.True(t, m.Has("a"))
+	require.False(t, m.Has(NewObject()))
+	require.True(t, m.Delete(math.NaN()))
+	require.False(t, m.Delete(math.NaN()))
+
+	var keys []Value
+	m.Range(func(k, v Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	require.Equal(t, []Value{ValueOf("a"), obj}, keys)
+	m.Clear()
+	require.Equal(t, 0, m.Len())
+
+	m = AsMap(New("Map", []interface{}{[]interface{}{"x", 1}}))
+	require.Equal(t, 1, m.Get("x").Int())
+
+	s := NewValueSet(1, "a", 1)
+	require.Equal(t, 2, s.Len())
+	require.True(t, s.Has(1))
+	require.False(t, s.Has("1"))
+	var vals []interface{}
+	s.Range(func(v Value) bool {
+		vals = append(vals, v.Ref.String())
+		return true
+	})
+	require.Equal(t, []interface{}{"1", "a"}, vals)
+	require.Equal(t, 2, Get("Array").Call("from", s).Length())
+	require.True(t, s.Delete("a"))
+	require.Equal(t, "[object Set]", Get("Object", "prototype", "toString").Call("call", s).String())
+
+	r := NewWeakRef(obj)
+	v, ok := r.Deref()
+	require.True(t, ok)
+	require.Equal(t, obj, v)
+
+	reg := NewFinalizationRegistry()
+	defer reg.Release()
+	a, b := NewObject(), NewObject()
+	var called []string
+	reg.Register(a, func() { called = append(called, "a") })
+	cancel := reg.Register(b, func() { called = append(called, "b") })
+	require.Equal(t, 2, reg.Len())
+	cancel()
+	cancel()
+	collectObject(a.Ref)
+	collectObject(b.Ref)
+	require.Equal(t, []string{"a"}, called)
+	require.Equal(t, 0, reg.Len())
+
+	f := FuncOf(func(this Value, args []Value) interface{} { return nil })
+	ReleaseOnCollect(a, f)
+	n := LiveFuncCount()
+	collectObject(a.Ref)
+	require.Equal(t, n-1, LiveFuncCount())
+}
+
+func TestHostKind(t *testing.T) {
+	for _, c := range []struct {
+		v    Value
+		kind Kind
+	}{
+		{Value{}, KindUndefined},
+		{Value{undefined}, KindUndefined},
+		{Value{null}, KindNull},
+		{ValueOf(true), KindBool},
+		{ValueOf(1.5), KindNumber},
+		{BigIntOf(1), KindBigInt},
+		{ValueOf("a"), KindString},
+		{Class("Symbol", "iterator"), KindSymbol},
+		{Get("Array"), KindFunction},
+		{ValueOf([]interface{}{1}), KindArray},
+		{NewObject(), KindObject},
+		{Object().Invoke(BigIntOf(1)), KindObject},
+	} {
+		require.Equal(t, c.kind, c.v.Kind(), "%v", c.kind)
+	}
+	require.Equal(t, "bigint", KindBigInt.String())
+
+	i, ok := ValueOf(3).AsInt()
+	require.True(t, ok)
+	require.Equal(t, 3, i)
+	_, ok = ValueOf(3.5).AsInt()
+	require.False(t, ok)
+	_, ok = ValueOf("3").AsInt()
+	require.False(t, ok)
+	_, ok = Value{}.AsInt()
+	require.False(t, ok)
+	_, ok = NewObject().AsFloat()
+	require.False(t, ok)
+	s, ok := ValueOf("a").AsString()
+	require.True(t, ok)
+	require.Equal(t, "a", s)
+	_, ok = ValueOf(1).AsString()
+	require.False(t, ok)
+	b, ok := ValueOf(true).AsBool()
+	require.True(t, ok && b)
+	_, ok = ValueOf(1).AsBool()
+	require.False(t, ok)
+
+	big := Get("BigInt").Invoke("9007199254740993")
+	require.True(t, big.IsBigInt())
+	require.Equal(t, int64(9007199254740993), big.Int64())
+	require.Equal(t, int64(-7), BigIntOf(-7).Int64())
+	require.Equal(t, int64(2), ValueOf(2.7).Int64())
+	_, ok = Get("BigInt").Invoke("9223372036854775808").AsInt64()
+	require.False(t, ok)
+	require.Panics(t, func() {
+		ValueOf("1").Int64()
+	})
+
+	obj := NewObject()
+	require.True(t, obj.StrictEqual(obj))
+	require.False(t, obj.StrictEqual(NewObject()))
+	require.True(t, ValueOf("a").StrictEqual(ValueOf("a")))
+	require.False(t, ValueOf(math.NaN()).StrictEqual(ValueOf(math.NaN())))
+	require.True(t, ValueOf(0.0).StrictEqual(ValueOf(math.Copysign(0, -1))))
+	require.False(t, ValueOf(1).StrictEqual(ValueOf("1")))
+	require.True(t, Value{}.StrictEqual(Value{undefined}))
+	require.True(t, BigIntOf(5).StrictEqual(Get("BigInt").Invoke(5)))
+
+	require.True(t, ValueOf(1).Equal(ValueOf("1")))
+	require.True(t, ValueOf(true).Equal(ValueOf(1)))
+	require.True(t, Value{null}.Equal(Value{undefined}))
+	require.False(t, Value{null}.Equal(ValueOf(0)))
+	require.True(t, ValueOf([]interface{}{2}).Equal(ValueOf("2")))
+	require.True(t, BigIntOf(2).Equal(ValueOf(2)))
+	require.False(t, BigIntOf(2).Equal(ValueOf(2.5)))
+	require.False(t, obj.Equal(NewObject()))
+	require.False(t, ValueOf("a").Equal(ValueOf(math.NaN())))
+}
+
+func TestHostStructuredClone(t *testing.T) {
+	type msg struct {
+		Name string `js:"name"`
+		N    int    `js:"n"`
+	}
+	v, err := StructuredClone(msg{Name: "a", N: 1}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "a", v.Get("name").String())
+	require.Equal(t, 1, v.Get("n").Int())
+
+	obj := NewObject()
+	obj.Set("self", obj)
+	obj.Set("list", []interface{}{1, "b"})
+	obj.Set("date", New("Date", 1000))
+	m := NewMap()
+	m.Set(obj, "x")
+	obj.Set("map", m)
+	v, err = StructuredClone(obj, nil)
+	require.NoError(t, err)
+	require.False(t, v.StrictEqual(obj))
+	require.True(t, v.Get("self").StrictEqual(v))
+	require.Equal(t, "b", v.Get("list").Index(1).String())
+	require.Equal(t, 1000, v.Get("date").Call("getTime").Int())
+	require.Equal(t, "x", AsMap(v.Get("map")).Get(v).String())
+
+	_, err = StructuredClone(Obj{"f": FuncOf(func(this Value, args []Value) interface{} { return nil })}, nil)
+	require.Error(t, err)
+	require.True(t, err.(Error).Is(ErrDataClone))
+
+	tr := NewTransfer()
+	arr := tr.Bytes([]byte{1, 2, 3})
+	view := New("Uint8Array", arr.Get("buffer"), 1)
+	tr.Add(view)
+	require.Equal(t, 1, tr.Len())
+	v, err = StructuredClone(Obj{"data": arr, "view": view}, tr)
+	require.NoError(t, err)
+	require.Equal(t, 0, arr.Length())
+	require.Equal(t, 0, view.Length())
+	require.Equal(t, 0, arr.Get("buffer", "byteLength").Int())
+	p, err := ReadBytes(v.Get("data"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3}, p)
+	require.Equal(t, 2, v.Get("view").Index(0).Int())
+	require.True(t, v.Get("view", "buffer").StrictEqual(v.Get("data", "buffer")))
+
+	_, err = StructuredClone(arr, tr)
+	require.Error(t, err)
+}
+
+type testFlag struct {
+	Flag bool `js:"flag"`
+}
+
+type testLeft struct{ testFlag }
+
+type testRight struct{ testFlag }
+
+type testDiamond struct {
+	testLeft
+	testRight
+	Name string `js:"name"`
+}
+
+type testTagged struct {
+	Name string
+}
+
+type testShadow struct {
+	testFlag
+	testTagged
+	Other struct {
+		Name string
+	} `js:"Name"`
+	Flag int
+}
+
+type testPromoted struct {
+	testShadow
+	Name string
+}
+
+func TestHostMarshal(t *testing.T) {
+	fieldNames := func(o interface{}) []string {
+		var names []string
+		for _, f := range cachedFields(reflect.TypeOf(o)) {
+			names = append(names, f.name)
+		}
+		return names
+	}
+	// the same type embedded on different paths is ambiguous
+	require.Equal(t, []string{"name"}, fieldNames(testDiamond{}))
+
+	v, err := Marshal(testDiamond{testRight: testRight{testFlag{Flag: true}}})
+	require.NoError(t, err)
+	require.True(t, v.Get("flag").IsUndefined())
+
+	// fields of the struct itself shadow embedded ones, and tagged fields win on the same depth
+	require.Equal(t, []string{"Name", "Flag", "flag"}, fieldNames(testShadow{}))
+	shadow := cachedFields(reflect.TypeOf(testShadow{}))
+	require.Equal(t, []int{2}, shadow[0].index)
+	require.Equal(t, []int{3}, shadow[1].index)
+	require.Equal(t, []int{0, 0}, shadow[2].index)
+
+	// tagged field is nested deeper, thus the untagged one wins
+	require.Equal(t, []string{"Name", "Flag", "flag"}, fieldNames(testPromoted{}))
+	promoted := cachedFields(reflect.TypeOf(testPromoted{}))
+	require.Equal(t, []int{1}, promoted[0].index)
+
+	var list struct {
+		Items []testFlag `js:"items"`
+	}
+	err = Unmarshal(ValueOf(Obj{"items": []interface{}{Obj{"flag": true}, Obj{"flag": 1}}}), &list)
+	require.Error(t, err)
+	e, ok := err.(*UnmarshalTypeError)
+	require.True(t, ok, "%T", err)
+	require.Equal(t, "items[1].flag", e.Field)
+
+	for _, when := range []time.Time{
+		time.Date(2019, 3, 1, 10, 20, 30, int(500*time.Millisecond), time.UTC),
+		time.Date(1500, 1, 2, 3, 4, 5, int(250*time.Millisecond), time.UTC),
+		time.Date(3000, 12, 31, 23, 59, 59, int(999*time.Millisecond), time.UTC),
+		time.Date(1969, 12, 31, 23, 59, 59, int(1*time.Millisecond), time.UTC),
+	} {
+		v, err := Marshal(when)
+		require.NoError(t, err)
+		require.Equal(t, float64(when.Unix())*1000+float64(when.Nanosecond()/1e6), v.Call("getTime").Float())
+		var out time.Time
+		err = Unmarshal(v, &out)
+		require.NoError(t, err)
+		require.True(t, out.Equal(when), "%v != %v", out, when)
+	}
+}
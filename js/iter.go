@@ -0,0 +1,305 @@
+package js
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// symbol returns a well-known JS symbol, like Symbol.iterator.
+func symbol(name string) Value {
+	return Class("Symbol", name)
+}
+
+// getSymbol returns a property of the value keyed by a given symbol.
+func (v Value) getSymbol(sym Value) Value {
+	obj := v
+	if !v.isObject() {
+		obj = Object().Invoke(v)
+	}
+	return Get("Reflect").Call("get", obj, sym)
+}
+
+// setSymbol sets a property of the object keyed by a given symbol.
+func (v Value) setSymbol(sym Value, val interface{}) {
+	Get("Reflect").Call("set", v, sym, val)
+}
+
+// iterator returns an iterator of JS iterable. If async is set, async iterator is preferred.
+// The second return value reports if the returned iterator is async.
+func (v Value) iterator(async bool) (Value, bool) {
+	if !v.Valid() {
+		panic(Error{Value: New("TypeError", v.Ref.String()+" is not iterable").Ref})
+	}
+	if async {
+		if f := v.getSymbol(symbol("asyncIterator")); f.Type() == TypeFunction {
+			return f.Call("call", v), true
+		}
+	}
+	f := v.getSymbol(symbol("iterator"))
+	if f.Type() != TypeFunction {
+		panic(Error{Value: New("TypeError", "object is not iterable").Ref})
+	}
+	return f.Call("call", v), false
+}
+
+// iterResult checks the iterator result object and returns its value.
+func iterResult(res Value) (Value, bool) {
+	if !res.isObject() {
+		panic(Error{Value: New("TypeError", "Iterator result "+res.Ref.String()+" is not an object").Ref})
+	}
+	if res.Get("done").Truthy() {
+		return Value{}, false
+	}
+	return res.Get("value"), true
+}
+
+// closeIterator calls the "return" method of the iterator, if it is defined.
+func closeIterator(it Value) {
+	if f := it.Get("return"); f.Type() == TypeFunction {
+		f.Call("call", it)
+	}
+}
+
+// Iterate calls fnc for each value produced by JS iterable, like arrays, Map, Set, or iterators returned
+// by entries() and values() methods of various browser APIs. It works the same way as "for...of" loop.
+//
+// Iteration stops if fnc returns false. In this case the iterator is closed by calling its "return" method.
+// It panics if the value is not iterable or if the iterator throws an exception.
+func (v Value) Iterate(fnc func(v Value) bool) {
+	if v.Type() == TypeString {
+		for _, r := range v.String() {
+			if !fnc(ValueOf(string(r))) {
+				return
+			}
+		}
+		return
+	}
+	it, _ := v.iterator(false)
+	next := it.Get("next")
+	for {
+		val, ok := iterResult(next.Call("call", it))
+		if !ok {
+			return
+		}
+		if !fnc(val) {
+			closeIterator(it)
+			return
+		}
+	}
+}
+
+// IterateAsync iterates over JS async iterable, like ReadableStream, and sends values to the returned channel.
+// Regular iterables are supported as well. It works the same way as "for await...of" loop.
+//
+// The value channel is closed when the iteration completes, fails or the context is canceled.
+// An error channel receives at most one error and is closed after the value channel.
+// If the context is canceled, the iterator is closed by calling its "return" method.
+//
+// The function must not be called from JS callbacks, since the iteration waits for promises to be resolved.
+func (v Value) IterateAsync(ctx context.Context) (<-chan Value, <-chan error) {
+	out := make(chan Value)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(out)
+		err := v.iterateAsync(ctx, func(v Value) bool {
+			select {
+			case out <- v:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			errc <- err
+		}
+	}()
+	return out, errc
+}
+
+func (v Value) iterateAsync(ctx context.Context, fnc func(v Value) bool) error {
+	var (
+		it    Value
+		async bool
+	)
+	err := Try(func() {
+		it, async = v.iterator(true)
+	})
+	if err != nil {
+		return err
+	}
+	next := it.Get("next")
+	// await resolves the value if it is a promise
+	await := func(v Value) (Value, error) {
+		if !isInstanceOf(v, "Promise") {
+			return v, nil
+		}
+		res, err := v.Promised().AwaitContext(ctx)
+		if err != nil {
+			return Value{}, err
+		}
+		return res[0], nil
+	}
+	for {
+		res, err := next.TryCall("call", it)
+		if err == nil && async {
+			res, err = await(res)
+		}
+		var (
+			val Value
+			ok  bool
+		)
+		if err == nil {
+			err = Try(func() {
+				val, ok = iterResult(res)
+			})
+		}
+		if err == nil && ok && !async {
+			val, err = await(val)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				Try(func() {
+					closeIterator(it)
+				})
+			}
+			return err
+		} else if !ok {
+			return nil
+		}
+		if !fnc(val) {
+			return Try(func() {
+				closeIterator(it)
+			})
+		}
+	}
+}
+
+// iterDone returns the result of a completed iterator.
+func iterDone() interface{} {
+	return Obj{"done": true}
+}
+
+// IteratorOf exposes a Go function as JS iterable iterator. Each call to the "next" method of the iterator
+// calls the function. The iteration completes when the function returns false.
+//
+// After the iteration completes, the "next" method keeps returning {done: true}, as required by the iterator
+// protocol. Resources allocated for the iterator are released when the iterator is closed by calling its "return"
+// method, or when the iterator object is garbage collected, if FinalizationRegistry is supported.
+// Without FinalizationRegistry the iterator must be closed explicitly to avoid the leak.
+func IteratorOf(next func() (interface{}, bool)) Value {
+	s := NewFuncScope()
+	done := false
+	it := NewObject()
+	it.Set("next", s.FuncOf(func(this Value, args []Value) interface{} {
+		if done {
+			return iterDone()
+		}
+		v, ok := next()
+		if !ok {
+			done, next = true, nil
+			return iterDone()
+		}
+		return Obj{"value": v, "done": false}
+	}))
+	it.Set("return", s.FuncOf(func(this Value, args []Value) interface{} {
+		done, next = true, nil
+		s.Release()
+		return iterDone()
+	}))
+	it.setSymbol(symbol("iterator"), s.FuncOf(func(this Value, args []Value) interface{} {
+		return this
+	}))
+	if Get("FinalizationRegistry").Type() == TypeFunction {
+		s.Defer(OnCollect(it, s.Release))
+	}
+	return it
+}
+
+// AsyncIteratorOf exposes a Go function as JS async iterable iterator. Each call to the "next" method of
+// the iterator returns a promise and calls the function in a separate goroutine. Calls are executed one
+// at a time, in the order they were made. The iteration completes when the function returns false.
+// If the function returns an error, the promise is rejected and the iteration completes.
+//
+// The context passed to the function is canceled when the iterator is closed by calling its "return" method.
+// Calls that are pending at this point resolve with {done: true}, and errors returned by them are ignored.
+// Resources allocated for the iterator are released automatically when the iteration completes or when
+// the iterator is closed. Thus, the iterator must be consumed to the end, or closed.
+func AsyncIteratorOf(next func(ctx context.Context) (interface{}, bool, error)) Value {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewFuncScope()
+	s.Defer(cancel)
+	var once sync.Once
+	finish := func() {
+		once.Do(s.Release)
+	}
+	// prev is closed when the previous call to next completes
+	prev := make(chan struct{})
+	close(prev)
+
+	it := NewObject()
+	it.Set("next", s.FuncOf(func(this Value, args []Value) interface{} {
+		wait, cur := prev, make(chan struct{})
+		prev = cur
+		return NewPromise(func() ([]interface{}, error) {
+			defer close(cur)
+			<-wait
+			if ctx.Err() != nil {
+				// iterator completed or was closed
+				return []interface{}{iterDone()}, nil
+			}
+			v, ok, err := next(ctx)
+			if ctx.Err() != nil {
+				// closed while the call was in progress
+				return []interface{}{iterDone()}, nil
+			} else if err != nil {
+				finish()
+				return nil, err
+			} else if !ok {
+				finish()
+				return []interface{}{iterDone()}, nil
+			}
+			return []interface{}{Obj{"value": v, "done": false}}, nil
+		})
+	}))
+	it.Set("return", s.FuncOf(func(this Value, args []Value) interface{} {
+		finish()
+		return NewPromise(func() ([]interface{}, error) {
+			return []interface{}{iterDone()}, nil
+		})
+	}))
+	it.setSymbol(symbol("asyncIterator"), s.FuncOf(func(this Value, args []Value) interface{} {
+		return this
+	}))
+	return it
+}
+
+// ChanIteratorOf exposes a Go channel as JS async iterable iterator. Values received from the channel
+// are converted with Marshal. The iteration completes when the channel is closed.
+//
+// It panics if ch is not a channel that can receive values. See AsyncIteratorOf for details.
+func ChanIteratorOf(ch interface{}) Value {
+	rv := reflect.ValueOf(ch)
+	if rv.Kind() != reflect.Chan || rv.Type().ChanDir()&reflect.RecvDir == 0 {
+		panic(fmt.Errorf("js: ChanIteratorOf: expected a receivable channel, got %T", ch))
+	}
+	return AsyncIteratorOf(func(ctx context.Context) (interface{}, bool, error) {
+		i, v, ok := reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: rv},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		})
+		if i == 1 || !ok {
+			return nil, false, nil
+		}
+		val, err := Marshal(v.Interface())
+		if err != nil {
+			return nil, false, err
+		}
+		return val, true, nil
+	})
+}
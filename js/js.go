@@ -67,7 +67,7 @@ func New(class string, args ...interface{}) Value {
 	return v.New(args...)
 }
 
-// NewError creates a new Go error from JS error value.
+// NewError creates a new Go error from JS error value. See Error for details.
 func NewError(e Wrapper) error {
 	return Error{Value: e.JSValue()}
 }
@@ -165,7 +165,86 @@ func (v Value) Set(name string, val interface{}) {
 	v.Ref.Set(name, valueOf(val))
 }
 
-// TODO: Del
+// isObject checks if a value is an object or a function, and can have properties.
+func (v Value) isObject() bool {
+	if v.isZero() {
+		return false
+	}
+	switch v.Type() {
+	case TypeObject, TypeFunction:
+		return true
+	}
+	return false
+}
+
+// Delete removes the JS property by name. It returns false if the property cannot be deleted.
+func (v Value) Delete(name string) bool {
+	if !v.isObject() {
+		return false
+	}
+	return Get("Reflect").Call("deleteProperty", v.Ref, name).Bool()
+}
+
+// Has checks if the JS object has a property with a given name, either own or inherited from the prototype.
+// It is an analog of JS "in" operator.
+func (v Value) Has(name string) bool {
+	if !v.isObject() {
+		return false
+	}
+	return Get("Reflect").Call("has", v.Ref, name).Bool()
+}
+
+// HasOwn checks if the JS object has an own property with a given name.
+func (v Value) HasOwn(name string) bool {
+	if !v.isObject() {
+		return false
+	}
+	return Object().Get("prototype", "hasOwnProperty").Call("call", v.Ref, name).Bool()
+}
+
+// Keys returns names of own enumerable properties of the JS object, as returned by Object.keys.
+func (v Value) Keys() []string {
+	if !v.Valid() {
+		return nil
+	}
+	keys := Object().Call("keys", v.Ref)
+	n := keys.Length()
+	out := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		out = append(out, keys.Index(i).String())
+	}
+	return out
+}
+
+// Entry is a name and a value of a JS object property.
+type Entry struct {
+	Key   string
+	Value Value
+}
+
+// Entries returns names and values of own enumerable properties of the JS object, as returned by Object.entries.
+func (v Value) Entries() []Entry {
+	keys := v.Keys()
+	if len(keys) == 0 {
+		return nil
+	}
+	out := make([]Entry, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, Entry{Key: k, Value: v.Get(k)})
+	}
+	return out
+}
+
+// Range calls fnc for each own enumerable property of the JS object. Iteration stops if fnc returns false.
+//
+// Property names are collected before the iteration, but values are read on each step.
+func (v Value) Range(fnc func(k string, v Value) bool) {
+	for _, k := range v.Keys() {
+		if !fnc(k, v.Get(k)) {
+			return
+		}
+	}
+}
 
 // Index returns JS index i of value v.
 func (v Value) Index(i int) Value {
@@ -2,18 +2,87 @@
 
 package js
 
-import "errors"
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"unsafe"
+)
+
+// On non-wasm builds the package uses an in-memory emulation of a subset of JavaScript instead of syscall/js.
+// It allows to run code that uses this package with a plain "go test" on the host.
 
 var (
-	global    = Ref{ref: 2}
-	null      = Ref{ref: 1}
-	undefined = Ref{ref: 0}
+	global    = Ref{newGlobal()}
+	null      = Ref{jsNull{}}
+	undefined = Ref{}
 )
 
-var errNotImplemented = errors.New("not implemented; build with GOARCH=wasm")
-
 func valueOf(o interface{}) Ref {
-	return undefined
+	switch x := o.(type) {
+	case Ref:
+		return x
+	case Func:
+		return x.Value
+	case Wrapper:
+		return x.JSValue()
+	case nil:
+		return null
+	case bool:
+		return Ref{x}
+	case int:
+		return Ref{float64(x)}
+	case int8:
+		return Ref{float64(x)}
+	case int16:
+		return Ref{float64(x)}
+	case int32:
+		return Ref{float64(x)}
+	case int64:
+		return Ref{float64(x)}
+	case uint:
+		return Ref{float64(x)}
+	case uint8:
+		return Ref{float64(x)}
+	case uint16:
+		return Ref{float64(x)}
+	case uint32:
+		return Ref{float64(x)}
+	case uint64:
+		return Ref{float64(x)}
+	case uintptr:
+		return Ref{float64(x)}
+	case unsafe.Pointer:
+		return Ref{float64(uintptr(x))}
+	case float32:
+		return Ref{float64(x)}
+	case float64:
+		return Ref{x}
+	case string:
+		return Ref{x}
+	case []interface{}:
+		arr := make([]Ref, 0, len(x))
+		for _, v := range x {
+			arr = append(arr, valueOf(v))
+		}
+		return Ref{newArray(arr)}
+	case map[string]interface{}:
+		obj := newObject(objectProto)
+		for k, v := range x {
+			obj.define(k, valueOf(v), true)
+		}
+		return Ref{obj}
+	default:
+		panic("ValueOf: invalid value")
+	}
+}
+
+func valuesOf(args []interface{}) []Ref {
+	refs := make([]Ref, 0, len(args))
+	for _, a := range args {
+		refs = append(refs, valueOf(a))
+	}
+	return refs
 }
 
 type Wrapper = interface {
@@ -21,132 +90,309 @@ type Wrapper = interface {
 	JSValue() Ref
 }
 
+var _ Wrapper = Func{}
+
 // Func is a wrapped Go function to be called by JavaScript.
 type Func struct {
 	Value Ref
+	id    *trackedFunc
+}
+
+// JSValue implements Wrapper interface.
+func (f Func) JSValue() Ref {
+	return f.Value
+}
+
+type funcData struct {
+	released int32
 }
 
 // Release frees up resources allocated for the function.
 // The function must not be invoked after calling Release.
 func (f Func) Release() {
-	panic(errNotImplemented)
+	untrackFunc(f.id)
+	o, ok := f.Value.v.(*jsObject)
+	if !ok {
+		return
+	}
+	if d, ok := o.data.(*funcData); ok {
+		atomic.StoreInt32(&d.released, 1)
+	}
 }
 
 func funcOf(fnc func(this Ref, refs []Ref) interface{}) Func {
-	panic(errNotImplemented)
+	d := &funcData{}
+	f := newFunc("", 0, func(this Ref, args []Ref) Ref {
+		if atomic.LoadInt32(&d.released) != 0 {
+			panic("call to released function")
+		}
+		return valueOf(fnc(this, args))
+	})
+	f.data = d
+	return Func{Value: Ref{f}, id: trackFunc()}
 }
 
+var _ Wrapper = Ref{}
+
 // Ref is an alias for syscall/js.Value.
 type Ref struct {
-	ref uint64
+	v interface{}
+}
+
+// JSValue implements Wrapper interface.
+func (v Ref) JSValue() Ref {
+	return v
 }
 
 // Type returns the JavaScript type of the value v. It is similar to JavaScript's typeof operator,
 // except that it returns TypeNull instead of TypeObject for null.
 func (v Ref) Type() Type {
-	panic(errNotImplemented)
+	switch x := v.v.(type) {
+	case nil:
+		return TypeUndefined
+	case jsNull:
+		return TypeNull
+	case bool:
+		return TypeBoolean
+	case float64:
+		return TypeNumber
+	case string:
+		return TypeString
+	case *jsSymbol:
+		return TypeSymbol
+	case jsBigInt:
+		// syscall/js reports BigInt values as objects
+		return TypeObject
+	case *jsObject:
+		if x.call != nil {
+			return TypeFunction
+		}
+		return TypeObject
+	}
+	panic("bad type")
 }
 
 // Get returns the JavaScript property p of value v.
 func (v Ref) Get(k string) Ref {
-	return undefined
+	return getProp(v, k)
 }
 
 // Set sets the JavaScript property p of value v to ValueOf(x).
 func (v Ref) Set(p string, x interface{}) {
-	panic(errNotImplemented)
+	setProp(v, p, valueOf(x))
 }
 
 // Index returns JavaScript index i of value v.
 func (v Ref) Index(i int) Ref {
-	panic(errNotImplemented)
+	if o, ok := v.v.(*jsObject); ok && o.class == "Array" {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		if i >= 0 && i < len(o.arr) {
+			return o.arr[i]
+		}
+		return undefined
+	}
+	return getProp(v, fmt.Sprint(i))
 }
 
 // SetIndex sets the JavaScript index i of value v to ValueOf(x).
 func (v Ref) SetIndex(i int, x interface{}) {
-	panic(errNotImplemented)
+	setProp(v, fmt.Sprint(i), valueOf(x))
 }
 
 // Length returns the JavaScript property "length" of v.
 func (v Ref) Length() int {
-	return 0
+	return toInt(getProp(v, "length"), 0)
 }
 
 // Call does a JavaScript call to the method m of value v with the given arguments.
 // It panics if v has no method m.
 // The arguments get mapped to JavaScript values according to the ValueOf function.
 func (v Ref) Call(m string, args ...interface{}) Ref {
-	panic(errNotImplemented)
+	return callMethod(v, m, valuesOf(args)...)
 }
 
 // Invoke does a JavaScript call of the value v with the given arguments.
 // It panics if v is not a function.
 // The arguments get mapped to JavaScript values according to the ValueOf function.
 func (v Ref) Invoke(args ...interface{}) Ref {
-	panic(errNotImplemented)
+	return callFunc(v, undefined, valuesOf(args))
 }
 
 // New uses JavaScript's "new" operator with value v as constructor and the given arguments.
 // It panics if v is not a function.
 // The arguments get mapped to JavaScript values according to the ValueOf function.
 func (v Ref) New(args ...interface{}) Ref {
-	panic(errNotImplemented)
+	return construct(v, valuesOf(args))
 }
 
 // Float returns the value v as a float64. It panics if v is not a JavaScript number.
 func (v Ref) Float() float64 {
-	panic(errNotImplemented)
+	f, ok := v.v.(float64)
+	if !ok {
+		panic(&valueError{"Value.Float", v.Type()})
+	}
+	return f
 }
 
 // Int returns the value v truncated to an int. It panics if v is not a JavaScript number.
 func (v Ref) Int() int {
-	panic(errNotImplemented)
+	f, ok := v.v.(float64)
+	if !ok {
+		panic(&valueError{"Value.Int", v.Type()})
+	}
+	return int(f)
 }
 
 // Bool returns the value v as a bool. It panics if v is not a JavaScript boolean.
 func (v Ref) Bool() bool {
-	panic(errNotImplemented)
+	b, ok := v.v.(bool)
+	if !ok {
+		panic(&valueError{"Value.Bool", v.Type()})
+	}
+	return b
 }
 
 // Truthy returns the JavaScript "truthiness" of the value v. In JavaScript,
 // false, 0, "", null, undefined, and NaN are "falsy", and everything else is
 // "truthy". See https://developer.mozilla.org/en-US/docs/Glossary/Truthy.
 func (v Ref) Truthy() bool {
-	panic(errNotImplemented)
+	switch x := v.v.(type) {
+	case nil, jsNull:
+		return false
+	case bool:
+		return x
+	case float64:
+		return x == x && x != 0
+	case string:
+		return x != ""
+	case jsBigInt:
+		return x != "0"
+	}
+	return true
 }
 
 // String returns the value v converted to string according to JavaScript type conversions.
 func (v Ref) String() string {
-	panic(errNotImplemented)
+	if s, ok := v.v.(*jsSymbol); ok {
+		return symbolString(s)
+	}
+	return toString(v)
 }
 
 // InstanceOf reports whether v is an instance of type t according to JavaScript's instanceof operator.
 func (v Ref) InstanceOf(t Ref) bool {
-	panic(errNotImplemented)
+	return instanceOf(v, t)
 }
 
-// Error is an alias for syscall/js.Error.
-type Error struct {
-	Value Ref
+// valueError occurs when a Ref method is invoked on a value that does not support it.
+type valueError struct {
+	Method string
+	Type   Type
+}
+
+func (e *valueError) Error() string {
+	return "syscall/js: call of " + e.Method + " on " + e.Type.String()
 }
 
-// Error implements the error interface.
-func (e Error) Error() string {
-	return "JavaScript error: undefined"
+// asException checks if a value recovered from a panic is a JS exception, and converts it to Error.
+func asException(r interface{}) (Error, bool) {
+	e, ok := r.(Error)
+	return e, ok
 }
 
 // Type is a type name of a JS value, as returned by "typeof".
 type Type int
 
 const (
-	TypeObject = Type(iota + 1)
+	TypeUndefined = Type(iota)
+	TypeNull
+	TypeBoolean
+	TypeNumber
+	TypeString
+	TypeSymbol
+	TypeObject
 	TypeFunction
 )
 
+func (t Type) String() string {
+	switch t {
+	case TypeUndefined:
+		return "undefined"
+	case TypeNull:
+		return "null"
+	case TypeBoolean:
+		return "boolean"
+	case TypeNumber:
+		return "number"
+	case TypeString:
+		return "string"
+	case TypeSymbol:
+		return "symbol"
+	case TypeObject:
+		return "object"
+	case TypeFunction:
+		return "function"
+	default:
+		return fmt.Sprintf("Type(%d)", int(t))
+	}
+}
+
+// typedArrayOf returns a typed array that shares the memory with a given slice.
 func typedArrayOf(slice interface{}) Ref {
-	panic(errNotImplemented)
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() != reflect.Slice {
+		panic("TypedArrayOf: not a supported slice")
+	}
+	var class *jsObject
+	switch slice.(type) {
+	case []int8:
+		class = typedArrayClasses["Int8Array"]
+	case []int16:
+		class = typedArrayClasses["Int16Array"]
+	case []int32:
+		class = typedArrayClasses["Int32Array"]
+	case []uint8:
+		class = typedArrayClasses["Uint8Array"]
+	case []uint16:
+		class = typedArrayClasses["Uint16Array"]
+	case []uint32:
+		class = typedArrayClasses["Uint32Array"]
+	case []float32:
+		class = typedArrayClasses["Float32Array"]
+	case []float64:
+		class = typedArrayClasses["Float64Array"]
+	default:
+		panic("TypedArrayOf: not a supported slice")
+	}
+	sz := rv.Len() * int(rv.Type().Elem().Size())
+	var data []byte
+	if sz != 0 {
+		data = (*[1 << 30]byte)(unsafe.Pointer(rv.Pointer()))[:sz:sz]
+	}
+	buf := newArrayBuffer(data)
+	return construct(Ref{class}, []Ref{Ref{buf}})
 }
 
-func releaseTypedArray(v Ref) {
-	panic(errNotImplemented)
+func releaseTypedArray(v Ref) {}
+
+// initThrowShim creates a JS function that wraps a Go function and throws errors returned by it.
+func initThrowShim() {
+	throwShim = Value{Ref{newFunc("", 1, func(_ Ref, args []Ref) Ref {
+		fnc := arg(args, 0)
+		return Ref{newFunc("", 0, func(this Ref, args []Ref) Ref {
+			r := callFunc(fnc, this, args)
+			if e := getProp(r, "error"); e.v != nil {
+				throw(e)
+			}
+			return getProp(r, "value")
+		})}
+	})}}
 }
+
+// initPropReader is a no-op on the host. There is no boundary between Go and JS in the emulator,
+// thus reading properties one by one is faster than filling a typed array.
+func initPropReader() {}
+
+// initBatchRunner is a no-op on the host. Batches are executed by the Go interpreter.
+func initBatchRunner() {}
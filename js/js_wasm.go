@@ -15,15 +15,30 @@ var (
 // Ref is an alias for syscall/js.Value.
 type Ref = js.Value
 
-// Error is an alias for syscall/js.Error.
-type Error = js.Error
+// asException checks if a value recovered from a panic is a JS exception, and converts it to Error.
+// It converts syscall/js.Error to Error.
+func asException(r interface{}) (Error, bool) {
+	switch e := r.(type) {
+	case js.Error:
+		return Error{Value: e.Value}, true
+	case Error:
+		return e, true
+	}
+	return Error{}, false
+}
 
 // Type is a type name of a JS value, as returned by "typeof".
 type Type = js.Type
 
 const (
-	TypeObject   = js.TypeObject
-	TypeFunction = js.TypeFunction
+	TypeUndefined = js.TypeUndefined
+	TypeNull      = js.TypeNull
+	TypeBoolean   = js.TypeBoolean
+	TypeNumber    = js.TypeNumber
+	TypeString    = js.TypeString
+	TypeSymbol    = js.TypeSymbol
+	TypeObject    = js.TypeObject
+	TypeFunction  = js.TypeFunction
 )
 
 // Wrapper is an alias for syscall/js.Wrapper.
@@ -34,10 +49,20 @@ func valueOf(o interface{}) Ref {
 }
 
 // Func is a wrapped Go function to be called by JavaScript.
-type Func = js.Func
+type Func struct {
+	js.Func
+	id *trackedFunc
+}
+
+// Release frees up resources allocated for the function.
+// The function must not be invoked after calling Release.
+func (f Func) Release() {
+	untrackFunc(f.id)
+	f.Func.Release()
+}
 
 func funcOf(fnc func(this Ref, refs []Ref) interface{}) Func {
-	return js.FuncOf(fnc)
+	return Func{Func: js.FuncOf(fnc), id: trackFunc()}
 }
 
 func typedArrayOf(slice interface{}) Ref {
@@ -47,3 +72,76 @@ func typedArrayOf(slice interface{}) Ref {
 func releaseTypedArray(v Ref) {
 	js.TypedArray{v}.Release()
 }
+
+// initThrowShim creates a JS function that wraps a Go function and throws errors returned by it.
+func initThrowShim() {
+	throwShim = NativeFuncOf("f", `
+return function() {
+	var r = f.apply(this, arguments);
+	if (r.error !== undefined) {
+		throw r.error;
+	}
+	return r.value;
+}`)
+}
+
+// initPropReader creates a JS function that reads numeric properties of an object into a typed array.
+func initPropReader() {
+	propReader = NativeFuncOf("o", "keys", "dst", `
+for (var i = 0; i < keys.length; i++) {
+	dst[i] = +o[keys[i]];
+}`)
+}
+
+// initBatchRunner creates a JS function that executes operations recorded by Batch.
+// It must be kept in sync with Batch.run.
+func initBatchRunner() {
+	batchRunner = NativeFuncOf("code", "strs", "vals", `
+var ops = Array.prototype.slice.call(code), pc = 0, slots = [];
+strs = JSON.parse(strs);
+function val() {
+	var k = ops[pc++], x = ops[pc++];
+	switch (k) {
+	case 0: return undefined;
+	case 1: return null;
+	case 2: return x;
+	case 3: return x !== 0;
+	case 4: return strs[x];
+	case 5: return slots[x];
+	case 6: return vals[x];
+	case 7: return globalThis[strs[x]];
+	}
+	throw new Error("js: invalid batch operand kind: " + k);
+}
+function args() {
+	var n = ops[pc++], a = new Array(n);
+	for (var i = 0; i < n; i++) {
+		a[i] = val();
+	}
+	return a;
+}
+while (pc < ops.length) {
+	var op = ops[pc++], dst, obj, name;
+	switch (op) {
+	case 1:
+		dst = ops[pc++]; obj = val();
+		slots[dst] = obj[strs[ops[pc++]]];
+		break;
+	case 2:
+		obj = val(); name = strs[ops[pc++]];
+		obj[name] = val();
+		break;
+	case 3:
+		dst = ops[pc++]; obj = val(); name = strs[ops[pc++]];
+		slots[dst] = obj[name].apply(obj, args());
+		break;
+	case 4:
+		dst = ops[pc++]; obj = val();
+		slots[dst] = Reflect.construct(obj, args());
+		break;
+	default:
+		throw new Error("js: invalid batch opcode: " + op);
+	}
+}
+return slots;`)
+}
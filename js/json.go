@@ -3,7 +3,6 @@ package js
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"sync"
 )
 
@@ -42,20 +41,12 @@ func (v Value) MarshalJSON() ([]byte, error) {
 }
 
 // UnmarshalJSON decodes a value from JSON by using native JavaScript functions (JSON.parse).
-func (v *Value) UnmarshalJSON(p []byte) (err error) {
+func (v *Value) UnmarshalJSON(p []byte) error {
 	jsonOnce.Do(initJSON)
 	if jsonParse == undefined {
 		return errors.New("json decoding is not supported")
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			if e, ok := r.(error); ok {
-				err = e
-			} else {
-				err = fmt.Errorf("%v", r)
-			}
-		}
-	}()
-	v.Ref = jsonParse.Invoke(string(p))
-	return err
+	return Try(func() {
+		v.Ref = jsonParse.Invoke(string(p))
+	})
 }
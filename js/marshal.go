@@ -0,0 +1,720 @@
+package js
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Marshaler is the interface implemented by types that can convert themselves into a JS value.
+type Marshaler interface {
+	MarshalJS() (Value, error)
+}
+
+// Unmarshaler is the interface implemented by types that can decode themselves from a JS value.
+type Unmarshaler interface {
+	UnmarshalJS(v Value) error
+}
+
+// UnsupportedTypeError is returned by Marshal when attempting to encode an unsupported value type.
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return "js: unsupported type: " + e.Type.String()
+}
+
+// UnmarshalTypeError describes a JS value that was not appropriate for a value of a specific Go type.
+type UnmarshalTypeError struct {
+	Value string       // description of JS value - "boolean", "array", "number -5"
+	Type  reflect.Type // type of Go value it could not be assigned to
+	Field string       // full path to the field, if any
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	if e.Field != "" {
+		return "js: cannot unmarshal " + e.Value + " into Go struct field " + e.Field + " of type " + e.Type.String()
+	}
+	return "js: cannot unmarshal " + e.Value + " into Go value of type " + e.Type.String()
+}
+
+var (
+	valueType       = reflect.TypeOf(Value{})
+	refType         = reflect.TypeOf(Ref{})
+	timeType        = reflect.TypeOf(time.Time{})
+	marshalerType   = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	wrapperType     = reflect.TypeOf((*Wrapper)(nil)).Elem()
+	anyType         = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// Marshal converts a Go value to a JS value by walking it with reflection:
+//
+//  | Go                     | JavaScript                 |
+//  | ---------------------- | -------------------------- |
+//  | js.Wrapper             | [its value]                |
+//  | js.Marshaler           | result of MarshalJS        |
+//  | nil, nil pointer       | null                       |
+//  | bool                   | boolean                    |
+//  | integers and floats    | number                     |
+//  | string                 | string                     |
+//  | time.Time              | Date                       |
+//  | []byte                 | Uint8Array (copy)          |
+//  | slices and arrays      | new array                  |
+//  | maps                   | new object                 |
+//  | structs                | new object                 |
+//
+// Struct fields can be customized with the "js" tag, using the same rules as encoding/json:
+// `js:"name,omitempty"` renames the field and omits it if it has an empty value,
+// while `js:"-"` skips the field completely. Fields of embedded structs are promoted to the parent object,
+// and name conflicts between them are resolved as in encoding/json.
+//
+// Zero time.Time values are considered empty for omitempty.
+func Marshal(o interface{}) (Value, error) {
+	if o == nil {
+		return Value{null}, nil
+	}
+	return marshalValue(reflect.ValueOf(o))
+}
+
+func marshalValue(rv reflect.Value) (Value, error) {
+	if !rv.IsValid() {
+		return Value{null}, nil
+	}
+	rt := rv.Type()
+	switch rt.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return Value{null}, nil
+		}
+	}
+	if rt.Implements(wrapperType) {
+		return Value{rv.Interface().(Wrapper).JSValue()}, nil
+	}
+	if rt == refType {
+		return Value{rv.Interface().(Ref)}, nil
+	}
+	if rt.Implements(marshalerType) {
+		return rv.Interface().(Marshaler).MarshalJS()
+	} else if rt.Kind() != reflect.Ptr && rv.CanAddr() && reflect.PtrTo(rt).Implements(marshalerType) {
+		return rv.Addr().Interface().(Marshaler).MarshalJS()
+	}
+	if rt == timeType {
+		t := rv.Interface().(time.Time)
+		return New("Date", timeToMillis(t)), nil
+	}
+	switch rt.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return marshalValue(rv.Elem())
+	case reflect.Bool:
+		return ValueOf(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return ValueOf(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return ValueOf(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return ValueOf(rv.Float()), nil
+	case reflect.String:
+		return ValueOf(rv.String()), nil
+	case reflect.Slice:
+		if rv.IsNil() {
+			return Value{null}, nil
+		}
+		if rt.Elem().Kind() == reflect.Uint8 {
+			return marshalBytes(rv.Bytes()), nil
+		}
+		return marshalArray(rv)
+	case reflect.Array:
+		return marshalArray(rv)
+	case reflect.Map:
+		if rv.IsNil() {
+			return Value{null}, nil
+		}
+		return marshalMap(rv)
+	case reflect.Struct:
+		return marshalStruct(rv)
+	}
+	return Value{}, &UnsupportedTypeError{Type: rt}
+}
+
+func marshalBytes(p []byte) Value {
+	v := New("Uint8Array", len(p))
+	CopyBytesToJS(v, p)
+	return v
+}
+
+func marshalArray(rv reflect.Value) (Value, error) {
+	n := rv.Len()
+	arr := Array().New(n)
+	for i := 0; i < n; i++ {
+		v, err := marshalValue(rv.Index(i))
+		if err != nil {
+			return Value{}, err
+		}
+		arr.SetIndex(i, v)
+	}
+	return arr, nil
+}
+
+func mapKey(k reflect.Value) (string, error) {
+	switch k.Kind() {
+	case reflect.String:
+		return k.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", &UnsupportedTypeError{Type: k.Type()}
+}
+
+func marshalMap(rv reflect.Value) (Value, error) {
+	obj := NewObject()
+	for _, k := range rv.MapKeys() {
+		key, err := mapKey(k)
+		if err != nil {
+			return Value{}, err
+		}
+		v, err := marshalValue(rv.MapIndex(k))
+		if err != nil {
+			return Value{}, err
+		}
+		obj.Set(key, v)
+	}
+	return obj, nil
+}
+
+func marshalStruct(rv reflect.Value) (Value, error) {
+	obj := NewObject()
+	for _, f := range cachedFields(rv.Type()) {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+		v, err := marshalValue(fv)
+		if err != nil {
+			return Value{}, err
+		}
+		obj.Set(f.name, v)
+	}
+	return obj, nil
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface().(time.Time).IsZero()
+		}
+	}
+	return false
+}
+
+// fieldByIndex returns a nested field of the struct. It returns false if one of embedded pointers is nil.
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, ind := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(ind)
+	}
+	return rv, true
+}
+
+// Unmarshal decodes a JS value into a Go value pointed to by dst. It uses the same rules as Marshal, but in reverse.
+//
+// Additionally, when decoding into an interface{}, JS objects are converted to map[string]interface{},
+// arrays to []interface{}, numbers to float64, Uint8Array and ArrayBuffer to []byte and Date to time.Time.
+// Struct fields and map values of type js.Value receive a JS value as-is.
+func Unmarshal(v Value, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("js: Unmarshal expects a non-nil pointer, got %T", dst)
+	}
+	return unmarshalValue(v, rv.Elem(), "")
+}
+
+func describe(v Value) string {
+	switch v.Type() {
+	case TypeNumber, TypeBoolean:
+		return v.Type().String() + " " + v.Ref.String()
+	case TypeObject:
+		if v.InstanceOf(Array()) {
+			return "array"
+		}
+	}
+	return v.Type().String()
+}
+
+func unmarshalValue(v Value, rv reflect.Value, field string) error {
+	rt := rv.Type()
+	typeErr := func() error {
+		return &UnmarshalTypeError{Value: describe(v), Type: rt, Field: field}
+	}
+	switch rt {
+	case valueType:
+		rv.Set(reflect.ValueOf(v))
+		return nil
+	case refType:
+		rv.Set(reflect.ValueOf(v.Ref))
+		return nil
+	}
+	if rt.Kind() == reflect.Ptr {
+		if !v.Valid() {
+			rv.Set(reflect.Zero(rt))
+			return nil
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.New(rt.Elem()))
+		}
+		return unmarshalValue(v, rv.Elem(), field)
+	}
+	if rv.CanAddr() && reflect.PtrTo(rt).Implements(unmarshalerType) {
+		return rv.Addr().Interface().(Unmarshaler).UnmarshalJS(v)
+	}
+	if rt == timeType {
+		var t time.Time
+		switch v.Type() {
+		case TypeNumber:
+			t = timeFromMillis(v.Float())
+		case TypeString:
+			var err error
+			t, err = time.Parse(time.RFC3339Nano, v.Ref.String())
+			if err != nil {
+				return err
+			}
+		case TypeObject:
+			if !v.InstanceOfClass("Date") {
+				return typeErr()
+			}
+			t = timeFromMillis(v.Call("getTime").Float())
+		case TypeNull, TypeUndefined:
+		default:
+			return typeErr()
+		}
+		rv.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if !v.Valid() {
+		// null resets interfaces, maps and slices, as in encoding/json,
+		// while other values are left unchanged
+		if v.IsNull() {
+			switch rt.Kind() {
+			case reflect.Interface, reflect.Map, reflect.Slice:
+				rv.Set(reflect.Zero(rt))
+			}
+		}
+		return nil
+	}
+	switch rt.Kind() {
+	case reflect.Interface:
+		if rt.NumMethod() != 0 {
+			return typeErr()
+		}
+		o, err := unmarshalAny(v)
+		if err != nil {
+			return err
+		}
+		if o == nil {
+			rv.Set(reflect.Zero(rt))
+		} else {
+			rv.Set(reflect.ValueOf(o))
+		}
+		return nil
+	case reflect.Bool:
+		if v.Type() != TypeBoolean {
+			return typeErr()
+		}
+		rv.SetBool(v.Bool())
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() != TypeNumber {
+			return typeErr()
+		}
+		f := v.Float()
+		n := int64(f)
+		if float64(n) != f || rv.OverflowInt(n) {
+			return typeErr()
+		}
+		rv.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Type() != TypeNumber {
+			return typeErr()
+		}
+		f := v.Float()
+		n := uint64(f)
+		if f < 0 || float64(n) != f || rv.OverflowUint(n) {
+			return typeErr()
+		}
+		rv.SetUint(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		if v.Type() != TypeNumber {
+			return typeErr()
+		}
+		f := v.Float()
+		if rv.OverflowFloat(f) {
+			return typeErr()
+		}
+		rv.SetFloat(f)
+		return nil
+	case reflect.String:
+		if v.Type() != TypeString {
+			return typeErr()
+		}
+		rv.SetString(v.Ref.String())
+		return nil
+	case reflect.Slice:
+		if rt.Elem().Kind() == reflect.Uint8 && isBinary(v) {
+			p, err := ReadBytes(v)
+			if err != nil {
+				return err
+			}
+			rv.SetBytes(p)
+			return nil
+		}
+		if v.Type() != TypeObject {
+			return typeErr()
+		}
+		n := v.Length()
+		sl := reflect.MakeSlice(rt, n, n)
+		for i := 0; i < n; i++ {
+			if err := unmarshalValue(v.Index(i), sl.Index(i), indexPath(field, i)); err != nil {
+				return err
+			}
+		}
+		rv.Set(sl)
+		return nil
+	case reflect.Array:
+		if v.Type() != TypeObject {
+			return typeErr()
+		}
+		n := v.Length()
+		for i := 0; i < rv.Len(); i++ {
+			if i >= n {
+				rv.Index(i).Set(reflect.Zero(rt.Elem()))
+				continue
+			}
+			if err := unmarshalValue(v.Index(i), rv.Index(i), indexPath(field, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if v.Type() != TypeObject && v.Type() != TypeFunction {
+			return typeErr()
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMap(rt))
+		}
+		kt := rt.Key()
+		for _, key := range v.Keys() {
+			kv, err := parseMapKey(key, kt)
+			if err != nil {
+				return err
+			}
+			ev := reflect.New(rt.Elem()).Elem()
+			if err := unmarshalValue(v.Get(key), ev, fieldPath(field, key)); err != nil {
+				return err
+			}
+			rv.SetMapIndex(kv, ev)
+		}
+		return nil
+	case reflect.Struct:
+		if v.Type() != TypeObject && v.Type() != TypeFunction {
+			return typeErr()
+		}
+		for _, f := range cachedFields(rt) {
+			fv := v.Get(f.name)
+			if fv.IsUndefined() {
+				continue
+			}
+			dst, ok := fieldByIndexAlloc(rv, f.index)
+			if !ok {
+				continue
+			}
+			if err := unmarshalValue(fv, dst, fieldPath(field, f.name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return &UnsupportedTypeError{Type: rt}
+}
+
+// fieldPath appends a field name or a map key to the path used in errors.
+func fieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// indexPath appends an index of the array element to the path used in errors.
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// fieldByIndexAlloc is like fieldByIndex, but allocates nil embedded pointers.
+// It returns false if the embedded pointer is nil and cannot be set.
+func fieldByIndexAlloc(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, ind := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return reflect.Value{}, false
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(ind)
+	}
+	return rv, true
+}
+
+func parseMapKey(key string, kt reflect.Type) (reflect.Value, error) {
+	kv := reflect.New(kt).Elem()
+	switch kt.Kind() {
+	case reflect.String:
+		kv.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(key, 10, 64)
+		if err != nil || kv.OverflowInt(n) {
+			return kv, &UnmarshalTypeError{Value: "key " + strconv.Quote(key), Type: kt}
+		}
+		kv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(key, 10, 64)
+		if err != nil || kv.OverflowUint(n) {
+			return kv, &UnmarshalTypeError{Value: "key " + strconv.Quote(key), Type: kt}
+		}
+		kv.SetUint(n)
+	default:
+		return kv, &UnsupportedTypeError{Type: kt}
+	}
+	return kv, nil
+}
+
+// timeToMillis converts time to JS timestamp. Seconds and nanoseconds are converted separately
+// to avoid overflow of UnixNano for dates outside of years 1678-2262.
+func timeToMillis(t time.Time) float64 {
+	return float64(t.Unix())*1000 + float64(t.Nanosecond())/float64(time.Millisecond)
+}
+
+// timeFromMillis converts JS timestamp to time. See timeToMillis.
+func timeFromMillis(ms float64) time.Time {
+	sec := math.Floor(ms / 1000)
+	nsec := math.Round((ms - sec*1000) * float64(time.Millisecond))
+	return time.Unix(int64(sec), int64(nsec))
+}
+
+func isBinary(v Value) bool {
+	return v.Type() == TypeObject && (v.InstanceOfClass("Uint8Array") || v.InstanceOfClass("ArrayBuffer"))
+}
+
+func unmarshalAny(v Value) (interface{}, error) {
+	switch v.Type() {
+	case TypeNull, TypeUndefined:
+		return nil, nil
+	case TypeBoolean:
+		return v.Bool(), nil
+	case TypeNumber:
+		return v.Float(), nil
+	case TypeString:
+		return v.Ref.String(), nil
+	case TypeObject:
+		switch {
+		case v.InstanceOf(Array()):
+			n := v.Length()
+			arr := make([]interface{}, 0, n)
+			for i := 0; i < n; i++ {
+				o, err := unmarshalAny(v.Index(i))
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, o)
+			}
+			return arr, nil
+		case isBinary(v):
+			return ReadBytes(v)
+		case v.InstanceOfClass("Date"):
+			return timeFromMillis(v.Call("getTime").Float()), nil
+		}
+		obj := make(map[string]interface{})
+		for _, k := range v.Keys() {
+			o, err := unmarshalAny(v.Get(k))
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = o
+		}
+		return obj, nil
+	}
+	return nil, &UnmarshalTypeError{Value: v.Type().String(), Type: anyType}
+}
+
+type field struct {
+	name      string
+	index     []int
+	tagged    bool // name is set by the tag
+	omitEmpty bool
+}
+
+var (
+	fieldsMu sync.RWMutex
+	fields   = make(map[reflect.Type][]field)
+)
+
+func cachedFields(rt reflect.Type) []field {
+	fieldsMu.RLock()
+	fl, ok := fields[rt]
+	fieldsMu.RUnlock()
+	if ok {
+		return fl
+	}
+	fl = dominantFields(typeFields(rt, nil, make(map[reflect.Type]bool)))
+	fieldsMu.Lock()
+	fields[rt] = fl
+	fieldsMu.Unlock()
+	return fl
+}
+
+// typeFields collects the list of fields that should be mapped to JS object properties,
+// including all fields with conflicting names. Fields of the struct itself go first, followed by embedded ones.
+//
+// Visited map contains types on the current path of embedded structs and is used to stop on recursive types.
+// The same type embedded on different paths is visited on each of them.
+func typeFields(rt reflect.Type, index []int, visited map[reflect.Type]bool) []field {
+	if visited[rt] {
+		return nil
+	}
+	visited[rt] = true
+	defer delete(visited, rt)
+	var out, embedded []field
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag := sf.Tag.Get("js")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if j := strings.Index(tag, ","); j >= 0 {
+			name, opts = tag[:j], tag[j+1:]
+		}
+		ind := make([]int, len(index)+1)
+		copy(ind, index)
+		ind[len(index)] = i
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct && ft != timeType {
+			embedded = append(embedded, typeFields(ft, ind, visited)...)
+			continue
+		}
+		if sf.PkgPath != "" {
+			// unexported
+			continue
+		}
+		tagged := name != ""
+		if !tagged {
+			name = sf.Name
+		}
+		out = append(out, field{name: name, index: ind, tagged: tagged, omitEmpty: hasOption(opts, "omitempty")})
+	}
+	return append(out, embedded...)
+}
+
+// dominantFields resolves name conflicts the same way as encoding/json: the least nested field wins,
+// and of the fields on the same depth the tagged one is preferred. If there is still more than one field
+// with the same name, all of them are dropped.
+func dominantFields(fl []field) []field {
+	byName := make(map[string][]field)
+	for _, f := range fl {
+		byName[f.name] = append(byName[f.name], f)
+	}
+	var out []field
+	for _, f := range fl {
+		cands, ok := byName[f.name]
+		if !ok {
+			// already processed
+			continue
+		}
+		delete(byName, f.name)
+		if d, ok := dominantField(cands); ok {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// dominantField selects the field that wins a name conflict. It returns false if the conflict is ambiguous.
+func dominantField(fl []field) (field, bool) {
+	depth := len(fl[0].index)
+	for _, f := range fl[1:] {
+		if len(f.index) < depth {
+			depth = len(f.index)
+		}
+	}
+	var (
+		best  field
+		n     int
+		ntags int
+	)
+	for _, f := range fl {
+		if len(f.index) != depth {
+			continue
+		}
+		if f.tagged {
+			if ntags == 0 {
+				best = f
+			}
+			ntags++
+		} else if ntags == 0 && n == 0 {
+			best = f
+		}
+		n++
+	}
+	if ntags > 1 || (ntags == 0 && n > 1) {
+		return field{}, false
+	}
+	return best, true
+}
+
+func hasOption(opts, name string) bool {
+	for opts != "" {
+		var cur string
+		if i := strings.Index(opts, ","); i >= 0 {
+			cur, opts = opts[:i], opts[i+1:]
+		} else {
+			cur, opts = opts, ""
+		}
+		if cur == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+//+build wasm,js
+
+package js
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testInner struct {
+	Flag bool `js:"flag"`
+}
+
+type testEmbed struct {
+	Embedded string `js:"embedded"`
+}
+
+type testStruct struct {
+	testEmbed
+	Name    string         `js:"name"`
+	Count   int            `js:"count,omitempty"`
+	Ratio   float64        `js:"ratio"`
+	Data    []byte         `js:"data"`
+	Tags    []string       `js:"tags"`
+	Attrs   map[string]int `js:"attrs"`
+	Inner   *testInner     `js:"inner"`
+	When    time.Time      `js:"when"`
+	Raw     Value          `js:"raw"`
+	Any     interface{}    `js:"any"`
+	Skip    string         `js:"-"`
+	NoTag   string
+	private string
+}
+
+func TestMarshal(t *testing.T) {
+	when := time.Date(2019, 3, 1, 10, 20, 30, int(500*time.Millisecond), time.UTC)
+	raw := NewObject()
+	raw.Set("x", 1)
+	s := testStruct{
+		testEmbed: testEmbed{Embedded: "e"},
+		Name:      "name",
+		Ratio:     0.5,
+		Data:      []byte{1, 2, 3},
+		Tags:      []string{"a", "b"},
+		Attrs:     map[string]int{"k": 2},
+		Inner:     &testInner{Flag: true},
+		When:      when,
+		Raw:       raw,
+		Any:       "str",
+		Skip:      "skip",
+		NoTag:     "notag",
+		private:   "private",
+	}
+	v, err := Marshal(s)
+	require.NoError(t, err)
+
+	require.Equal(t, "e", v.Get("embedded").String())
+	require.Equal(t, "name", v.Get("name").String())
+	require.True(t, v.Get("count").IsUndefined())
+	require.Equal(t, 0.5, v.Get("ratio").Float())
+	require.True(t, v.Get("data").InstanceOfClass("Uint8Array"))
+	require.Equal(t, 3, v.Get("data").Length())
+	require.Equal(t, 2, v.Get("data").Index(1).Int())
+	require.True(t, v.Get("tags").InstanceOf(Array()))
+	require.Equal(t, "b", v.Get("tags").Index(1).String())
+	require.Equal(t, 2, v.Get("attrs", "k").Int())
+	require.True(t, v.Get("inner", "flag").Bool())
+	require.True(t, v.Get("when").InstanceOfClass("Date"))
+	require.Equal(t, float64(when.UnixNano()/1e6), v.Get("when").Call("getTime").Float())
+	require.Equal(t, 1, v.Get("raw", "x").Int())
+	require.Equal(t, "str", v.Get("any").String())
+	require.True(t, v.Get("Skip").IsUndefined())
+	require.Equal(t, "notag", v.Get("NoTag").String())
+	require.True(t, v.Get("private").IsUndefined())
+
+	var out testStruct
+	err = Unmarshal(v, &out)
+	require.NoError(t, err)
+	require.True(t, out.When.Equal(when))
+	out.When = when
+	s.Skip, s.private = "", ""
+	require.Equal(t, s, out)
+}
+
+func TestMarshalNil(t *testing.T) {
+	v, err := Marshal(nil)
+	require.NoError(t, err)
+	require.True(t, v.IsNull())
+
+	var p *testInner
+	v, err = Marshal(p)
+	require.NoError(t, err)
+	require.True(t, v.IsNull())
+}
+
+func TestMarshalUnsupported(t *testing.T) {
+	_, err := Marshal(make(chan int))
+	require.NotNil(t, err)
+	_, ok := err.(*UnsupportedTypeError)
+	require.True(t, ok)
+}
+
+func TestUnmarshalAny(t *testing.T) {
+	v := NativeFuncOf(`return {a: [1, "s", null], b: {c: true}, d: new Uint8Array([5])}`).Invoke()
+	var out interface{}
+	err := Unmarshal(v, &out)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"a": []interface{}{1.0, "s", nil},
+		"b": map[string]interface{}{"c": true},
+		"d": []byte{5},
+	}, out)
+}
+
+func TestUnmarshalTypeError(t *testing.T) {
+	v := NativeFuncOf(`return {inner: {flag: 1}}`).Invoke()
+	var out testStruct
+	err := Unmarshal(v, &out)
+	require.NotNil(t, err)
+	e, ok := err.(*UnmarshalTypeError)
+	require.True(t, ok)
+	require.Equal(t, "inner.flag", e.Field)
+	require.Equal(t, "number 1", e.Value)
+
+	var n int8
+	err = Unmarshal(ValueOf(1000), &n)
+	require.NotNil(t, err)
+
+	err = Unmarshal(ValueOf(1), n)
+	require.NotNil(t, err)
+}
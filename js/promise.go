@@ -2,6 +2,7 @@ package js
 
 import (
 	"context"
+	"fmt"
 )
 
 var (
@@ -16,19 +17,88 @@ func NewPromise(fnc func() ([]interface{}, error)) Value {
 		initFunc.Release()
 		resolve, reject := args[0], args[1]
 		res, err := fnc()
-		if err != nil {
-			if w, ok := err.(Wrapper); ok {
-				reject.Invoke(w)
-			} else {
-				reject.Invoke(err.Error())
+		settlePromise(resolve, reject, res, err)
+	})
+	return New("Promise", initFunc)
+}
+
+// NewPromiseContext is similar to NewPromise, but passes a context to the function.
+//
+// If the context is canceled before the function returns, the promise is rejected with
+// an "AbortError" DOMException, the same way as native APIs that accept an AbortSignal.
+// The context passed to the function is canceled when the promise is settled.
+//
+// To allow JavaScript code to cancel the function, see ContextWithSignal.
+func NewPromiseContext(ctx context.Context, fnc func(ctx context.Context) ([]interface{}, error)) Value {
+	var initFunc Func
+	initFunc = AsyncCallbackOf(func(args []Value) {
+		initFunc.Release()
+		resolve, reject := args[0], args[1]
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		type result struct {
+			res []interface{}
+			err error
+		}
+		done := make(chan result, 1)
+		go func() {
+			res, err := fnc(ctx)
+			done <- result{res: res, err: err}
+		}()
+		select {
+		case <-ctx.Done():
+			reject.Invoke(NewAbortError(ctx.Err().Error()))
+		case r := <-done:
+			if r.err != nil && r.err == ctx.Err() {
+				r.err = NewError(NewAbortError(r.err.Error()))
 			}
-		} else {
-			resolve.Invoke(res...)
+			settlePromise(resolve, reject, r.res, r.err)
 		}
 	})
 	return New("Promise", initFunc)
 }
 
+func settlePromise(resolve, reject Value, res []interface{}, err error) {
+	if err != nil {
+		reject.Invoke(errorValue(err))
+	} else {
+		resolve.Invoke(res...)
+	}
+}
+
+// NewAbortError creates a new JavaScript "AbortError" exception with a given message.
+// It creates a DOMException if it is supported by the environment and an Error with a corresponding name otherwise.
+func NewAbortError(msg string) Value {
+	return NewDOMException(msg, "AbortError")
+}
+
+// ContextWithSignal returns a copy of the parent context that is canceled when a given JavaScript AbortSignal is aborted.
+// If the signal is null or undefined, only the returned cancel function and the parent context can cancel the context.
+//
+// The cancel function must be called to remove the event listener from the signal.
+func ContextWithSignal(parent context.Context, signal Value) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	if !signal.Valid() {
+		return ctx, cancel
+	}
+	if signal.Get("aborted").Bool() {
+		cancel()
+		return ctx, cancel
+	}
+	cb := CallbackOf(func(_ []Value) {
+		cancel()
+	})
+	signal.Call("addEventListener", "abort", cb)
+	go func() {
+		<-ctx.Done()
+		signal.Call("removeEventListener", "abort", cb)
+		cb.Release()
+	}()
+	return ctx, cancel
+}
+
 // Promise represents a JavaScript Promise.
 type Promise struct {
 	v    Value
@@ -60,36 +130,182 @@ func (p *Promise) AwaitContext(ctx context.Context) ([]Value, error) {
 
 // Promised returns converts the value into a Promise.
 func (v Value) Promised() *Promise {
+	return v.promised()
+}
+
+// promised is like Promised, but additionally releases given functions when the promise is settled.
+func (v Value) promised(funcs ...Func) *Promise {
 	done := make(chan struct{})
 	p := &Promise{
 		v: v, done: done,
 	}
 	var then, catch Func
-	then = CallbackOf(func(v []Value) {
+	release := func() {
 		then.Release()
 		catch.Release()
+		for _, f := range funcs {
+			f.Release()
+		}
+	}
+	then = CallbackOf(func(v []Value) {
+		release()
 		p.res = v
 		close(done)
 	})
 	catch = CallbackOf(func(v []Value) {
-		then.Release()
-		catch.Release()
+		release()
 		var e Value
 		if len(v) != 0 {
 			e = v[0]
 		}
-		if e.Ref == undefined {
-			e = NewObject()
-		}
-		p.err = NewError(e)
+		p.err = rejectionError(e)
 		close(done)
 	})
 	v.Call("then", then).Call("catch", catch)
 	return p
 }
 
+// Then registers a function that will be called when the promise is resolved and returns a new chained promise.
+//
+// The function is called on a separate goroutine and may block. The chained promise is settled with the results
+// of the function, the same way as in NewPromise. If the promise is rejected, the function is not called and
+// the chained promise is rejected with the same reason.
+func (p *Promise) Then(fnc func(v []Value) ([]interface{}, error)) *Promise {
+	cb := FuncOf(func(_ Value, args []Value) interface{} {
+		return NewPromise(func() ([]interface{}, error) {
+			return fnc(args)
+		})
+	})
+	return p.v.Call("then", cb).promised(cb)
+}
+
+// Catch registers a function that will be called when the promise is rejected and returns a new chained promise.
+//
+// The function is called on a separate goroutine and may block. The chained promise is settled with the results
+// of the function, the same way as in NewPromise. If the promise is resolved, the function is not called and
+// the chained promise is resolved with the same value.
+func (p *Promise) Catch(fnc func(err error) ([]interface{}, error)) *Promise {
+	cb := FuncOf(func(_ Value, args []Value) interface{} {
+		var e Value
+		if len(args) != 0 {
+			e = args[0]
+		}
+		err := rejectionError(e)
+		return NewPromise(func() ([]interface{}, error) {
+			return fnc(err)
+		})
+	})
+	return p.v.Call("catch", cb).promised(cb)
+}
+
+// Finally registers a function that will be called when the promise is settled and returns a new chained promise.
+//
+// The function is called on a separate goroutine and may block. The chained promise is settled the same way as
+// this promise, after the function returns.
+func (p *Promise) Finally(fnc func()) *Promise {
+	cb := FuncOf(func(_ Value, _ []Value) interface{} {
+		return NewPromise(func() ([]interface{}, error) {
+			fnc()
+			return nil, nil
+		})
+	})
+	return p.v.Call("finally", cb).promised(cb)
+}
+
 // Await wait for the promise to be resolved or rejected.
 // A shorthand for calling Await on the promise returned by Promised.
 func (v Value) Await() ([]Value, error) {
 	return v.Promised().Await()
 }
+
+// rejectionError converts a promise rejection reason to a Go error.
+func rejectionError(e Value) error {
+	if e.Ref == undefined {
+		e = NewObject()
+	}
+	return NewError(e)
+}
+
+// PromiseResult is an outcome of a single promise, as reported by PromiseAllSettled.
+type PromiseResult struct {
+	Value Value // resolved value; undefined if the promise was rejected
+	Err   error // rejection reason; nil if the promise was resolved
+}
+
+// AggregateError is returned by PromiseAny when all the promises were rejected.
+// It contains a rejection reason for each promise, in order.
+type AggregateError struct {
+	Errors []error
+}
+
+func (e *AggregateError) Error() string {
+	return fmt.Sprintf("all promises were rejected (%d errors)", len(e.Errors))
+}
+
+// combinePromises calls a static Promise method with the given promises and waits for the result.
+func combinePromises(ctx context.Context, method string, ps []Wrapper) (Value, error) {
+	arr := make([]interface{}, 0, len(ps))
+	for _, p := range ps {
+		arr = append(arr, p.JSValue())
+	}
+	res, err := Class("Promise").Call(method, arr).Promised().AwaitContext(ctx)
+	if err != nil {
+		return Value{}, err
+	}
+	if len(res) == 0 {
+		return Value{undefined}, nil
+	}
+	return res[0], nil
+}
+
+// PromiseAll waits for all the promises to be resolved and returns their values in the same order.
+// If any of the promises is rejected, the first rejection reason is returned as an error.
+//
+// Arguments that are not promises are treated as already resolved values, as in JavaScript's Promise.all.
+func PromiseAll(ctx context.Context, ps ...Wrapper) ([]Value, error) {
+	res, err := combinePromises(ctx, "all", ps)
+	if err != nil {
+		return nil, err
+	}
+	return res.Slice(), nil
+}
+
+// PromiseRace waits for the first of the promises to be settled and returns its value or rejection reason.
+func PromiseRace(ctx context.Context, ps ...Wrapper) (Value, error) {
+	return combinePromises(ctx, "race", ps)
+}
+
+// PromiseAllSettled waits for all the promises to be either resolved or rejected and returns
+// an outcome of each promise in the same order. The error is only returned if the context is canceled.
+func PromiseAllSettled(ctx context.Context, ps ...Wrapper) ([]PromiseResult, error) {
+	res, err := combinePromises(ctx, "allSettled", ps)
+	if err != nil {
+		return nil, err
+	}
+	arr := res.Slice()
+	out := make([]PromiseResult, 0, len(arr))
+	for _, r := range arr {
+		if r.Get("status").String() == "fulfilled" {
+			out = append(out, PromiseResult{Value: r.Get("value")})
+		} else {
+			out = append(out, PromiseResult{Value: Value{undefined}, Err: rejectionError(r.Get("reason"))})
+		}
+	}
+	return out, nil
+}
+
+// PromiseAny waits for the first of the promises to be resolved and returns its value.
+// If all the promises are rejected, it returns an *AggregateError with a rejection reason for each promise.
+func PromiseAny(ctx context.Context, ps ...Wrapper) (Value, error) {
+	res, err := combinePromises(ctx, "any", ps)
+	if e, ok := err.(Error); ok {
+		if errs := (Value{e.Value}).Get("errors"); errs.Valid() && errs.InstanceOf(Array()) {
+			agg := &AggregateError{}
+			for _, r := range errs.Slice() {
+				agg.Errors = append(agg.Errors, rejectionError(r))
+			}
+			return Value{}, agg
+		}
+	}
+	return res, err
+}
@@ -3,6 +3,7 @@
 package js
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -129,3 +130,156 @@ func TestNewPromiseReject(t *testing.T) {
 		t.Fatal("deadlock")
 	}
 }
+
+func testPromises() (ok1, ok2, fail *Promise) {
+	f := NativeFuncOf("v", "ok", `return new Promise((resolve, reject) => {
+	setTimeout(() => ok ? resolve(v) : reject(new Error(v)), 0)
+})`)
+	ok1 = f.Invoke(1, true).Promised()
+	ok2 = f.Invoke(2, true).Promised()
+	fail = f.Invoke("fail", false).Promised()
+	return
+}
+
+func TestPromiseAll(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ok1, ok2, fail := testPromises()
+	res, err := PromiseAll(ctx, ok1, ok2, ValueOf(3))
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	require.Equal(t, 1, res[0].Int())
+	require.Equal(t, 2, res[1].Int())
+	require.Equal(t, 3, res[2].Int())
+
+	_, err = PromiseAll(ctx, ok1, fail)
+	require.NotNil(t, err)
+	require.Equal(t, "JavaScript error: fail", err.Error())
+}
+
+func TestPromiseRace(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ok1, _, fail := testPromises()
+	res, err := PromiseRace(ctx, ok1, fail)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Int())
+}
+
+func TestPromiseAllSettled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ok1, ok2, fail := testPromises()
+	res, err := PromiseAllSettled(ctx, ok1, fail, ok2)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	require.NoError(t, res[0].Err)
+	require.Equal(t, 1, res[0].Value.Int())
+	require.NotNil(t, res[1].Err)
+	require.True(t, res[1].Value.IsUndefined())
+	require.NoError(t, res[2].Err)
+	require.Equal(t, 2, res[2].Value.Int())
+}
+
+func TestPromiseAny(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ok1, _, fail := testPromises()
+	res, err := PromiseAny(ctx, fail, ok1)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Int())
+
+	_, err = PromiseAny(ctx, fail, fail)
+	require.NotNil(t, err)
+	agg, ok := err.(*AggregateError)
+	require.True(t, ok)
+	require.Len(t, agg.Errors, 2)
+}
+
+func TestPromiseThen(t *testing.T) {
+	p := Class("Promise").Call("resolve", 1).Promised().Then(func(v []Value) ([]interface{}, error) {
+		return []interface{}{v[0].Int() + 1}, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	res, err := p.AwaitContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, res[0].Int())
+
+	p = p.Then(func(v []Value) ([]interface{}, error) {
+		return nil, errors.New("fail")
+	})
+	_, err = p.AwaitContext(ctx)
+	require.NotNil(t, err)
+	require.Equal(t, "JavaScript error: fail", err.Error())
+}
+
+func TestPromiseCatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	called := false
+	p := Class("Promise").Call("reject", New("Error", "fail")).Promised().Then(func(v []Value) ([]interface{}, error) {
+		called = true
+		return nil, nil
+	}).Catch(func(err error) ([]interface{}, error) {
+		return []interface{}{err.Error()}, nil
+	})
+	res, err := p.AwaitContext(ctx)
+	require.NoError(t, err)
+	require.False(t, called)
+	require.Equal(t, "JavaScript error: fail", res[0].String())
+}
+
+func TestPromiseFinally(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	called := make(chan struct{})
+	p := Class("Promise").Call("resolve", 1).Promised().Finally(func() {
+		close(called)
+	})
+	res, err := p.AwaitContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, res[0].Int())
+	select {
+	case <-called:
+	default:
+		t.Fatal("function was not called")
+	}
+}
+
+func TestNewPromiseContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	v := NewPromiseContext(ctx, func(ctx context.Context) ([]interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	<-started
+	cancel()
+
+	tctx, tcancel := context.WithTimeout(context.Background(), time.Second)
+	defer tcancel()
+	_, err := v.Promised().AwaitContext(tctx)
+	require.NotNil(t, err)
+	require.Equal(t, "AbortError", Value{err.(Error).Value}.Get("name").String())
+}
+
+func TestContextWithSignal(t *testing.T) {
+	ctrl := New("AbortController")
+	ctx, cancel := ContextWithSignal(context.Background(), ctrl.Get("signal"))
+	defer cancel()
+
+	ctrl.Call("abort")
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context was not canceled")
+	}
+}
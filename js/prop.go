@@ -0,0 +1,108 @@
+package js
+
+import (
+	"math"
+	"sync"
+)
+
+var (
+	propReader     Value
+	propReaderOnce sync.Once
+)
+
+// Props is a prepared accessor for a fixed set of properties.
+//
+// On wasm it reads all properties of an object in a single JS call, instead of calling Get for each of them.
+// On other platforms, or if the JS helper cannot be created, it falls back to reading properties one by one.
+//
+// Props can be declared as a package-level variable: no JS calls are made until the first read.
+// It is safe to use Props from multiple goroutines.
+type Props struct {
+	names []string
+
+	once sync.Once
+	keys Value
+
+	mu  sync.Mutex
+	buf []float64
+}
+
+// NewProps prepares an accessor for properties with given names.
+func NewProps(names ...string) *Props {
+	return &Props{names: append([]string{}, names...)}
+}
+
+// Names returns property names of the accessor.
+func (p *Props) Names() []string {
+	return append([]string{}, p.names...)
+}
+
+// Len returns the number of properties.
+func (p *Props) Len() int {
+	return len(p.names)
+}
+
+func (p *Props) init() {
+	propReaderOnce.Do(func() {
+		// Function constructor might be forbidden by Content Security Policy
+		Try(initPropReader)
+	})
+	keys := make([]interface{}, len(p.names))
+	for i, name := range p.names {
+		keys[i] = name
+	}
+	p.keys = ValueOf(keys)
+}
+
+// Floats reads numeric properties of the object into dst, resizing it if necessary, and returns it.
+// Values are converted with Number(v); missing properties are read as NaN.
+func (p *Props) Floats(v Wrapper, dst []float64) []float64 {
+	p.once.Do(p.init)
+	n := len(p.names)
+	if cap(dst) < n {
+		dst = make([]float64, n)
+	}
+	dst = dst[:n]
+	if n == 0 {
+		return dst
+	}
+	obj := v.JSValue()
+	if !propReader.Valid() {
+		for i, name := range p.names {
+			dst[i] = numberOf(Value{obj.Get(name)})
+		}
+		return dst
+	}
+	arr := TypedArrayOf(dst)
+	propReader.Invoke(obj, p.keys, arr)
+	arr.Release()
+	return dst
+}
+
+// Ints is the same as Floats, but converts values to integers. Missing properties are read as zero.
+func (p *Props) Ints(v Wrapper, dst []int) []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.buf = p.Floats(v, p.buf)
+	n := len(p.buf)
+	if cap(dst) < n {
+		dst = make([]int, n)
+	}
+	dst = dst[:n]
+	for i, f := range p.buf {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			dst[i] = 0
+		} else {
+			dst[i] = int(f)
+		}
+	}
+	return dst
+}
+
+// numberOf is a fallback implementation of JS Number(v) conversion.
+func numberOf(v Value) float64 {
+	if v.Type() == TypeNumber {
+		return v.Float()
+	}
+	return Class("Number").Invoke(v).Float()
+}
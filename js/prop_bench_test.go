@@ -0,0 +1,31 @@
+//+build wasm
+
+package js
+
+import "testing"
+
+// Props only differ from sequential Get calls on wasm, where each Get crosses the boundary between Go and JS.
+// The host emulation reads properties one by one in both cases.
+
+var benchObj = Obj{"clientX": 10, "clientY": 20, "pageX": 30, "pageY": 40}
+
+func BenchmarkGet(b *testing.B) {
+	obj := ValueOf(benchObj)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = obj.Get("clientX").Float()
+		_ = obj.Get("clientY").Float()
+		_ = obj.Get("pageX").Float()
+		_ = obj.Get("pageY").Float()
+	}
+}
+
+func BenchmarkProps(b *testing.B) {
+	obj := ValueOf(benchObj)
+	p := NewProps("clientX", "clientY", "pageX", "pageY")
+	var buf [4]float64
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = p.Floats(obj, buf[:0])
+	}
+}
@@ -0,0 +1,25 @@
+package js
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProps(t *testing.T) {
+	obj := ValueOf(Obj{"x": 1.5, "y": -2, "s": "3"})
+	p := NewProps("x", "y", "s", "none")
+	require.Equal(t, 4, p.Len())
+
+	f := p.Floats(obj, nil)
+	require.Equal(t, []float64{1.5, -2, 3}, f[:3])
+	require.True(t, math.IsNaN(f[3]))
+
+	buf := make([]float64, 0, 8)
+	f = p.Floats(obj, buf)
+	require.Equal(t, 4, len(f))
+	require.Equal(t, &buf[:1][0], &f[0])
+
+	require.Equal(t, []int{1, -2, 3, 0}, p.Ints(obj, nil))
+}
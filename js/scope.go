@@ -0,0 +1,116 @@
+package js
+
+import (
+	"context"
+	"sync"
+)
+
+// FuncScope tracks Go functions exposed to JS and releases all of them at once.
+//
+// Cleanup functions can be registered with Defer, for example to remove event listeners before their callbacks
+// are released. A zero FuncScope is ready to use. Functions added to a scope after it was released
+// are released immediately.
+type FuncScope struct {
+	mu       sync.Mutex
+	funcs    []Func
+	defers   []func()
+	released bool
+}
+
+// NewFuncScope creates a new function scope.
+func NewFuncScope() *FuncScope {
+	return &FuncScope{}
+}
+
+// NewFuncScopeContext creates a new function scope that is released when the context is canceled.
+func NewFuncScopeContext(ctx context.Context) *FuncScope {
+	s := NewFuncScope()
+	if ctx.Done() != nil {
+		go func() {
+			<-ctx.Done()
+			s.Release()
+		}()
+	}
+	return s
+}
+
+// Add adds the function to the scope.
+func (s *FuncScope) Add(f Func) {
+	s.mu.Lock()
+	if s.released {
+		s.mu.Unlock()
+		f.Release()
+		return
+	}
+	s.funcs = append(s.funcs, f)
+	s.mu.Unlock()
+}
+
+// Defer registers a function that will be called when the scope is released, before releasing the functions.
+// Deferred functions are called in the reverse order.
+func (s *FuncScope) Defer(fnc func()) {
+	s.mu.Lock()
+	if s.released {
+		s.mu.Unlock()
+		fnc()
+		return
+	}
+	s.defers = append(s.defers, fnc)
+	s.mu.Unlock()
+}
+
+// FuncOf is the same as FuncOf, but adds the function to the scope.
+func (s *FuncScope) FuncOf(fnc func(this Value, args []Value) interface{}) Func {
+	f := FuncOf(fnc)
+	s.Add(f)
+	return f
+}
+
+// CallbackOf is the same as CallbackOf, but adds the function to the scope.
+func (s *FuncScope) CallbackOf(fnc func(v []Value)) Func {
+	f := CallbackOf(fnc)
+	s.Add(f)
+	return f
+}
+
+// AsyncCallbackOf is the same as AsyncCallbackOf, but adds the function to the scope.
+func (s *FuncScope) AsyncCallbackOf(fnc func(v []Value)) Func {
+	f := AsyncCallbackOf(fnc)
+	s.Add(f)
+	return f
+}
+
+// AddEventListener adds an event listener to the target. The listener is removed when the scope is released.
+func (s *FuncScope) AddEventListener(target Wrapper, typ string, fnc func(v Value)) Func {
+	t := Value{target.JSValue()}
+	cb := NewEventCallback(fnc)
+	t.Call("addEventListener", typ, cb)
+	s.Add(cb)
+	s.Defer(func() {
+		t.Call("removeEventListener", typ, cb)
+	})
+	return cb
+}
+
+// Len returns the number of functions in the scope.
+func (s *FuncScope) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.funcs)
+}
+
+// Release calls deferred functions and releases all functions in the scope.
+// Release can be called multiple times.
+func (s *FuncScope) Release() {
+	s.mu.Lock()
+	funcs, defers := s.funcs, s.defers
+	s.funcs, s.defers = nil, nil
+	s.released = true
+	s.mu.Unlock()
+	for i := len(defers) - 1; i >= 0; i-- {
+		defers[i]()
+	}
+	for _, f := range funcs {
+		f.Release()
+	}
+}
@@ -0,0 +1,51 @@
+package js
+
+// Try runs fnc and converts a panic caused by a JS exception into an error.
+//
+// The thrown JS value is returned as Error. Other panics, like nil pointer dereferences in Go code, are not recovered.
+func Try(fnc func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := asException(r)
+			if !ok {
+				panic(r)
+			}
+			err = e
+		}
+	}()
+	fnc()
+	return nil
+}
+
+// TryGet is the same as Get, but returns an error instead of panicking,
+// for example when one of the objects on the path is undefined.
+func (v Value) TryGet(name string, path ...string) (out Value, err error) {
+	err = Try(func() {
+		out = v.Get(name, path...)
+	})
+	return
+}
+
+// TryCall is the same as Call, but returns an error instead of panicking if JS method throws an exception.
+func (v Value) TryCall(name string, args ...interface{}) (out Value, err error) {
+	err = Try(func() {
+		out = v.Call(name, args...)
+	})
+	return
+}
+
+// TryInvoke is the same as Invoke, but returns an error instead of panicking if JS function throws an exception.
+func (v Value) TryInvoke(args ...interface{}) (out Value, err error) {
+	err = Try(func() {
+		out = v.Invoke(args...)
+	})
+	return
+}
+
+// TryNew is the same as New, but returns an error instead of panicking if JS constructor throws an exception.
+func (v Value) TryNew(args ...interface{}) (out Value, err error) {
+	err = Try(func() {
+		out = v.New(args...)
+	})
+	return
+}
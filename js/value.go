@@ -1,6 +1,252 @@
 package js
 
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
 // Type is an analog for JS "typeof" operator.
 func (v Value) Type() Type {
 	return v.Ref.Type()
 }
+
+// Kind is a kind of JS value. Unlike Type, it distinguishes arrays and BigInt values from other objects.
+type Kind int
+
+const (
+	KindUndefined = Kind(iota)
+	KindNull
+	KindBool
+	KindNumber
+	KindBigInt
+	KindString
+	KindSymbol
+	KindFunction
+	KindArray
+	KindObject
+)
+
+var kindNames = []string{
+	KindUndefined: "undefined",
+	KindNull:      "null",
+	KindBool:      "boolean",
+	KindNumber:    "number",
+	KindBigInt:    "bigint",
+	KindString:    "string",
+	KindSymbol:    "symbol",
+	KindFunction:  "function",
+	KindArray:     "array",
+	KindObject:    "object",
+}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return "Kind(" + strconv.Itoa(int(k)) + ")"
+	}
+	return kindNames[k]
+}
+
+// Kind returns a kind of the value. A zero Value is reported as undefined.
+func (v Value) Kind() Kind {
+	if v.isZero() {
+		return KindUndefined
+	}
+	switch v.Type() {
+	case TypeUndefined:
+		return KindUndefined
+	case TypeNull:
+		return KindNull
+	case TypeBoolean:
+		return KindBool
+	case TypeNumber:
+		return KindNumber
+	case TypeString:
+		return KindString
+	case TypeSymbol:
+		return KindSymbol
+	case TypeFunction:
+		return KindFunction
+	}
+	switch v.tag() {
+	case "Array":
+		return KindArray
+	case "BigInt":
+		if v.isBigInt() {
+			return KindBigInt
+		}
+	}
+	return KindObject
+}
+
+// tag returns a class name of the object, as reported by Object.prototype.toString.
+func (v Value) tag() string {
+	s := Get("Object", "prototype", "toString").Call("call", v).String()
+	s = strings.TrimPrefix(s, "[object ")
+	return strings.TrimSuffix(s, "]")
+}
+
+// isBigInt checks if the value is a BigInt primitive, as opposed to a BigInt wrapper object.
+func (v Value) isBigInt() bool {
+	// Object(v) returns the same value for objects and creates a wrapper for primitives
+	return !Object().Call("is", Object().Invoke(v), v).Bool()
+}
+
+// IsBigInt checks if the value is a JS BigInt.
+func (v Value) IsBigInt() bool {
+	return v.Kind() == KindBigInt
+}
+
+// AsBool returns the value as a bool. It returns false if the value is not a boolean.
+func (v Value) AsBool() (bool, bool) {
+	if v.isZero() || v.Type() != TypeBoolean {
+		return false, false
+	}
+	return v.Bool(), true
+}
+
+// AsFloat returns the value as a float. It returns false if the value is not a number.
+func (v Value) AsFloat() (float64, bool) {
+	if v.isZero() || v.Type() != TypeNumber {
+		return 0, false
+	}
+	return v.Float(), true
+}
+
+// AsInt returns the value as an int. It returns false if the value is not a number, has a fractional part,
+// or cannot be represented as an int.
+func (v Value) AsInt() (int, bool) {
+	f, ok := v.AsFloat()
+	if !ok || f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	i := int64(f)
+	if int64(int(i)) != i {
+		return 0, false
+	}
+	return int(i), true
+}
+
+// AsString returns the value as a string. It returns false if the value is not a string.
+func (v Value) AsString() (string, bool) {
+	if v.isZero() || v.Type() != TypeString {
+		return "", false
+	}
+	return v.Ref.String(), true
+}
+
+// AsInt64 returns the value as an int64. Both integer numbers and BigInt values are supported.
+// It returns false if the value is not an integer, or cannot be represented as an int64.
+func (v Value) AsInt64() (int64, bool) {
+	if f, ok := v.AsFloat(); ok {
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(f), true
+	}
+	if v.Kind() != KindBigInt {
+		return 0, false
+	}
+	i, err := strconv.ParseInt(v.String(), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return i, true
+}
+
+// Int64 returns the value as an int64. Both numbers and BigInt values are supported; numbers are truncated.
+// It panics if the value is not a number or a BigInt, or if BigInt cannot be represented as an int64.
+func (v Value) Int64() int64 {
+	if f, ok := v.AsFloat(); ok {
+		return int64(f)
+	}
+	i, ok := v.AsInt64()
+	if !ok {
+		panic(fmt.Errorf("js: cannot convert %v to int64", v.Kind()))
+	}
+	return i
+}
+
+// BigIntOf creates a JS BigInt value from int64.
+func BigIntOf(x int64) Value {
+	return Get("BigInt").Invoke(strconv.FormatInt(x, 10))
+}
+
+// StrictEqual compares two values with JS "===" operator.
+func (v Value) StrictEqual(w Value) bool {
+	if x, ok := v.AsFloat(); ok {
+		y, ok := w.AsFloat()
+		return ok && x == y
+	}
+	k := v.Kind()
+	if k != w.Kind() {
+		return false
+	} else if isNullKind(k) {
+		return true
+	}
+	if v.Ref == w.Ref {
+		return true
+	}
+	return Object().Call("is", v, w).Bool()
+}
+
+// Equal compares two values with JS "==" operator.
+func (v Value) Equal(w Value) bool {
+	kv, kw := v.Kind(), w.Kind()
+	if kv == kw || (isObjectKind(kv) && isObjectKind(kw)) {
+		return v.StrictEqual(w)
+	}
+	switch {
+	case isNullKind(kv) || isNullKind(kw):
+		return isNullKind(kv) && isNullKind(kw)
+	case kv == KindBool:
+		return ValueOf(numberOf(v)).Equal(w)
+	case kw == KindBool:
+		return v.Equal(ValueOf(numberOf(w)))
+	case kv == KindSymbol || kw == KindSymbol:
+		return false
+	case isObjectKind(kv):
+		return v.toPrimitive().Equal(w)
+	case isObjectKind(kw):
+		return v.Equal(w.toPrimitive())
+	case kv == KindBigInt || kw == KindBigInt:
+		// compare mathematical values
+		a, b := v, w
+		if kw == KindBigInt {
+			a, b = w, v
+		}
+		f := numberOf(b)
+		if math.IsNaN(f) || math.IsInf(f, 0) || f != math.Trunc(f) {
+			return false
+		}
+		return a.String() == strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	// number and string
+	return numberOf(v) == numberOf(w)
+}
+
+func isNullKind(k Kind) bool {
+	return k == KindUndefined || k == KindNull
+}
+
+func isObjectKind(k Kind) bool {
+	return k == KindObject || k == KindArray || k == KindFunction
+}
+
+// toPrimitive implements JS ToPrimitive conversion with the default hint.
+func (v Value) toPrimitive() Value {
+	names := []string{"valueOf", "toString"}
+	if v.tag() == "Date" {
+		// dates use string hint by default
+		names[0], names[1] = names[1], names[0]
+	}
+	for _, name := range names {
+		if f := v.Get(name); f.Type() == TypeFunction {
+			if r := f.Call("call", v); !isObjectKind(r.Kind()) {
+				return r
+			}
+		}
+	}
+	panic(Error{Value: New("TypeError", "Cannot convert object to primitive value").Ref})
+}
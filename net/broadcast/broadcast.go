@@ -0,0 +1,144 @@
+// Package broadcast provides publish-subscribe messaging between tabs, windows, iframes and workers
+// of the same origin on top of BroadcastChannel.
+//
+// Messages published to a channel are delivered to all other channels with the same name, but not to the sender:
+//
+//	ch, err := broadcast.Open("updates")
+//	cancel := ch.Subscribe(func(m broadcast.Message) {
+//		var u Update
+//		if err := m.Decode(&u); err == nil {
+//			go handle(u)
+//		}
+//	})
+//	defer cancel()
+//	err = ch.Publish(Update{ID: 1})
+package broadcast
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/dennwc/dom/js"
+)
+
+// ErrClosed is returned when publishing to a closed channel.
+var ErrClosed = errors.New("broadcast: channel closed")
+
+var _ js.Wrapper = (*Channel)(nil)
+
+// Channel is a named broadcast channel.
+type Channel struct {
+	v    js.Value
+	name string
+
+	mu     sync.Mutex
+	closed bool
+	subs   map[*subscription]struct{}
+}
+
+// Message is a message received from a channel.
+type Message struct {
+	// Data is a structured clone of a published value.
+	Data js.Value
+}
+
+// Decode decodes the message into a Go value, as js.Unmarshal does.
+func (m Message) Decode(dst interface{}) error {
+	return js.Unmarshal(m.Data, dst)
+}
+
+type subscription struct {
+	cb   js.Func
+	once sync.Once
+}
+
+// Open joins a channel with a given name.
+//
+// It returns js.ErrNotSupported if BroadcastChannel is not available.
+func Open(name string) (*Channel, error) {
+	if js.Get("BroadcastChannel").Type() != js.TypeFunction {
+		return nil, js.ErrNotSupported
+	}
+	v, err := js.Class("BroadcastChannel").TryNew(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Channel{v: v, name: name, subs: make(map[*subscription]struct{})}, nil
+}
+
+// Name returns the name of the channel.
+func (c *Channel) Name() string {
+	return c.name
+}
+
+// JSValue implements js.Wrapper.
+func (c *Channel) JSValue() js.Ref {
+	return c.v.JSValue()
+}
+
+// Publish sends a message to all other channels with the same name. Go values are converted with js.Marshal first.
+// The message is copied with the structured clone algorithm, thus functions and DOM nodes cannot be sent.
+func (c *Channel) Publish(msg interface{}) error {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	v, err := js.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = c.v.TryCall("postMessage", v)
+	return err
+}
+
+// Subscribe registers a function that is called for each message received by the channel.
+// The function is called on the event goroutine and must not block.
+//
+// It returns a function that cancels the subscription.
+func (c *Channel) Subscribe(fnc func(m Message)) (cancel func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return func() {}
+	}
+	s := &subscription{
+		cb: js.NewEventCallback(func(ev js.Value) {
+			fnc(Message{Data: ev.Get("data")})
+		}),
+	}
+	c.subs[s] = struct{}{}
+	c.v.Call("addEventListener", "message", s.cb)
+	return func() {
+		c.mu.Lock()
+		delete(c.subs, s)
+		c.mu.Unlock()
+		c.unsubscribe(s)
+	}
+}
+
+func (c *Channel) unsubscribe(s *subscription) {
+	s.once.Do(func() {
+		c.v.Call("removeEventListener", "message", s.cb)
+		s.cb.Release()
+	})
+}
+
+// Close leaves the channel and cancels all subscriptions.
+func (c *Channel) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	subs := c.subs
+	c.subs = nil
+	c.mu.Unlock()
+	for s := range subs {
+		c.unsubscribe(s)
+	}
+	c.v.Call("close")
+	return nil
+}
@@ -0,0 +1,53 @@
+//+build !wasm
+
+package broadcast
+
+import (
+	"testing"
+
+	_ "github.com/dennwc/dom/headless"
+	"github.com/stretchr/testify/require"
+)
+
+type update struct {
+	ID   int    `js:"id"`
+	Text string `js:"text"`
+}
+
+func TestBroadcast(t *testing.T) {
+	a, err := Open("test")
+	require.NoError(t, err)
+	b, err := Open("test")
+	require.NoError(t, err)
+	other, err := Open("other")
+	require.NoError(t, err)
+	defer other.Close()
+
+	recv := func(c *Channel) <-chan Message {
+		ch := make(chan Message, 10)
+		c.Subscribe(func(m Message) {
+			ch <- m
+		})
+		return ch
+	}
+	ca, cb, co := recv(a), recv(b), recv(other)
+
+	err = a.Publish(update{ID: 1, Text: "hello"})
+	require.NoError(t, err)
+
+	var u update
+	require.NoError(t, (<-cb).Decode(&u))
+	require.Equal(t, update{ID: 1, Text: "hello"}, u)
+
+	// the message is received by b only, check it after b replies to a
+	require.NoError(t, b.Publish("done"))
+	var s string
+	require.NoError(t, (<-ca).Decode(&s))
+	require.Equal(t, "done", s)
+	require.Equal(t, 0, len(ca))
+	require.Equal(t, 0, len(co))
+
+	require.NoError(t, b.Close())
+	require.Equal(t, ErrClosed, b.Publish("closed"))
+	require.NoError(t, a.Close())
+}
@@ -0,0 +1,385 @@
+package msgport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/dennwc/dom/js"
+)
+
+// frame types
+const (
+	frameHello = 1 + iota
+	frameData
+	frameAck
+	frameClose
+)
+
+// maxFrame is the maximal size of the data frame payload.
+const maxFrame = 64 * 1024
+
+// ErrClosed is returned when reading from or writing to a closed connection.
+var ErrClosed = errors.New("msgport: connection closed")
+
+var _ net.Conn = (*Conn)(nil)
+
+// Conn is a net.Conn on top of a postMessage target.
+type Conn struct {
+	target js.Value // messages are posted to this object
+	source js.Value // messages are received from this object
+	window bool     // target is a Window
+	origin string   // expected origin of messages, if not empty
+	post   js.Obj   // postMessage options, except for transfer list
+	size   int      // receive window
+	events []listener
+	local  net.Addr
+	remote net.Addr
+
+	done   chan struct{}
+	close  sync.Once
+	read   chan struct{} // wakes Read
+	credit chan struct{} // wakes Write
+	rdl    deadline
+	wdl    deadline
+	wmu    sync.Mutex // serializes Write calls
+
+	mu       sync.Mutex
+	rerr     error
+	werr     error
+	rbuf     bytes.Buffer
+	consumed int  // bytes read since the last ack
+	avail    int  // number of bytes that the peer can accept
+	hello    bool // received hello from the peer
+}
+
+type listener struct {
+	event string
+	cb    js.Func
+}
+
+// New creates a connection on top of a postMessage target: MessagePort, Worker, worker global scope or Window.
+// Options are optional.
+//
+// For Window targets, like an iframe content window, messages are received by the current window and filtered
+// by the source and the origin. Other targets both send and receive messages.
+//
+// Both sides must create a connection for the data to flow: Write blocks until the peer announces its window.
+func New(target js.Value, opts *Options) *Conn {
+	if opts == nil {
+		opts = &Options{}
+	}
+	c := &Conn{
+		target: target,
+		source: target,
+		origin: opts.Origin,
+		post:   js.Obj{},
+		size:   opts.Window,
+		local:  opts.Local,
+		remote: opts.Remote,
+		done:   make(chan struct{}),
+		read:   make(chan struct{}, 1),
+		credit: make(chan struct{}, 1),
+		rdl:    makeDeadline(),
+		wdl:    makeDeadline(),
+	}
+	if c.size <= 0 {
+		c.size = DefaultWindow
+	}
+	if c.local == nil {
+		c.local = Addr("")
+	}
+	if c.remote == nil {
+		c.remote = Addr("")
+	}
+	if w := target.Get("window"); w.Valid() && w.StrictEqual(target) {
+		c.window = true
+		c.source = js.Get("self")
+		switch c.origin {
+		case "":
+			// same origin only
+			c.origin = js.Get("location", "origin").String()
+			c.post["targetOrigin"] = "/"
+		default:
+			c.post["targetOrigin"] = c.origin
+		}
+	}
+	if c.origin == "*" {
+		c.origin = ""
+	}
+	c.listen("message", c.HandleMessage)
+	c.listen("messageerror", c.HandleError)
+	if c.source.Get("start").Type() == js.TypeFunction {
+		// MessagePort doesn't dispatch messages until started
+		c.source.Call("start")
+	}
+	c.sendHello(true)
+	return c
+}
+
+func (c *Conn) listen(event string, fnc func(js.Value)) {
+	cb := js.NewEventCallback(fnc)
+	c.source.Call("addEventListener", event, cb)
+	c.events = append(c.events, listener{event: event, cb: cb})
+}
+
+// WatchErrors makes the connection fail when a given event is fired on the target.
+// For example, Worker fires "error" event when the worker script fails.
+func (c *Conn) WatchErrors(event string) {
+	c.listen(event, c.HandleError)
+}
+
+// JSValue returns the target of the connection. It implements js.Wrapper.
+func (c *Conn) JSValue() js.Ref {
+	return c.target.JSValue()
+}
+
+func wake(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// send posts a frame to the target.
+func (c *Conn) send(p []byte) error {
+	t := js.NewTransfer()
+	arr := t.Bytes(p)
+	opts := t.Options()
+	for k, v := range c.post {
+		opts.Set(k, v)
+	}
+	_, err := c.target.TryCall("postMessage", arr, opts)
+	return err
+}
+
+func (c *Conn) sendHello(reply bool) {
+	var p [6]byte
+	p[0] = frameHello
+	if reply {
+		p[1] = 1
+	}
+	binary.LittleEndian.PutUint32(p[2:], uint32(c.size))
+	if err := c.send(p[:]); err != nil {
+		c.fail(err)
+	}
+}
+
+func (c *Conn) fail(err error) {
+	c.mu.Lock()
+	if c.rerr == nil {
+		c.rerr = err
+	}
+	if c.werr == nil {
+		c.werr = err
+	}
+	c.mu.Unlock()
+	wake(c.read)
+	wake(c.credit)
+}
+
+// HandleMessage processes a message event. It can be used to pass messages received before the connection
+// was created.
+func (c *Conn) HandleMessage(ev js.Value) {
+	if c.window && !ev.Get("source").StrictEqual(c.target) {
+		return
+	}
+	if c.origin != "" && ev.Get("origin").String() != c.origin {
+		return
+	}
+	data := ev.Get("data")
+	if !data.InstanceOfClass("Uint8Array") || data.Length() == 0 {
+		return
+	}
+	p := make([]byte, data.Length())
+	if _, err := js.CopyBytesToGo(p, data); err != nil {
+		c.fail(err)
+		return
+	}
+	switch p[0] {
+	case frameHello:
+		if len(p) < 6 {
+			return
+		}
+		c.mu.Lock()
+		first := !c.hello
+		if first {
+			c.hello = true
+			c.avail = int(binary.LittleEndian.Uint32(p[2:]))
+		}
+		c.mu.Unlock()
+		if first {
+			wake(c.credit)
+		}
+		if p[1] != 0 {
+			// the peer might have missed our hello, if it was created later
+			c.sendHello(false)
+		}
+	case frameData:
+		c.mu.Lock()
+		c.rbuf.Write(p[1:])
+		c.mu.Unlock()
+		wake(c.read)
+	case frameAck:
+		if len(p) < 5 {
+			return
+		}
+		c.mu.Lock()
+		c.avail += int(binary.LittleEndian.Uint32(p[1:]))
+		c.mu.Unlock()
+		wake(c.credit)
+	case frameClose:
+		c.mu.Lock()
+		if c.rerr == nil {
+			c.rerr = io.EOF
+		}
+		if c.werr == nil {
+			c.werr = io.ErrClosedPipe
+		}
+		c.mu.Unlock()
+		wake(c.read)
+		wake(c.credit)
+	}
+}
+
+// HandleError fails the connection with an error described by the event.
+func (c *Conn) HandleError(ev js.Value) {
+	// don't let the browser report an uncaught error, the error is returned by Read instead
+	ev.Call("preventDefault")
+	c.fail(js.NewError(ev))
+}
+
+func (c *Conn) isClosed() bool {
+	return isClosedChan(c.done)
+}
+
+// Read reads data sent by the peer. It returns io.EOF after the peer closed the connection and all data was read.
+func (c *Conn) Read(b []byte) (int, error) {
+	for {
+		switch {
+		case c.isClosed():
+			return 0, ErrClosed
+		case isClosedChan(c.rdl.wait()):
+			return 0, timeoutError{}
+		}
+		c.mu.Lock()
+		if c.rbuf.Len() != 0 || len(b) == 0 {
+			n, _ := c.rbuf.Read(b)
+			c.consumed += n
+			ack := 0
+			if c.consumed >= c.size/2 {
+				ack, c.consumed = c.consumed, 0
+			}
+			c.mu.Unlock()
+			if ack != 0 {
+				var p [5]byte
+				p[0] = frameAck
+				binary.LittleEndian.PutUint32(p[1:], uint32(ack))
+				if err := c.send(p[:]); err != nil {
+					c.fail(err)
+				}
+			}
+			return n, nil
+		}
+		err := c.rerr
+		c.mu.Unlock()
+		if err != nil {
+			return 0, err
+		}
+		select {
+		case <-c.done:
+		case <-c.rdl.wait():
+		case <-c.read:
+		}
+	}
+}
+
+// Write sends data to the peer. It blocks if the receive window of the peer is full.
+func (c *Conn) Write(b []byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	n := 0
+	for {
+		switch {
+		case c.isClosed():
+			return n, ErrClosed
+		case isClosedChan(c.wdl.wait()):
+			return n, timeoutError{}
+		case len(b) == 0:
+			return n, nil
+		}
+		c.mu.Lock()
+		err, avail := c.werr, c.avail
+		c.mu.Unlock()
+		if err != nil {
+			return n, err
+		}
+		if avail <= 0 {
+			select {
+			case <-c.done:
+			case <-c.wdl.wait():
+			case <-c.credit:
+			}
+			continue
+		}
+		m := len(b)
+		if m > avail {
+			m = avail
+		}
+		if m > maxFrame {
+			m = maxFrame
+		}
+		p := make([]byte, 1+m)
+		p[0] = frameData
+		copy(p[1:], b[:m])
+		if err := c.send(p); err != nil {
+			return n, err
+		}
+		c.mu.Lock()
+		c.avail -= m
+		c.mu.Unlock()
+		n += m
+		b = b[m:]
+	}
+}
+
+// Close closes the connection and notifies the peer. The target itself is not closed.
+func (c *Conn) Close() error {
+	c.close.Do(func() {
+		close(c.done)
+		for _, l := range c.events {
+			c.source.Call("removeEventListener", l.event, l.cb)
+			l.cb.Release()
+		}
+		_ = c.send([]byte{frameClose})
+	})
+	return nil
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.rdl.set(t)
+	c.wdl.set(t)
+	return nil
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.rdl.set(t)
+	return nil
+}
+
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.wdl.set(t)
+	return nil
+}
@@ -0,0 +1,65 @@
+// Package msgport turns postMessage targets, like MessagePort, Worker or Window, into net.Conn.
+//
+// Data is sent as binary frames: each message is a Uint8Array with a one byte frame type followed by a payload.
+// Messages that are not frames are ignored, thus the target can still be used for other purposes.
+//
+//	| type      | payload                                  |
+//	|-----------|------------------------------------------|
+//	| 1 (hello) | reply flag (uint8), window size (uint32) |
+//	| 2 (data)  | bytes                                    |
+//	| 3 (ack)   | number of bytes read (uint32)            |
+//	| 4 (close) | none                                     |
+//
+// Both sides announce the size of their receive window with a hello frame. The writer never sends more data than
+// the peer can buffer, and blocks until the peer reads it and acknowledges, thus a fast writer cannot flood
+// a slow reader. Integers are little-endian.
+package msgport
+
+import (
+	"net"
+
+	"github.com/dennwc/dom/js"
+)
+
+// DefaultWindow is the default size of the receive window of a connection.
+const DefaultWindow = 256 * 1024
+
+// CloseFrame is the type of the close frame. Peers that are not written in Go, like the bootstrap script of a worker,
+// can close the connection by posting a Uint8Array with this single byte.
+const CloseFrame = frameClose
+
+var _ net.Addr = Addr("")
+
+// Addr is an address of the side of a connection. It can be an arbitrary string.
+type Addr string
+
+func (Addr) Network() string {
+	return "msgport"
+}
+
+func (a Addr) String() string {
+	return string(a)
+}
+
+// Options are optional parameters for New.
+type Options struct {
+	// Origin is an origin of the peer, for example "https://example.com".
+	//
+	// Messages from other origins are ignored. For Window targets, it's also used as a target origin for
+	// postMessage. If it's empty, only same-origin windows are allowed. Set it to "*" to allow any origin.
+	// For other targets, the origin is only checked if set.
+	Origin string
+	// Window is the size of the receive window in bytes. DefaultWindow is used if not set.
+	Window int
+	// Local and Remote are addresses reported by the connection.
+	Local, Remote net.Addr
+}
+
+// Pipe creates a MessageChannel and returns connections for both of its ports.
+// Unlike net.Pipe, data is buffered, and Write only waits for the Read on the other side when the window is full.
+func Pipe() (*Conn, *Conn) {
+	ch := js.New("MessageChannel")
+	a := New(ch.Get("port1"), &Options{Local: Addr("port1"), Remote: Addr("port2")})
+	b := New(ch.Get("port2"), &Options{Local: Addr("port2"), Remote: Addr("port1")})
+	return a, b
+}
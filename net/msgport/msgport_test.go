@@ -0,0 +1,96 @@
+//+build !wasm
+
+package msgport
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	_ "github.com/dennwc/dom/headless"
+	"github.com/dennwc/dom/js"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipe(t *testing.T) {
+	a, b := Pipe()
+	require.Equal(t, "port2", a.RemoteAddr().String())
+
+	_, err := a.Write([]byte("hello "))
+	require.NoError(t, err)
+	_, err = a.Write([]byte("world"))
+	require.NoError(t, err)
+
+	// messages that are not frames are ignored
+	a.target.Call("postMessage", "text")
+	a.target.Call("postMessage", js.New("Uint8Array", 0))
+
+	buf := make([]byte, 11)
+	_, err = io.ReadFull(b, buf)
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(buf))
+
+	_, err = b.Write([]byte("ok"))
+	require.NoError(t, err)
+	buf = make([]byte, 2)
+	_, err = io.ReadFull(a, buf)
+	require.NoError(t, err)
+	require.Equal(t, "ok", string(buf))
+
+	require.NoError(t, a.Close())
+	_, err = b.Read(buf)
+	require.Equal(t, io.EOF, err)
+	_, err = b.Write([]byte("x"))
+	require.Equal(t, io.ErrClosedPipe, err)
+	_, err = a.Write([]byte("closed"))
+	require.Equal(t, ErrClosed, err)
+	require.NoError(t, b.Close())
+}
+
+func TestDeadline(t *testing.T) {
+	a, b := Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	require.NoError(t, b.SetReadDeadline(time.Now().Add(20*time.Millisecond)))
+	_, err := b.Read(make([]byte, 1))
+	require.True(t, err.(net.Error).Timeout())
+
+	// data that is already buffered is not returned after the deadline
+	_, err = a.Write([]byte("x"))
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = b.Read(make([]byte, 1))
+	require.True(t, err.(net.Error).Timeout())
+
+	require.NoError(t, b.SetReadDeadline(time.Time{}))
+	n, err := b.Read(make([]byte, 1))
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
+
+func TestBackpressure(t *testing.T) {
+	ch := js.New("MessageChannel")
+	a := New(ch.Get("port1"), nil)
+	b := New(ch.Get("port2"), &Options{Window: 16})
+	defer a.Close()
+	defer b.Close()
+
+	// the writer cannot send more than the reader can buffer
+	require.NoError(t, a.SetWriteDeadline(time.Now().Add(50*time.Millisecond)))
+	n, err := a.Write(make([]byte, 40))
+	require.True(t, err.(net.Error).Timeout())
+	require.Equal(t, 16, n)
+
+	// reading unblocks the writer
+	errc := make(chan error, 1)
+	go func() {
+		require.NoError(t, a.SetWriteDeadline(time.Time{}))
+		_, err := a.Write(make([]byte, 40))
+		errc <- err
+	}()
+	_, err = io.ReadFull(b, make([]byte, 56))
+	require.NoError(t, err)
+	require.NoError(t, <-errc)
+}
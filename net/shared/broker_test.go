@@ -0,0 +1,317 @@
+//+build !wasm
+
+package shared
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/rpc"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dennwc/dom/js"
+	"github.com/stretchr/testify/require"
+)
+
+// nodeHarness runs BrokerScript in Node.js as if it was a SharedWorker.
+//
+// MessagePorts cannot leave the emulator, thus each port is replaced by {$port: id} object. Messages are sent
+// as JSON lines: {connect: id} connects a new tab, {to: id, data: msg} delivers a message to a port on the other
+// side, and {close: id} closes a port in the emulator.
+const nodeHarness = `"use strict";
+const vm = require("vm");
+const fs = require("fs");
+const readline = require("readline");
+const harnessPorts = new Map();
+function harnessSend(m) { process.stdout.write(JSON.stringify(m) + "\n"); }
+class HarnessPort {
+	constructor(id) { this.id = id; this.onmessage = null; }
+	postMessage(data) { harnessSend({to: this.id, data: harnessEncode(data)}); }
+	close() { harnessSend({close: this.id}); }
+}
+function harnessPort(id) {
+	let p = harnessPorts.get(id);
+	if (!p) { p = new HarnessPort(id); harnessPorts.set(id, p); }
+	return p;
+}
+function harnessEncode(v) {
+	if (v instanceof HarnessPort) return {$port: v.id};
+	if (v && typeof v === "object") {
+		const o = {};
+		for (const k of Object.keys(v)) o[k] = harnessEncode(v[k]);
+		return o;
+	}
+	return v;
+}
+function harnessDecode(v) {
+	if (v && typeof v === "object") {
+		if (typeof v.$port === "number") return harnessPort(v.$port);
+		const o = {};
+		for (const k of Object.keys(v)) o[k] = harnessDecode(v[k]);
+		return o;
+	}
+	return v;
+}
+global.self = global;
+vm.runInThisContext(fs.readFileSync(process.argv[2], "utf8"));
+readline.createInterface({input: process.stdin}).on("line", (line) => {
+	const m = JSON.parse(line);
+	if (m.connect !== undefined) {
+		self.onconnect({ports: [harnessPort(m.connect)]});
+	} else {
+		const p = harnessPort(m.to);
+		if (p.onmessage) p.onmessage({data: harnessDecode(m.data)});
+	}
+});
+`
+
+type nodeMessage struct {
+	Connect *int                   `json:"connect,omitempty"`
+	To      *int                   `json:"to,omitempty"`
+	Close   *int                   `json:"close,omitempty"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+// nodeBroker replaces SharedWorker in the emulator with a bridge to BrokerScript running in Node.js.
+type nodeBroker struct {
+	dir    string
+	cmd    *exec.Cmd
+	stderr bytes.Buffer
+	done   chan struct{}
+
+	wmu     sync.Mutex
+	in      io.WriteCloser
+	stopped bool // messages sent after Stop are dropped
+
+	mu    sync.Mutex
+	last  int
+	ports map[int]js.Value
+}
+
+func startNodeBroker(t *testing.T) *nodeBroker {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skipf("cannot find Node.js: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "shared_broker_")
+	require.NoError(t, err)
+	harness, script := filepath.Join(dir, "harness.js"), filepath.Join(dir, "broker.js")
+	require.NoError(t, ioutil.WriteFile(harness, []byte(nodeHarness), 0644))
+	require.NoError(t, ioutil.WriteFile(script, []byte(BrokerScript), 0644))
+
+	b := &nodeBroker{
+		dir:   dir,
+		cmd:   exec.Command(node, harness, script),
+		done:  make(chan struct{}),
+		ports: make(map[int]js.Value),
+	}
+	b.cmd.Stderr = &b.stderr
+	b.in, err = b.cmd.StdinPipe()
+	require.NoError(t, err)
+	out, err := b.cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, b.cmd.Start())
+	go b.readLoop(t, out)
+
+	setSharedWorker(func() js.Value {
+		ch := js.New("MessageChannel")
+		port := ch.Get("port2")
+		id := b.add(port)
+		port.Call("addEventListener", "message", js.NewEventCallback(func(ev js.Value) {
+			if err := b.send(nodeMessage{To: &id, Data: b.encode(ev.Get("data"))}); err != nil {
+				t.Error(err)
+			}
+		}))
+		port.Call("start")
+		if err := b.send(nodeMessage{Connect: &id}); err != nil {
+			t.Error(err)
+		}
+		return ch.Get("port1")
+	})
+	return b
+}
+
+func (b *nodeBroker) Stop(t *testing.T) {
+	defer os.RemoveAll(b.dir)
+	b.wmu.Lock()
+	b.stopped = true
+	b.in.Close()
+	b.wmu.Unlock()
+	select {
+	case <-b.done:
+	case <-time.After(5 * time.Second):
+		b.cmd.Process.Kill()
+		<-b.done
+	}
+	if err := b.cmd.Wait(); err != nil {
+		t.Errorf("broker failed: %v\n%s", err, b.stderr.String())
+	}
+}
+
+func (b *nodeBroker) send(m nodeMessage) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	b.wmu.Lock()
+	defer b.wmu.Unlock()
+	if b.stopped {
+		return nil
+	}
+	_, err = b.in.Write(append(data, '\n'))
+	return err
+}
+
+func (b *nodeBroker) add(port js.Value) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.last++
+	b.ports[b.last] = port
+	return b.last
+}
+
+func (b *nodeBroker) port(id int) js.Value {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.ports[id]
+}
+
+// encode converts a message sent to the broker to JSON, replacing ports with their IDs.
+func (b *nodeBroker) encode(d js.Value) map[string]interface{} {
+	out := make(map[string]interface{})
+	for _, k := range d.Keys() {
+		v := d.Get(k)
+		if v.InstanceOfClass("MessagePort") {
+			out[k] = map[string]int{"$port": b.add(v)}
+			continue
+		}
+		var x interface{}
+		if err := js.Unmarshal(v, &x); err != nil {
+			panic(err)
+		}
+		out[k] = x
+	}
+	return out
+}
+
+// decode converts a message sent by the broker back, replacing port IDs with ports and adding them to the transfer list.
+func (b *nodeBroker) decode(d map[string]interface{}) (js.Obj, *js.Transfer) {
+	obj, tr := js.Obj{}, js.NewTransfer()
+	for k, v := range d {
+		if m, ok := v.(map[string]interface{}); ok {
+			if id, ok := m["$port"].(float64); ok {
+				p := b.port(int(id))
+				obj[k] = p
+				tr.Add(p)
+				continue
+			}
+		}
+		obj[k] = v
+	}
+	return obj, tr
+}
+
+func (b *nodeBroker) readLoop(t *testing.T, r io.Reader) {
+	defer close(b.done)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		var m nodeMessage
+		if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+			t.Error(err)
+			return
+		}
+		switch {
+		case m.Close != nil:
+			b.port(*m.Close).Call("close")
+		case m.To != nil:
+			obj, tr := b.decode(m.Data)
+			if err := js.PostMessage(b.port(*m.To), obj, tr); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+}
+
+// useBroker makes Listen and Dial use a given broker connection. Each connection acts as a separate tab.
+func useBroker(b *broker) {
+	brokerOnce.Do(func() {})
+	defBroker, brokerErr = b, nil
+}
+
+func serveRPC(t *testing.T, lis net.Listener) {
+	rs := rpc.NewServer()
+	require.NoError(t, rs.RegisterName("S", service{}))
+	go func() {
+		for {
+			c, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go rs.ServeConn(c)
+		}
+	}()
+}
+
+func callRPC(t *testing.T, addr string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := DialContext(ctx, addr)
+	require.NoError(t, err)
+	cli := rpc.NewClient(c)
+	defer cli.Close()
+	var out string
+	require.NoError(t, cli.Call("S.Hello", "Bob", &out))
+	require.Equal(t, "Hello Bob", out)
+}
+
+func TestBrokerScript(t *testing.T) {
+	nb := startNodeBroker(t)
+	defer nb.Stop(t)
+	defer func() {
+		brokerOnce = sync.Once{}
+		defBroker, brokerErr = nil, nil
+	}()
+
+	tab1, err := newBroker()
+	require.NoError(t, err)
+	tab2, err := newBroker()
+	require.NoError(t, err)
+
+	useBroker(tab2)
+	_, err = Dial("rpc")
+	require.Equal(t, ErrRefused, err)
+
+	useBroker(tab1)
+	lis1, err := Listen("rpc")
+	require.NoError(t, err)
+	defer lis1.Close()
+	serveRPC(t, lis1)
+
+	// the broker pings the first tab, and it responds
+	useBroker(tab2)
+	_, err = Listen("rpc")
+	require.Equal(t, ErrAddrInUse, err)
+
+	// connections are routed to another tab
+	callRPC(t, "rpc")
+
+	// the first tab stops responding, as if it was closed without closing the listener
+	tab1.port.Call("removeEventListener", "message", tab1.cb)
+	lis2, err := Listen("rpc")
+	require.NoError(t, err)
+	serveRPC(t, lis2)
+	callRPC(t, "rpc")
+
+	require.NoError(t, lis2.Close())
+	_, err = Dial("rpc")
+	require.Equal(t, ErrRefused, err)
+}
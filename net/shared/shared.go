@@ -0,0 +1,326 @@
+// Package shared provides a functionality similar to Go net package for connections between browser tabs
+// of the same origin, without a server.
+//
+// One tab listens on an address, while others dial it, the same way as with the ws package:
+//
+//	// in the first tab
+//	lis, err := shared.Listen("rpc")
+//	conn, err := lis.Accept()
+//
+//	// in the second tab
+//	conn, err := shared.Dial("rpc")
+//
+// Tabs find each other through a SharedWorker that runs a small broker script. Once the connection is
+// established, data is sent directly between tabs through a MessageChannel.
+package shared
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/dennwc/dom/js"
+	"github.com/dennwc/dom/net/msgport"
+)
+
+var (
+	// ErrAddrInUse is returned by Listen if another tab is already listening on the address.
+	ErrAddrInUse = errors.New("shared: address already in use")
+	// ErrRefused is returned by Dial if no tab is listening on the address.
+	ErrRefused = errors.New("shared: connection refused")
+	// ErrClosed is returned by Accept after the listener was closed.
+	ErrClosed = errors.New("shared: listener closed")
+)
+
+// BrokerURL is an URL of the broker script that runs in the SharedWorker. If it's empty, BrokerScript is
+// loaded from a data URL.
+//
+// Content Security Policy of the page might forbid workers from data URLs. In this case serve BrokerScript
+// from the same origin and set BrokerURL before the first call to Listen or Dial.
+var BrokerURL = ""
+
+// BrokerName is a name of the SharedWorker that runs the broker.
+const BrokerName = "github.com/dennwc/dom/net/shared"
+
+// BrokerScript is a source of the broker script that runs in the SharedWorker.
+//
+// The broker keeps a list of listening tabs. On dial, it checks that the listening tab is still alive and
+// passes it one of the ports of a MessageChannel created by the dialing tab.
+const BrokerScript = `"use strict";
+const listeners = new Map();
+const pings = new Map();
+let lastID = 0;
+function ping(port, addr) {
+	return new Promise((resolve) => {
+		const id = ++lastID;
+		const timer = setTimeout(() => { pings.delete(id); resolve(false); }, 1000);
+		pings.set(id, () => { clearTimeout(timer); resolve(true); });
+		port.postMessage({op: "ping", id: id, addr: addr});
+	});
+}
+self.onconnect = (e) => {
+	const port = e.ports[0];
+	port.onmessage = async (m) => {
+		const d = m.data;
+		const reply = (err) => { port.postMessage({op: "reply", id: d.id, error: err || ""}); };
+		switch (d.op) {
+		case "pong": {
+			const f = pings.get(d.id);
+			if (f) { pings.delete(d.id); f(); }
+			return;
+		}
+		case "listen": {
+			const cur = listeners.get(d.addr);
+			if (cur && await ping(cur, d.addr) && listeners.get(d.addr) === cur) { reply("in_use"); return; }
+			listeners.set(d.addr, port);
+			reply();
+			return;
+		}
+		case "close": {
+			if (listeners.get(d.addr) === port) listeners.delete(d.addr);
+			return;
+		}
+		case "dial": {
+			const l = listeners.get(d.addr);
+			if (!l || !(await ping(l, d.addr))) {
+				if (l && listeners.get(d.addr) === l) listeners.delete(d.addr);
+				d.port.close();
+				reply("refused");
+				return;
+			}
+			l.postMessage({op: "accept", addr: d.addr, port: d.port}, [d.port]);
+			reply();
+			return;
+		}
+		}
+	};
+};
+`
+
+var _ net.Addr = Addr("")
+
+// Addr is an address of a listener. It's an arbitrary string shared by tabs.
+type Addr string
+
+func (Addr) Network() string {
+	return "shared"
+}
+
+func (a Addr) String() string {
+	return string(a)
+}
+
+var (
+	brokerOnce sync.Once
+	brokerErr  error
+	defBroker  *broker
+)
+
+// getBroker connects to the broker worker. All listeners and connections of the page share the same broker.
+func getBroker() (*broker, error) {
+	brokerOnce.Do(func() {
+		defBroker, brokerErr = newBroker()
+	})
+	return defBroker, brokerErr
+}
+
+type broker struct {
+	port js.Value
+	cb   js.Func
+
+	mu        sync.Mutex
+	lastID    int
+	calls     map[int]chan string
+	listeners map[string]*listener
+}
+
+func newBroker() (*broker, error) {
+	if js.Get("SharedWorker").Type() != js.TypeFunction {
+		return nil, js.ErrNotSupported
+	}
+	u := BrokerURL
+	if u == "" {
+		u = "data:text/javascript;base64," + base64.StdEncoding.EncodeToString([]byte(BrokerScript))
+	}
+	w, err := js.Class("SharedWorker").TryNew(u, BrokerName)
+	if err != nil {
+		return nil, err
+	}
+	b := &broker{
+		port:      w.Get("port"),
+		calls:     make(map[int]chan string),
+		listeners: make(map[string]*listener),
+	}
+	b.cb = js.NewEventCallback(b.onMessage)
+	b.port.Call("addEventListener", "message", b.cb)
+	b.port.Call("start")
+	return b, nil
+}
+
+func (b *broker) onMessage(ev js.Value) {
+	d := ev.Get("data")
+	switch d.Get("op").String() {
+	case "reply":
+		id := d.Get("id").Int()
+		b.mu.Lock()
+		ch := b.calls[id]
+		delete(b.calls, id)
+		b.mu.Unlock()
+		if ch != nil {
+			ch <- d.Get("error").String()
+		}
+	case "ping":
+		b.mu.Lock()
+		_, ok := b.listeners[d.Get("addr").String()]
+		b.mu.Unlock()
+		if ok {
+			b.port.Call("postMessage", js.Obj{"op": "pong", "id": d.Get("id")})
+		}
+	case "accept":
+		b.mu.Lock()
+		l := b.listeners[d.Get("addr").String()]
+		b.mu.Unlock()
+		port := d.Get("port")
+		if l == nil {
+			port.Call("close")
+			return
+		}
+		l.accept(port)
+	}
+}
+
+// call sends a request to the broker and waits for the reply.
+func (b *broker) call(ctx context.Context, req js.Obj, t *js.Transfer) (string, error) {
+	ch := make(chan string, 1)
+	b.mu.Lock()
+	b.lastID++
+	id := b.lastID
+	b.calls[id] = ch
+	b.mu.Unlock()
+	req["id"] = id
+	if err := js.PostMessage(b.port, req, t); err != nil {
+		b.mu.Lock()
+		delete(b.calls, id)
+		b.mu.Unlock()
+		return "", err
+	}
+	select {
+	case e := <-ch:
+		return e, nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		delete(b.calls, id)
+		b.mu.Unlock()
+		return "", ctx.Err()
+	}
+}
+
+// Listen starts listening on a given address. Only one tab can listen on the address at a time.
+func Listen(addr string) (net.Listener, error) {
+	b, err := getBroker()
+	if err != nil {
+		return nil, err
+	}
+	l := &listener{
+		b:     b,
+		addr:  addr,
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+	b.mu.Lock()
+	if _, ok := b.listeners[addr]; ok {
+		b.mu.Unlock()
+		return nil, ErrAddrInUse
+	}
+	// register the listener first, so it responds to pings from the broker
+	b.listeners[addr] = l
+	b.mu.Unlock()
+
+	e, err := b.call(context.Background(), js.Obj{"op": "listen", "addr": addr}, nil)
+	if err == nil && e == "in_use" {
+		err = ErrAddrInUse
+	}
+	if err != nil {
+		b.mu.Lock()
+		delete(b.listeners, addr)
+		b.mu.Unlock()
+		return nil, err
+	}
+	return l, nil
+}
+
+type listener struct {
+	b     *broker
+	addr  string
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func (l *listener) accept(port js.Value) {
+	c := msgport.New(port, &msgport.Options{Local: Addr(l.addr)})
+	go func() {
+		select {
+		case l.conns <- c:
+		case <-l.done:
+			c.Close()
+			port.Call("close")
+		}
+	}()
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	select {
+	case <-l.done:
+		return nil, ErrClosed
+	case c := <-l.conns:
+		return c, nil
+	}
+}
+
+func (l *listener) Close() error {
+	l.once.Do(func() {
+		close(l.done)
+		l.b.mu.Lock()
+		if l.b.listeners[l.addr] == l {
+			delete(l.b.listeners, l.addr)
+		}
+		l.b.mu.Unlock()
+		_ = js.PostMessage(l.b.port, js.Obj{"op": "close", "addr": l.addr}, nil)
+	})
+	return nil
+}
+
+func (l *listener) Addr() net.Addr {
+	return Addr(l.addr)
+}
+
+// Dial connects to a tab listening on a given address.
+func Dial(addr string) (net.Conn, error) {
+	return DialContext(context.Background(), addr)
+}
+
+// DialContext connects to a tab listening on a given address.
+func DialContext(ctx context.Context, addr string) (net.Conn, error) {
+	b, err := getBroker()
+	if err != nil {
+		return nil, err
+	}
+	ch := js.New("MessageChannel")
+	port, remote := ch.Get("port1"), ch.Get("port2")
+	// start receiving before the other side can send anything
+	c := msgport.New(port, &msgport.Options{Remote: Addr(addr)})
+
+	e, err := b.call(ctx, js.Obj{"op": "dial", "addr": addr, "port": remote}, js.NewTransfer(remote))
+	if err == nil && e == "refused" {
+		err = ErrRefused
+	}
+	if err != nil {
+		c.Close()
+		port.Call("close")
+		return nil, err
+	}
+	return c, nil
+}
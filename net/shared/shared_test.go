@@ -0,0 +1,127 @@
+//+build !wasm
+
+package shared
+
+import (
+	"net/rpc"
+	"sync"
+	"testing"
+
+	_ "github.com/dennwc/dom/headless"
+	"github.com/dennwc/dom/js"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBroker implements the broker protocol in Go. See TestBrokerScript for tests of the real broker.
+// Listeners are always considered alive, thus pings are not sent.
+func fakeBroker() {
+	listeners := make(map[string]js.Value)
+	setSharedWorker(func() js.Value {
+		ch := js.New("MessageChannel")
+		port := ch.Get("port2")
+		cb := js.NewEventCallback(func(ev js.Value) {
+			d := ev.Get("data")
+			addr := d.Get("addr").String()
+			reply := func(e string) {
+				port.Call("postMessage", js.Obj{"op": "reply", "id": d.Get("id"), "error": e})
+			}
+			switch d.Get("op").String() {
+			case "listen":
+				if _, ok := listeners[addr]; ok {
+					reply("in_use")
+					return
+				}
+				listeners[addr] = port
+				reply("")
+			case "close":
+				delete(listeners, addr)
+			case "dial":
+				l, ok := listeners[addr]
+				if !ok {
+					reply("refused")
+					return
+				}
+				p := d.Get("port")
+				l.Call("postMessage", js.Obj{"op": "accept", "addr": addr, "port": p}, []interface{}{p})
+				reply("")
+			}
+		})
+		port.Call("addEventListener", "message", cb)
+		port.Call("start")
+		return ch.Get("port1")
+	})
+}
+
+var (
+	workerOnce sync.Once
+	workerMu   sync.Mutex
+	newWorker  func() js.Value
+)
+
+// setSharedWorker replaces SharedWorker constructor with a function that returns a port of a fake worker.
+//
+// The constructor is cached by js.Class, thus the global is set once, and only the Go function is replaced.
+func setSharedWorker(fnc func() js.Value) {
+	workerMu.Lock()
+	newWorker = fnc
+	workerMu.Unlock()
+	workerOnce.Do(func() {
+		js.Set("SharedWorker", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			workerMu.Lock()
+			fnc := newWorker
+			workerMu.Unlock()
+			return js.Obj{"port": fnc()}
+		}))
+	})
+}
+
+type service struct{}
+
+func (service) Hello(name string, out *string) error {
+	*out = "Hello " + name
+	return nil
+}
+
+func TestShared(t *testing.T) {
+	fakeBroker()
+
+	_, err := Dial("rpc")
+	require.Equal(t, ErrRefused, err)
+
+	lis, err := Listen("rpc")
+	require.NoError(t, err)
+	require.Equal(t, "rpc", lis.Addr().String())
+
+	_, err = Listen("rpc")
+	require.Equal(t, ErrAddrInUse, err)
+
+	rs := rpc.NewServer()
+	require.NoError(t, rs.RegisterName("S", service{}))
+	errc := make(chan error, 1)
+	go func() {
+		for {
+			c, err := lis.Accept()
+			if err != nil {
+				errc <- err
+				return
+			}
+			go rs.ServeConn(c)
+		}
+	}()
+
+	c, err := Dial("rpc")
+	require.NoError(t, err)
+	require.Equal(t, "rpc", c.RemoteAddr().String())
+	cli := rpc.NewClient(c)
+
+	var out string
+	require.NoError(t, cli.Call("S.Hello", "Alice", &out))
+	require.Equal(t, "Hello Alice", out)
+	require.NoError(t, cli.Close())
+
+	require.NoError(t, lis.Close())
+	require.Equal(t, ErrClosed, <-errc)
+
+	_, err = Dial("rpc")
+	require.Equal(t, ErrRefused, err)
+}
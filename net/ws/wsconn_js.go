@@ -23,22 +23,16 @@ func Dial(addr string) (net.Conn, error) {
 }
 
 // DialContext connects to a WebSocket on a specified URL.
-func DialContext(ctx context.Context, addr string) (_ net.Conn, gerr error) {
+func DialContext(ctx context.Context, addr string) (net.Conn, error) {
 	c := &jsConn{
 		events: make(chan event, 2),
 		done:   make(chan struct{}),
 		read:   make(chan struct{}),
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			if e, ok := r.(error); ok {
-				gerr = e
-			} else {
-				gerr = fmt.Errorf("%v", r)
-			}
-		}
-	}()
-	p := c.openSocket(addr)
+	p, err := c.openSocket(addr)
+	if err != nil {
+		return nil, err
+	}
 
 	out, err := p.AwaitContext(ctx)
 	if err != nil {
@@ -93,7 +87,7 @@ const (
 	eventData   = eventType(3)
 )
 
-func (c *jsConn) openSocket(addr string) *js.Promise {
+func (c *jsConn) openSocket(addr string) (*js.Promise, error) {
 	c.cb = js.CallbackOf(func(v []js.Value) {
 		ev := event{
 			Type: eventType(v[0].Int()),
@@ -137,7 +131,12 @@ return new Promise(function(resolve, reject){
 	}
 })
 `)
-	return setup.Invoke(addr, c.cb, c.state).Promised()
+	v, err := setup.TryInvoke(addr, c.cb, c.state)
+	if err != nil {
+		c.cb.Release()
+		return nil, err
+	}
+	return v.Promised(), nil
 }
 
 func (c *jsConn) Close() error {
@@ -182,12 +181,8 @@ func (c *jsConn) loop() {
 			case eventData:
 				arr := ev.Data
 
-				sz := arr.Get("length").Int()
-
-				data := make([]byte, sz)
-				m := js.MMap(data)
-				err := m.CopyFrom(arr)
-				m.Release()
+				data := make([]byte, arr.Length())
+				_, err := js.CopyBytesToGo(data, arr)
 
 				c.mu.Lock()
 				if err == nil {
@@ -205,16 +200,11 @@ func (c *jsConn) loop() {
 	}
 }
 
-func cloneToJS(data []byte) js.Value {
-	arr := js.TypedArrayOf(data)
-	v := js.New("Uint8Array", arr)
-	arr.Release()
-	return v
-}
-
 func (c *jsConn) send(data []byte) {
-	jarr := cloneToJS(data)
-	c.ws.Call("send", jarr)
+	// WebSocket.send copies the data, so the buffer can be reused
+	buf := js.BufferOf(data)
+	c.ws.Call("send", buf)
+	buf.Release()
 }
 
 func (c *jsConn) Read(b []byte) (int, error) {
@@ -9,7 +9,8 @@ type Node interface {
 
 	BaseURI() string
 	NodeName() string
-	ChildNodes() NodeList
+	NodeType() NodeType
+	ChildNodes() []Node
 	ParentNode() Node
 	ParentElement() *Element
 	TextContent() string
@@ -41,11 +42,49 @@ const (
 
 var _ js.Wrapper = NodeBase{}
 
+// NodeList is a list of elements, as returned by element queries. Use AsNodes to convert a list of arbitrary nodes.
 type NodeList []*Element
 
+// AsNode converts a JS node to a Go type that corresponds to its node type: *Element, *Text, *Comment,
+// *Document, *DocumentType, *DocumentFragment or *ShadowRoot. It returns nil for null and undefined values.
+func AsNode(v js.Value) Node {
+	if !v.Valid() {
+		return nil
+	}
+	switch NodeType(v.Get("nodeType").Int()) {
+	case ElementNode:
+		return AsElement(v)
+	case TextNode, CDataSectionNode:
+		return AsText(v)
+	case CommentNode:
+		return AsComment(v)
+	case DocumentNode:
+		return &Document{NodeBase{newEventTarget(v)}}
+	case DocumentTypeNode:
+		return AsDocumentType(v)
+	case DocumentFragmentNode:
+		if v.Get("host").Valid() {
+			return AsShadowRoot(v)
+		}
+		return AsDocumentFragment(v)
+	}
+	return &NodeBase{newEventTarget(v)}
+}
+
+// AsNodes converts a JS list of nodes, like NodeList returned by childNodes, to a slice of nodes. See AsNode.
+func AsNodes(v js.Value) []Node {
+	if !v.Valid() {
+		return nil
+	}
+	arr := make([]Node, v.Length())
+	for i := range arr {
+		arr[i] = AsNode(v.Index(i))
+	}
+	return arr
+}
+
 type NodeBase struct {
-	v     js.Value
-	funcs []js.Func
+	eventTarget
 }
 
 // JSValue implements js.Wrapper.
@@ -53,20 +92,14 @@ func (e NodeBase) JSValue() js.Ref {
 	return e.v.JSValue()
 }
 
+// Remove removes the node from its parent, and releases event listeners added with AddEventListener.
+// It does nothing if the node has no parent.
 func (e *NodeBase) Remove() {
-	e.ParentNode().RemoveChild(e)
-	for _, c := range e.funcs {
-		c.Release()
+	if !e.v.Get("parentNode").Valid() {
+		return
 	}
-	e.funcs = nil
-}
-
-func (e *NodeBase) AddEventListener(typ string, h EventHandler) {
-	cb := js.NewEventCallback(func(v js.Value) {
-		h(convertEvent(v))
-	})
-	e.funcs = append(e.funcs, cb)
-	e.v.Call("addEventListener", typ, cb)
+	e.v.Call("remove")
+	e.ReleaseListeners()
 }
 
 func (e *NodeBase) AddErrorListener(h func(err error)) {
@@ -88,12 +121,13 @@ func (e *NodeBase) NodeType() NodeType {
 	return NodeType(e.v.Get("nodeType").Int())
 }
 
-func (e *NodeBase) ChildNodes() NodeList {
-	return AsNodeList(e.v.Get("childNodes"))
+// ChildNodes returns all child nodes, including text and comments.
+func (e *NodeBase) ChildNodes() []Node {
+	return AsNodes(e.v.Get("childNodes"))
 }
 
 func (e *NodeBase) ParentNode() Node {
-	return AsElement(e.v.Get("parentNode"))
+	return AsNode(e.v.Get("parentNode"))
 }
 
 func (e *NodeBase) ParentElement() *Element {
@@ -125,9 +159,108 @@ func (e *NodeBase) IsSameNode(n Node) bool {
 }
 
 func (e *NodeBase) RemoveChild(n Node) Node {
-	return AsElement(e.v.Call("removeChild", n))
+	return AsNode(e.v.Call("removeChild", n))
 }
 
 func (e *NodeBase) ReplaceChild(n, old Node) Node {
-	return AsElement(e.v.Call("replaceChild", n, old))
+	return AsNode(e.v.Call("replaceChild", n, old))
+}
+
+// nodeArgs converts nodes to call arguments. Nil nodes are skipped, since DOM methods like append would insert
+// null as a "null" text node.
+func nodeArgs(nodes []Node) []interface{} {
+	args := make([]interface{}, 0, len(nodes))
+	for _, n := range nodes {
+		if n != nil {
+			args = append(args, n)
+		}
+	}
+	return args
+}
+
+// nodeArg converts a node to a call argument. Nil node is passed as null.
+func nodeArg(n Node) interface{} {
+	if n == nil {
+		return nil
+	}
+	return n
+}
+
+// HasChildNodes checks if the node has any child nodes.
+func (e *NodeBase) HasChildNodes() bool {
+	return e.v.Call("hasChildNodes").Bool()
+}
+
+// FirstChild returns the first child node, or nil if the node has no children.
+func (e *NodeBase) FirstChild() Node {
+	return AsNode(e.v.Get("firstChild"))
+}
+
+// LastChild returns the last child node, or nil if the node has no children.
+func (e *NodeBase) LastChild() Node {
+	return AsNode(e.v.Get("lastChild"))
+}
+
+// NextSibling returns the next node with the same parent, or nil if the node is the last one.
+func (e *NodeBase) NextSibling() Node {
+	return AsNode(e.v.Get("nextSibling"))
+}
+
+// PreviousSibling returns the previous node with the same parent, or nil if the node is the first one.
+func (e *NodeBase) PreviousSibling() Node {
+	return AsNode(e.v.Get("previousSibling"))
+}
+
+// InsertBefore inserts a node before the ref child node. If ref is nil, the node is inserted at the end.
+// If the node is already in the document, it's moved to the new position. It returns the inserted node.
+func (e *NodeBase) InsertBefore(n, ref Node) Node {
+	return AsNode(e.v.Call("insertBefore", n, nodeArg(ref)))
+}
+
+// CloneNode returns a copy of the node. If deep is set, all descendants are copied as well.
+// Event listeners are not copied.
+func (e *NodeBase) CloneNode(deep bool) Node {
+	return AsNode(e.v.Call("cloneNode", deep))
+}
+
+// Normalize merges adjacent text nodes and removes empty ones in the subtree of the node.
+func (e *NodeBase) Normalize() {
+	e.v.Call("normalize")
+}
+
+// Append inserts nodes after the last child of the node.
+// Nil nodes are ignored by this and other methods that accept a list of nodes.
+// It's only supported by elements, documents and document fragments.
+func (e *NodeBase) Append(nodes ...Node) {
+	e.v.Call("append", nodeArgs(nodes)...)
+}
+
+// Prepend inserts nodes before the first child of the node.
+// It's only supported by elements, documents and document fragments.
+func (e *NodeBase) Prepend(nodes ...Node) {
+	e.v.Call("prepend", nodeArgs(nodes)...)
+}
+
+// ReplaceChildren replaces all children of the node with given nodes. If no nodes are given, the node is cleared.
+// It's only supported by elements, documents and document fragments.
+func (e *NodeBase) ReplaceChildren(nodes ...Node) {
+	e.v.Call("replaceChildren", nodeArgs(nodes)...)
+}
+
+// Before inserts nodes before this node, in its parent.
+// It's only supported by elements, text, comments and document types.
+func (e *NodeBase) Before(nodes ...Node) {
+	e.v.Call("before", nodeArgs(nodes)...)
+}
+
+// After inserts nodes after this node, in its parent.
+// It's only supported by elements, text, comments and document types.
+func (e *NodeBase) After(nodes ...Node) {
+	e.v.Call("after", nodeArgs(nodes)...)
+}
+
+// ReplaceWith replaces this node in its parent with given nodes.
+// It's only supported by elements, text, comments and document types.
+func (e *NodeBase) ReplaceWith(nodes ...Node) {
+	e.v.Call("replaceWith", nodeArgs(nodes)...)
 }
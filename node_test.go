@@ -0,0 +1,73 @@
+//+build !wasm
+
+package dom
+
+import (
+	"testing"
+
+	"github.com/dennwc/dom/headless"
+	"github.com/stretchr/testify/require"
+)
+
+func setHTML(t *testing.T, s string) *Document {
+	headless.Reset()
+	require.NoError(t, headless.SetHTML(s))
+	return GetDocument()
+}
+
+func TestAsNode(t *testing.T) {
+	d := setHTML(t, `<!DOCTYPE html><html><body><div id="main">text<!--note--><p>x</p></div></body></html>`)
+	main := d.GetElementById("main")
+
+	nodes := main.ChildNodes()
+	require.Len(t, nodes, 3)
+	txt, ok := nodes[0].(*Text)
+	require.True(t, ok, "%T", nodes[0])
+	require.Equal(t, "text", txt.Data())
+	cm, ok := nodes[1].(*Comment)
+	require.True(t, ok, "%T", nodes[1])
+	require.Equal(t, "note", cm.Data())
+	p, ok := nodes[2].(*Element)
+	require.True(t, ok, "%T", nodes[2])
+	require.Equal(t, "P", p.TagName())
+
+	_, ok = p.ParentNode().(*Element)
+	require.True(t, ok)
+	_, ok = AsNode(d.v).(*Document)
+	require.True(t, ok)
+	_, ok = AsNode(d.CreateDocumentFragment().v).(*DocumentFragment)
+	require.True(t, ok)
+	require.Equal(t, "html", d.Doctype().Name())
+	require.Nil(t, AsNode(d.v.Get("parentNode")))
+	require.Nil(t, p.FirstChild().(*Text).NextSibling())
+}
+
+func TestNodeMutation(t *testing.T) {
+	d := setHTML(t, `<ul id="list"><li id="b">b</li></ul>`)
+	list := d.GetElementById("list")
+	b := d.GetElementById("b")
+	item := func(id string) *Element {
+		li := d.CreateElement("li")
+		li.SetId(id)
+		li.SetTextContent(id)
+		return li
+	}
+
+	list.Append(item("d"), nil, d.CreateTextNode("e"))
+	require.Equal(t, "bde", list.TextContent())
+
+	// nil reference inserts at the end
+	list.InsertBefore(item("f"), nil)
+	list.InsertBefore(item("a"), b)
+	require.Equal(t, "abdef", list.TextContent())
+
+	b.After(item("c"))
+	b.ReplaceWith(item("x"), nil, item("y"))
+	require.Equal(t, "axycdef", list.TextContent())
+	require.Nil(t, b.ParentNode())
+
+	list.Prepend(nil)
+	require.Equal(t, "axycdef", list.TextContent())
+	list.ReplaceChildren()
+	require.False(t, list.HasChildNodes())
+}
@@ -8,13 +8,14 @@ func AsShadowRoot(v js.Value) *ShadowRoot {
 	if !v.Valid() {
 		return nil
 	}
-	return &ShadowRoot{NodeBase{v: v}}
+	return &ShadowRoot{DocumentFragment{NodeBase{newEventTarget(v)}}}
 }
 
 var _ Node = (*ShadowRoot)(nil)
 
+// ShadowRoot is a root of a shadow DOM tree.
 type ShadowRoot struct {
-	NodeBase
+	DocumentFragment
 }
 
 func (r *ShadowRoot) IsOpen() bool {
@@ -0,0 +1,6 @@
+//+build !wasm
+
+package storage
+
+// On non-wasm builds the package uses an in-memory storage provided by the headless DOM.
+import _ "github.com/dennwc/dom/headless"
@@ -71,23 +71,23 @@ func (e *Element) Translate(x, y float64) {
 }
 
 // OnClick registers an onclick event listener.
-func (e *Element) OnClick(h dom.MouseEventHandler) {
-	e.e.OnClick(h)
+func (e *Element) OnClick(h dom.MouseEventHandler) func() {
+	return e.e.OnClick(h)
 }
 
 // OnMouseDown registers an onmousedown event listener.
-func (e *Element) OnMouseDown(h dom.MouseEventHandler) {
-	e.e.OnMouseDown(h)
+func (e *Element) OnMouseDown(h dom.MouseEventHandler) func() {
+	return e.e.OnMouseDown(h)
 }
 
 // OnMouseMove registers an onmousemove event listener.
-func (e *Element) OnMouseMove(h dom.MouseEventHandler) {
-	e.e.OnMouseMove(h)
+func (e *Element) OnMouseMove(h dom.MouseEventHandler) func() {
+	return e.e.OnMouseMove(h)
 }
 
 // OnMouseUp registers an onmouseup event listener.
-func (e *Element) OnMouseUp(h dom.MouseEventHandler) {
-	e.e.OnMouseUp(h)
+func (e *Element) OnMouseUp(h dom.MouseEventHandler) func() {
+	return e.e.OnMouseUp(h)
 }
 
 // NewG creates a detached SVG group element ("g").
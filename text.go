@@ -0,0 +1,93 @@
+package dom
+
+import "github.com/dennwc/dom/js"
+
+// https://developer.mozilla.org/en-US/docs/Web/API/CharacterData
+
+// CharacterData is a common base of nodes that contain text, like Text and Comment.
+type CharacterData struct {
+	NodeBase
+}
+
+// Data returns the text of the node.
+func (c *CharacterData) Data() string {
+	return c.v.Get("data").String()
+}
+
+// SetData replaces the text of the node.
+func (c *CharacterData) SetData(s string) {
+	c.v.Set("data", s)
+}
+
+// Length returns the length of the text in UTF-16 code units.
+func (c *CharacterData) Length() int {
+	return c.v.Get("length").Int()
+}
+
+// AppendData appends a string to the text of the node.
+func (c *CharacterData) AppendData(s string) {
+	c.v.Call("appendData", s)
+}
+
+// InsertData inserts a string at a given offset. Offsets are in UTF-16 code units.
+func (c *CharacterData) InsertData(off int, s string) {
+	c.v.Call("insertData", off, s)
+}
+
+// DeleteData removes n code units starting from a given offset.
+func (c *CharacterData) DeleteData(off, n int) {
+	c.v.Call("deleteData", off, n)
+}
+
+// ReplaceData replaces n code units starting from a given offset with a string.
+func (c *CharacterData) ReplaceData(off, n int, s string) {
+	c.v.Call("replaceData", off, n, s)
+}
+
+// SubstringData returns n code units starting from a given offset.
+func (c *CharacterData) SubstringData(off, n int) string {
+	return c.v.Call("substringData", off, n).String()
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/API/Text
+
+func AsText(v js.Value) *Text {
+	if !v.Valid() {
+		return nil
+	}
+	return &Text{CharacterData{NodeBase{newEventTarget(v)}}}
+}
+
+var _ Node = (*Text)(nil)
+
+// Text is a text node.
+type Text struct {
+	CharacterData
+}
+
+// WholeText returns the text of this node and all adjacent text nodes.
+func (t *Text) WholeText() string {
+	return t.v.Get("wholeText").String()
+}
+
+// SplitText splits the node in two at a given offset. The node keeps the text before the offset,
+// and the new node that contains the rest of the text is inserted after it and returned.
+func (t *Text) SplitText(off int) *Text {
+	return AsText(t.v.Call("splitText", off))
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/API/Comment
+
+func AsComment(v js.Value) *Comment {
+	if !v.Valid() {
+		return nil
+	}
+	return &Comment{CharacterData{NodeBase{newEventTarget(v)}}}
+}
+
+var _ Node = (*Comment)(nil)
+
+// Comment is a comment node.
+type Comment struct {
+	CharacterData
+}
@@ -11,25 +11,22 @@ func GetWindow() *Window {
 	if !win.Valid() {
 		return nil
 	}
-	return &Window{v: win}
+	w := &Window{eventTarget: newEventTarget(win)}
+	w.eventHandlers.t = &w.eventTarget
+	return w
 }
 
 var _ EventTarget = (*Window)(nil)
 
 type Window struct {
-	v js.Value
+	eventTarget
+	eventHandlers
 }
 
 func (w *Window) JSValue() js.Ref {
 	return w.v.JSValue()
 }
 
-func (w *Window) AddEventListener(typ string, h EventHandler) {
-	w.v.Call("addEventListener", typ, js.NewEventCallback(func(v js.Value) {
-		h(convertEvent(v))
-	}))
-}
-
 func (w *Window) Open(url, windowName string, windowFeatures map[string]string) {
 	w.v.Call("open", url, windowName, joinKeyValuePairs(windowFeatures, ","))
 }
@@ -38,8 +35,8 @@ func (w *Window) SetLocation(url string) {
 	w.v.Set("location", url)
 }
 
-func (w *Window) OnResize(fnc func(e Event)) {
-	w.AddEventListener("resize", fnc)
+func (w *Window) OnResize(fnc func(e Event)) func() {
+	return w.AddEventListener("resize", fnc)
 }
 
 func joinKeyValuePairs(kvpair map[string]string, joiner string) string {
@@ -0,0 +1,210 @@
+// Package worker allows to run Go wasm binaries in dedicated Web Workers.
+//
+// Code in the page can start a worker with New and talk to it using a net.Conn returned by Worker.Conn,
+// see msgport package for details.
+// Code in the worker gets the other side of the connection with Parent. Any protocol that works on top
+// of net.Conn can be used, for example net/rpc:
+//
+//	// in the page
+//	w, err := worker.New("/worker.wasm", nil)
+//	cli := rpc.NewClient(w.Conn())
+//
+//	// in the worker
+//	conn, err := worker.Parent()
+//	rpc.ServeConn(conn)
+//
+// Workers have no access to the DOM. Use HasDocument or IsWorker to check it.
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/dennwc/dom/js"
+	"github.com/dennwc/dom/net/msgport"
+)
+
+// ErrNotWorker is returned by Parent when the code doesn't run in a worker.
+var ErrNotWorker = errors.New("worker: not running in a worker")
+
+var _ net.Addr = Addr{}
+
+// Addr is an address of the page or of a worker.
+type Addr struct {
+	Name string
+}
+
+func (Addr) Network() string {
+	return "worker"
+}
+
+func (a Addr) String() string {
+	return "worker://" + a.Name
+}
+
+// DefaultExecURL is the default location of wasm_exec.js, the same as served by wasm-server.
+const DefaultExecURL = "/wasm_exec.js"
+
+// IsWorker checks if the code runs in a dedicated worker.
+func IsWorker() bool {
+	return !HasDocument() && js.Get("importScripts").Type() == js.TypeFunction &&
+		js.Get("postMessage").Type() == js.TypeFunction
+}
+
+// HasDocument checks if the DOM document is available. It returns false in workers.
+func HasDocument() bool {
+	return js.Get("document").Valid()
+}
+
+// Options are optional parameters for New.
+type Options struct {
+	// Name of the worker, for debugging.
+	Name string
+	// ExecURL is an URL of wasm_exec.js shim. DefaultExecURL is used if not set.
+	ExecURL string
+	// Args are command line arguments passed to the Go program.
+	Args []string
+	// Env is a set of environment variables of the Go program.
+	Env map[string]string
+}
+
+var _ js.Wrapper = (*Worker)(nil)
+
+// Worker is a dedicated Web Worker that runs a Go wasm binary.
+type Worker struct {
+	v    js.Value
+	url  js.Value
+	conn *msgport.Conn
+}
+
+// bootstrap is a worker script that runs Go wasm binary.
+//
+// Messages that arrive before Go code calls Parent are buffered in goWorker.queue.
+// When the Go program exits, the close frame of msgport is sent, so the page can see EOF.
+const bootstrap = `"use strict";
+self.goWorker = {queue: []};
+self.goWorker.listener = (e) => { self.goWorker.queue.push(e); };
+self.addEventListener("message", self.goWorker.listener);
+const conf = %s;
+importScripts(conf.exec);
+const go = new Go();
+go.argv = go.argv.concat(conf.args);
+Object.assign(go.env, conf.env);
+fetch(conf.wasm).then((resp) => resp.arrayBuffer()).then((buf) => {
+	return WebAssembly.instantiate(buf, go.importObject);
+}).then((res) => go.run(res.instance)).then(() => {
+	self.postMessage(new Uint8Array([%d]));
+	self.close();
+}, (err) => {
+	setTimeout(() => { throw err; });
+});
+`
+
+// resolveURL converts an URL relative to the page location to an absolute one. It is required because
+// the bootstrap script is loaded from a blob URL.
+func resolveURL(u string) string {
+	loc := js.Get("location")
+	if !loc.Valid() {
+		return u
+	}
+	return js.New("URL", u, loc.Get("href")).Get("href").String()
+}
+
+// New starts a worker that runs a Go wasm binary located at a given URL. Options are optional.
+//
+// It returns js.ErrNotSupported if workers are not available.
+func New(wasmURL string, opts *Options) (*Worker, error) {
+	if js.Get("Worker").Type() != js.TypeFunction {
+		return nil, js.ErrNotSupported
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+	exec := opts.ExecURL
+	if exec == "" {
+		exec = DefaultExecURL
+	}
+	args := opts.Args
+	if args == nil {
+		args = []string{}
+	}
+	env := opts.Env
+	if env == nil {
+		env = map[string]string{}
+	}
+	conf, err := json.Marshal(map[string]interface{}{
+		"exec": resolveURL(exec),
+		"wasm": resolveURL(wasmURL),
+		"args": args,
+		"env":  env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	script := fmt.Sprintf(bootstrap, conf, msgport.CloseFrame)
+	blob := js.New("Blob", []interface{}{script}, js.Obj{"type": "application/javascript"})
+	u := js.Class("URL").Call("createObjectURL", blob)
+
+	v, err := js.Class("Worker").TryNew(u, js.Obj{"name": opts.Name})
+	if err != nil {
+		js.Class("URL").Call("revokeObjectURL", u)
+		return nil, err
+	}
+	w := &Worker{v: v, url: u}
+	w.conn = newConn(v, "main", opts.Name)
+	w.conn.WatchErrors("error")
+	return w, nil
+}
+
+// newConn creates a connection between the page and the worker on top of a given postMessage target.
+func newConn(target js.Value, local, remote string) *msgport.Conn {
+	return msgport.New(target, &msgport.Options{Local: Addr{Name: local}, Remote: Addr{Name: remote}})
+}
+
+// JSValue implements js.Wrapper.
+func (w *Worker) JSValue() js.Ref {
+	return w.v.JSValue()
+}
+
+// Conn returns a connection to the worker.
+func (w *Worker) Conn() net.Conn {
+	return w.conn
+}
+
+// Terminate immediately stops the worker.
+func (w *Worker) Terminate() {
+	w.conn.Close()
+	w.v.Call("terminate")
+	js.Class("URL").Call("revokeObjectURL", w.url)
+}
+
+var parent struct {
+	once sync.Once
+	conn *msgport.Conn
+}
+
+// Parent returns a connection to the page that started the worker with New. All calls return the same connection.
+//
+// It returns ErrNotWorker if the code doesn't run in a worker.
+func Parent() (net.Conn, error) {
+	if !IsWorker() {
+		return nil, ErrNotWorker
+	}
+	parent.once.Do(func() {
+		self := js.Get("self")
+		c := newConn(self, self.Get("name").String(), "main")
+		if st := self.Get("goWorker"); st.Valid() {
+			// messages received before the connection was created
+			self.Call("removeEventListener", "message", st.Get("listener"))
+			for _, ev := range st.Get("queue").Slice() {
+				c.HandleMessage(ev)
+			}
+			st.Set("queue", js.NewArray())
+		}
+		parent.conn = c
+	})
+	return parent.conn, nil
+}
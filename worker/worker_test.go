@@ -0,0 +1,71 @@
+//+build !wasm
+
+package worker
+
+import (
+	"io"
+	"testing"
+
+	_ "github.com/dennwc/dom/headless"
+	"github.com/dennwc/dom/js"
+	"github.com/dennwc/dom/net/msgport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	require.True(t, HasDocument())
+	require.False(t, IsWorker())
+
+	_, err := Parent()
+	require.Equal(t, ErrNotWorker, err)
+
+	_, err = New("/worker.wasm", nil)
+	require.Equal(t, js.ErrNotSupported, err)
+}
+
+func TestConn(t *testing.T) {
+	ch := js.New("MessageChannel")
+	a := newConn(ch.Get("port1"), "a", "b")
+	b := newConn(ch.Get("port2"), "b", "a")
+	require.Equal(t, "worker://b", a.RemoteAddr().String())
+
+	_, err := a.Write([]byte("hello "))
+	require.NoError(t, err)
+	_, err = a.Write([]byte("world"))
+	require.NoError(t, err)
+
+	// messages of other types are ignored
+	ch.Get("port1").Call("postMessage", "text")
+
+	buf := make([]byte, 11)
+	_, err = io.ReadFull(b, buf)
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(buf))
+
+	_, err = b.Write([]byte("ok"))
+	require.NoError(t, err)
+	buf = make([]byte, 2)
+	_, err = io.ReadFull(a, buf)
+	require.NoError(t, err)
+	require.Equal(t, "ok", string(buf))
+
+	require.NoError(t, a.Close())
+	_, err = b.Read(buf)
+	require.Equal(t, io.EOF, err)
+	_, err = a.Write([]byte("closed"))
+	require.Equal(t, msgport.ErrClosed, err)
+	require.NoError(t, b.Close())
+}
+
+func TestConnExit(t *testing.T) {
+	ch := js.New("MessageChannel")
+	c := newConn(ch.Get("port1"), "main", "w")
+	defer c.Close()
+
+	// the bootstrap script sends the close frame when the Go program in the worker exits
+	ch.Get("port2").Call("postMessage", js.New("Uint8Array", []interface{}{msgport.CloseFrame}))
+	_, err := c.Read(make([]byte, 1))
+	require.Equal(t, io.EOF, err)
+	_, err = c.Write([]byte("x"))
+	require.Equal(t, io.ErrClosedPipe, err)
+}